@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minAccountPasswordLen is the shortest keystore password the GUI accepts.
+const minAccountPasswordLen = 8
+
+// keystoreAccount is one key file in the node's keystore.
+type keystoreAccount struct {
+	Address string // 0x + lowercase hex
+	File    string
+	ModTime time.Time
+}
+
+// nodeKeystoreDir is <NodeDataDir>/keystore, the folder geth loads accounts
+// from and resync keeps.
+func nodeKeystoreDir(cfg *Config) (string, error) {
+	dataDir := strings.TrimSpace(cfg.NodeDataDir)
+	if dataDir == "" {
+		dataDir = defaultNodeDataDir()
+	}
+	dataDir, err := expandUserPath(dataDir)
+	if err != nil {
+		return "", err
+	}
+	if dataDir == "" {
+		return "", errors.New("node data directory is required")
+	}
+	return filepath.Join(dataDir, "keystore"), nil
+}
+
+// listKeystoreAccounts reads the v3 key files in dir, oldest first. Files
+// that are not key files are skipped; a missing dir has no accounts.
+func listKeystoreAccounts(dir string) ([]keystoreAccount, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var accounts []keystoreAccount
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var key struct {
+			Address string `json:"address"`
+		}
+		if json.Unmarshal(b, &key) != nil {
+			continue
+		}
+		addr := "0x" + strings.ToLower(strings.TrimPrefix(key.Address, "0x"))
+		if !isHexAddress(addr) {
+			continue
+		}
+		acc := keystoreAccount{Address: addr, File: path}
+		if info, err := e.Info(); err == nil {
+			acc.ModTime = info.ModTime()
+		}
+		accounts = append(accounts, acc)
+	}
+	// geth names key files UTC--<time>--<address>, so names sort by age.
+	slices.SortFunc(accounts, func(a, b keystoreAccount) int { return strings.Compare(a.File, b.File) })
+	return accounts, nil
+}
+
+// writeSecretFile writes a password or key to a temporary file, readable by
+// the user only, for geth to read. The caller removes it.
+func writeSecretFile(secret string) (string, error) {
+	f, err := os.CreateTemp("", "olivetum-secret-*")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	if _, err := f.WriteString(secret + "\n"); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// runGethAccount runs `geth account <args>` with the password from a
+// temporary file and returns the address of the key file it added.
+func runGethAccount(dataDir, password string, args ...string) (string, error) {
+	gethPath, err := findGeth()
+	if err != nil {
+		return "", fmt.Errorf("geth not found: %w", err)
+	}
+	keystore := filepath.Join(dataDir, "keystore")
+	before, err := listKeystoreAccounts(keystore)
+	if err != nil {
+		return "", err
+	}
+	passwordFile, err := writeSecretFile(password)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(passwordFile)
+
+	cmdArgs := append([]string{"--datadir", dataDir, "account"}, args...)
+	cmdArgs = append(cmdArgs, "--password", passwordFile)
+	cmd := exec.Command(gethPath, cmdArgs...)
+	configureChildProcess(cmd)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("geth account %s failed: %s", args[0], lastLine(msg))
+		}
+		return "", fmt.Errorf("geth account %s failed: %w", args[0], err)
+	}
+
+	after, err := listKeystoreAccounts(keystore)
+	if err != nil {
+		return "", err
+	}
+	for _, acc := range after {
+		if !slices.ContainsFunc(before, func(b keystoreAccount) bool { return b.File == acc.File }) {
+			return acc.Address, nil
+		}
+	}
+	return "", errors.New("geth did not add a key file")
+}
+
+// createNodeAccount creates a new scrypt-encrypted key in the keystore of
+// the node data directory.
+func createNodeAccount(cfg *Config, password string) (string, error) {
+	keystore, err := nodeKeystoreDir(cfg)
+	if err != nil {
+		return "", err
+	}
+	return runGethAccount(filepath.Dir(keystore), password, "new")
+}
+
+// importNodeAccount encrypts a hex private key into the keystore.
+func importNodeAccount(cfg *Config, privateKey, password string) (string, error) {
+	key := strings.TrimPrefix(strings.TrimSpace(privateKey), "0x")
+	if b, err := hex.DecodeString(key); err != nil || len(b) != 32 {
+		return "", errors.New("invalid private key (expected 64 hex chars)")
+	}
+	keystore, err := nodeKeystoreDir(cfg)
+	if err != nil {
+		return "", err
+	}
+	keyFile, err := writeSecretFile(key)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(keyFile)
+	return runGethAccount(filepath.Dir(keystore), password, "import", keyFile)
+}
+
+func validateAccountPassword(password, confirm string) error {
+	if len(password) < minAccountPasswordLen {
+		return fmt.Errorf("password is too short (min %d characters)", minAccountPasswordLen)
+	}
+	if password != confirm {
+		return errors.New("passwords do not match")
+	}
+	return nil
+}
+
+// exportKeystoreFile copies a key file to dst for backup. The copy stays
+// encrypted with the account password.
+func exportKeystoreFile(src string, dst io.Writer) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
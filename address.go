@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const maxAddressBookEntries = 100
+
+// AddressBookEntry is a named address the wallet fields can pick from.
+type AddressBookEntry struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// checksumAddress returns the EIP-55 mixed-case form of a hex address: a
+// letter is upper case when the matching nibble of the Keccak-256 hash of
+// the lowercase hex is 8 or more.
+func checksumAddress(addr string) string {
+	lower := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(addr), "0x"))
+	sum := keccak256([]byte(lower))
+	hash := hex.EncodeToString(sum[:])
+	out := []byte(lower)
+	for i, c := range out {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
+
+// addressChecksumOK reports whether a hex address passes EIP-55. All lower
+// or all upper case addresses carry no checksum and pass.
+func addressChecksumOK(addr string) bool {
+	addr = strings.TrimSpace(addr)
+	body := strings.TrimPrefix(addr, "0x")
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return true
+	}
+	return addr == checksumAddress(addr)
+}
+
+// normalizeAddress lowercases a valid address for storage, as xmrig and
+// geth get it. A checksum mismatch is left as typed so validation can
+// still see it.
+func normalizeAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if !isHexAddress(addr) || !addressChecksumOK(addr) {
+		return addr
+	}
+	return strings.ToLower(addr)
+}
+
+// displayAddress returns the checksummed form of a valid address for the
+// GUI and leaves anything else as is.
+func displayAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if !isHexAddress(addr) || !addressChecksumOK(addr) {
+		return addr
+	}
+	return checksumAddress(addr)
+}
+
+// validateAddressChecksum rejects a mixed-case address whose case does not
+// match its EIP-55 checksum, which usually means a typo.
+func validateAddressChecksum(field, addr string) error {
+	if isHexAddress(addr) && !addressChecksumOK(addr) {
+		return fmt.Errorf("%s checksum mismatch (check the address for a typo)", field)
+	}
+	return nil
+}
+
+// addressHint describes an address field for the hint below it: its book
+// name, a checksum warning, or the checksummed form to compare against.
+func addressHint(addr string, book []AddressBookEntry) (string, bool) {
+	addr = strings.TrimSpace(addr)
+	switch {
+	case addr == "":
+		return "", false
+	case !isHexAddress(addr):
+		return "Not an address (expected 0x + 40 hex chars).", true
+	case !addressChecksumOK(addr):
+		return "Checksum mismatch: this address probably has a typo.", true
+	}
+	if name := addressBookName(book, addr); name != "" {
+		return fmt.Sprintf("%s (%s)", name, checksumAddress(addr)), false
+	}
+	if addr != checksumAddress(addr) {
+		return "Checksummed: " + checksumAddress(addr), false
+	}
+	return "", false
+}
+
+func addressBookName(book []AddressBookEntry, addr string) string {
+	for _, e := range book {
+		if strings.EqualFold(e.Address, addr) {
+			return e.Name
+		}
+	}
+	return ""
+}
+
+func normalizeAddressBook(book []AddressBookEntry) {
+	for i := range book {
+		book[i].Name = strings.TrimSpace(book[i].Name)
+		book[i].Address = displayAddress(book[i].Address)
+	}
+}
+
+func validateAddressBook(book []AddressBookEntry) error {
+	if len(book) > maxAddressBookEntries {
+		return fmt.Errorf("too many address book entries (max %d)", maxAddressBookEntries)
+	}
+	names := make(map[string]bool)
+	for _, e := range book {
+		if e.Name == "" || len(e.Name) > 40 {
+			return errors.New("invalid address book name (1..40 chars)")
+		}
+		if names[strings.ToLower(e.Name)] {
+			return fmt.Errorf("duplicate address book name %q", e.Name)
+		}
+		names[strings.ToLower(e.Name)] = true
+		if !isHexAddress(e.Address) {
+			return fmt.Errorf("invalid address for %q (expected 0x + 40 hex chars)", e.Name)
+		}
+		if err := validateAddressChecksum(fmt.Sprintf("address of %q", e.Name), e.Address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
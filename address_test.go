@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecksumAddress(t *testing.T) {
+	// Test vectors from EIP-55.
+	for _, want := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		if got := checksumAddress(strings.ToLower(want)); got != want {
+			t.Errorf("checksumAddress(%s) = %s", strings.ToLower(want), got)
+		}
+		if !addressChecksumOK(want) {
+			t.Errorf("addressChecksumOK(%s) = false", want)
+		}
+	}
+}
+
+func TestAddressChecksumOK(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false},
+		{"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false},
+	}
+	for _, tt := range tests {
+		if got := addressChecksumOK(tt.addr); got != tt.want {
+			t.Errorf("addressChecksumOK(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+// A mixed-case node mining address must reach validation as written, like
+// the wallet address, instead of being lowercased on load.
+func TestLoadedNodeEtherbaseChecksum(t *testing.T) {
+	tests := []struct {
+		name      string
+		etherbase string
+		want      string
+		wantErr   bool
+	}{
+		{"valid checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false},
+		{"lower case", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false},
+		{"checksum typo", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", true},
+		{"not an address", "0x1234", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig([]byte(`{"walletAddress":"` + testWallet + `","nodeEtherbase":"` + tt.etherbase + `"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			normalizeConfig(cfg)
+			err = validateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfig() = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "node mining address checksum mismatch") {
+				t.Errorf("validateConfig() = %v, want a checksum error", err)
+			}
+			if cfg.NodeEtherbase != tt.want {
+				t.Errorf("NodeEtherbase = %q, want %q", cfg.NodeEtherbase, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAPIBindAddress = "127.0.0.1"
+	defaultAPIPort        = 9480
+	apiDefaultLogLines    = 200
+	apiMaxConfigBody      = 1 << 20
+)
+
+// apiServer is the token-authenticated HTTP control API. It drives the same
+// Controller as the GUI and headless mode.
+type apiServer struct {
+	ctrl  *Controller
+	token string
+	srv   *http.Server
+	addr  string
+
+	// patchMu serializes PATCH requests from reading the config to pushing
+	// it to the running miner.
+	patchMu sync.Mutex
+	// updateConfig changes and saves the config like Controller.Update. The
+	// GUI replaces it to run the change on its own goroutine and refresh the
+	// Setup form.
+	updateConfig func(change func(next *Config) error) error
+}
+
+// statusError is an error with the HTTP status to answer it with, returned
+// from inside a config update.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func apiListenAddress(cfg *Config) string {
+	host := strings.TrimSpace(cfg.APIBindAddress)
+	if host == "" {
+		host = defaultAPIBindAddress
+	}
+	port := cfg.APIPort
+	if port <= 0 {
+		port = defaultAPIPort
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func generateAPIToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func startAPIServer(ctrl *Controller, cfg *Config, updateConfig func(change func(next *Config) error) error) (*apiServer, error) {
+	token := strings.TrimSpace(cfg.APIToken)
+	if len(token) < 16 {
+		return nil, errors.New("API token must be at least 16 characters")
+	}
+	if updateConfig == nil {
+		updateConfig = ctrl.Update
+	}
+	ln, err := net.Listen("tcp", apiListenAddress(cfg))
+	if err != nil {
+		return nil, err
+	}
+	s := &apiServer{
+		ctrl:         ctrl,
+		token:        token,
+		addr:         ln.Addr().String(),
+		updateConfig: updateConfig,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/status", s.handleStatus)
+	mux.HandleFunc("POST /api/v1/miner/start", s.handleMinerStart)
+	mux.HandleFunc("POST /api/v1/miner/stop", s.handleMinerStop)
+	mux.HandleFunc("POST /api/v1/miner/pause", s.handleMinerPause)
+	mux.HandleFunc("POST /api/v1/miner/resume", s.handleMinerResume)
+	mux.HandleFunc("POST /api/v1/miner/restart", s.handleMinerRestart)
+	mux.HandleFunc("POST /api/v1/node/start", s.handleNodeStart)
+	mux.HandleFunc("POST /api/v1/node/stop", s.handleNodeStop)
+	mux.HandleFunc("POST /api/v1/node/reset", s.handleNodeReset)
+	mux.HandleFunc("GET /api/v1/logs/{source}", s.handleLogs)
+	mux.HandleFunc("GET /api/v1/config", s.handleConfigGet)
+	mux.HandleFunc("PATCH /api/v1/config", s.handleConfigPatch)
+	s.srv = &http.Server{
+		Handler:           s.authenticate(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ctrl.appendMinerLog(fmt.Sprintf("[api] %v\n", err))
+		}
+	}()
+	return s, nil
+}
+
+func (s *apiServer) Addr() string {
+	return s.addr
+}
+
+func (s *apiServer) Close() {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.srv.Shutdown(ctx)
+}
+
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="olivetum-miner"`)
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+type apiMinerStats struct {
+	Hashrate       float64 `json:"hashrate"`
+	PerCPUHashrate []int64 `json:"perCpuHashrate"`
+	Threads        int     `json:"threads"`
+	Accepted       int64   `json:"accepted"`
+	Rejected       int64   `json:"rejected"`
+	Invalid        int64   `json:"invalid"`
+	PoolSwitches   int64   `json:"poolSwitches"`
+	Pool           string  `json:"pool"`
+	UptimeMin      int     `json:"uptimeMin"`
+	Difficulty     float64 `json:"difficulty"`
+}
+
+type apiStatus struct {
+	Miner struct {
+		State            string         `json:"state"`
+		Paused           bool           `json:"paused"`
+		Throttled        bool           `json:"throttled"`
+		PausedBy         []string       `json:"pausedBy,omitempty"`
+		PendingRestart   []string       `json:"pendingRestart,omitempty"`
+		Stats            *apiMinerStats `json:"stats,omitempty"`
+		JobBlock         int64          `json:"jobBlock"`
+		JobDifficulty    string         `json:"jobDifficulty"`
+		LastFoundBlock   int64          `json:"lastFoundBlock"`
+		WatchdogRestarts int64          `json:"watchdogRestarts"`
+	} `json:"miner"`
+	Node struct {
+		State string `json:"state"`
+		Mode  string `json:"mode,omitempty"`
+	} `json:"node"`
+}
+
+func (s *apiServer) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	var status apiStatus
+	status.Miner.State = s.ctrl.MinerState().String()
+	status.Miner.Paused = s.ctrl.MinerPaused()
+	status.Miner.Throttled = s.ctrl.Throttled()
+	status.Miner.PausedBy = s.ctrl.PauseReasons()
+	status.Miner.PendingRestart = s.ctrl.PendingRestart()
+	if st, ok := s.ctrl.LastStats(); ok {
+		status.Miner.Stats = &apiMinerStats{
+			Hashrate:       st.Hashrate,
+			PerCPUHashrate: st.PerGPU_KHs,
+			Threads:        st.Threads,
+			Accepted:       st.Accepted,
+			Rejected:       st.Rejected,
+			Invalid:        st.Invalid,
+			PoolSwitches:   st.PoolSwitches,
+			Pool:           st.Pool,
+			UptimeMin:      st.UptimeMin,
+			Difficulty:     st.Stat.Difficulty,
+		}
+	}
+	status.Miner.JobBlock = s.ctrl.CurrentJobBlock()
+	status.Miner.JobDifficulty = s.ctrl.JobDifficulty()
+	status.Miner.LastFoundBlock = s.ctrl.LastFoundBlock()
+	status.Miner.WatchdogRestarts = s.ctrl.WatchdogRestarts()
+	status.Node.State = s.ctrl.NodeState().String()
+	if s.ctrl.NodeRunning() {
+		status.Node.Mode = s.ctrl.NodeRunMode()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *apiServer) handleMinerStart(w http.ResponseWriter, _ *http.Request) {
+	if err := s.ctrl.StartMiner(minerStartOriginUser); err != nil {
+		if errors.Is(err, errMinerAlreadyRunning) {
+			writeAPIError(w, http.StatusConflict, err)
+			return
+		}
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"state": s.ctrl.MinerState().String()})
+}
+
+func (s *apiServer) handleMinerStop(w http.ResponseWriter, _ *http.Request) {
+	s.ctrl.StopMiner(minerStopOriginUser)
+	writeJSON(w, http.StatusAccepted, map[string]string{"state": s.ctrl.MinerState().String()})
+}
+
+func (s *apiServer) handleMinerPause(w http.ResponseWriter, _ *http.Request) {
+	if err := s.ctrl.PauseMiner(); err != nil {
+		writeAPIError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"paused": true})
+}
+
+func (s *apiServer) handleMinerResume(w http.ResponseWriter, _ *http.Request) {
+	if err := s.ctrl.ResumeMiner(); err != nil {
+		writeAPIError(w, http.StatusConflict, err)
+		return
+	}
+	reasons := s.ctrl.PauseReasons()
+	writeJSON(w, http.StatusAccepted, map[string]any{"paused": len(reasons) > 0, "pausedBy": reasons})
+}
+
+func (s *apiServer) handleMinerRestart(w http.ResponseWriter, _ *http.Request) {
+	if err := s.ctrl.RestartMiner(); err != nil {
+		writeAPIError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"state": s.ctrl.MinerState().String()})
+}
+
+func (s *apiServer) nodeSettings(cfg *Config) (nodeStartSettings, bool, error) {
+	requireMiningService := cfg.Mode == modeRPCLocal
+	settings, err := nodeSettingsFromConfig(cfg, requireMiningService)
+	return settings, requireMiningService, err
+}
+
+func (s *apiServer) handleNodeStart(w http.ResponseWriter, _ *http.Request) {
+	if s.ctrl.NodeRunning() {
+		writeAPIError(w, http.StatusConflict, errors.New("node already running"))
+		return
+	}
+	settings, requireMiningService, err := s.nodeSettings(s.ctrl.Snapshot())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.ctrl.StartNode(settings, requireMiningService); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"state": s.ctrl.NodeState().String()})
+}
+
+func (s *apiServer) handleNodeStop(w http.ResponseWriter, _ *http.Request) {
+	s.ctrl.StopNode()
+	writeJSON(w, http.StatusAccepted, map[string]string{"state": s.ctrl.NodeState().String()})
+}
+
+// handleNodeReset wipes the chain data like "Reset node data & resync".
+// With {"start": true} the node is started again once the reset is done.
+func (s *apiServer) handleNodeReset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Start bool `json:"start"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxConfigBody)).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+	}
+	cfg := s.ctrl.Snapshot()
+	if !cfg.NodeEnabled {
+		writeAPIError(w, http.StatusConflict, errors.New("node is disabled"))
+		return
+	}
+	if s.ctrl.NodeState() == nodeStateResetting {
+		writeAPIError(w, http.StatusConflict, errors.New("reset already in progress"))
+		return
+	}
+	dataDir := strings.TrimSpace(cfg.NodeDataDir)
+	if dataDir == "" {
+		dataDir = defaultNodeDataDir()
+	}
+	dataDir, err := expandUserPath(dataDir)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	settings, requireMiningService, err := s.nodeSettings(cfg)
+	if err != nil && req.Start {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	go func() {
+		if err := s.ctrl.ResetNodeData(dataDir); err != nil {
+			s.ctrl.appendNodeLog(fmt.Sprintf("[node] Reset failed: %v\n", err))
+			return
+		}
+		if req.Start {
+			if err := s.ctrl.StartNode(settings, requireMiningService); err != nil {
+				s.ctrl.appendNodeLog(fmt.Sprintf("[node] Start after reset failed: %v\n", err))
+			}
+		}
+	}()
+	writeJSON(w, http.StatusAccepted, map[string]string{"state": nodeStateResetting.String()})
+}
+
+func (s *apiServer) handleLogs(w http.ResponseWriter, r *http.Request) {
+	var buf *ringLogs
+	switch r.PathValue("source") {
+	case "miner":
+		buf = s.ctrl.minerLog
+	case "node":
+		buf = s.ctrl.nodeLog
+	default:
+		writeAPIError(w, http.StatusNotFound, errors.New("unknown log (use miner or node)"))
+		return
+	}
+	n := apiDefaultLogLines
+	if text := r.URL.Query().Get("lines"); text != "" {
+		v, err := strconv.Atoi(text)
+		if err != nil || v < 1 {
+			writeAPIError(w, http.StatusBadRequest, errors.New("invalid lines parameter"))
+			return
+		}
+		n = v
+	}
+	lines := buf.Snapshot()
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"lines": lines})
+}
+
+// redactedConfig is the config as returned by the API: the token is never
+// sent back.
+func (s *apiServer) redactedConfig() Config {
+	out := s.ctrl.Snapshot()
+	out.APIToken = ""
+	return *out
+}
+
+func (s *apiServer) handleConfigGet(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.redactedConfig())
+}
+
+// patchConfig returns cfg with the top-level keys of the JSON object patch
+// replaced. Arrays are replaced as a whole rather than merged element by
+// element, and the result shares no memory with cfg, so a rejected patch
+// leaves cfg untouched.
+func patchConfig(cfg *Config, patch []byte) (*Config, error) {
+	var changes map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		return nil, errors.New("not an object")
+	}
+	cur, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(cur, &raw); err != nil {
+		return nil, err
+	}
+	for k, v := range changes {
+		raw[k] = v
+	}
+	merged, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	next := new(Config)
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// handleConfigPatch merges the JSON body into the current config, then
+// normalizes and validates it like the Setup form before saving. API
+// settings cannot be changed remotely.
+func (s *apiServer) handleConfigPatch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, apiMaxConfigBody))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid config: %w", err))
+		return
+	}
+	s.patchMu.Lock()
+	defer s.patchMu.Unlock()
+	err = s.updateConfig(func(cur *Config) error {
+		next, err := patchConfig(cur, body)
+		if err != nil {
+			return &statusError{http.StatusBadRequest, fmt.Errorf("invalid config: %w", err)}
+		}
+		next.APIEnabled = cur.APIEnabled
+		next.APIBindAddress = cur.APIBindAddress
+		next.APIPort = cur.APIPort
+		next.APIToken = cur.APIToken
+		// A new activeProfile switches to that profile's settings.
+		if target := next.ActiveProfile; !strings.EqualFold(target, cur.ActiveProfile) {
+			next.ActiveProfile = cur.ActiveProfile
+			if err := switchProfile(next, target); err != nil {
+				return &statusError{http.StatusUnprocessableEntity, err}
+			}
+		}
+
+		normalizeConfig(next)
+		if err := validateConfig(next); err != nil {
+			return &statusError{http.StatusUnprocessableEntity, err}
+		}
+		*cur = *next
+		return nil
+	})
+	if err != nil {
+		var se *statusError
+		if errors.As(err, &se) {
+			writeAPIError(w, se.status, se.err)
+		} else {
+			writeAPIError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if _, err := s.ctrl.ApplyConfig(); err != nil {
+		writeAPIError(w, http.StatusBadGateway, fmt.Errorf("config saved, but %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.redactedConfig())
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testWallet = "0x52908400098527886e0f7030069857d2e4169ee7"
+
+func testAPIConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.WalletAddress = testWallet
+	cfg.Pools = []PoolConfig{
+		{Mode: modeStratum, Host: "backup1.example.org", Port: 3333, WalletAddress: testWallet, WorkerName: "rig1"},
+		{Mode: modeStratum, Host: "backup2.example.org", Port: 4444, WalletAddress: testWallet},
+	}
+	cfg.NodeStaticPeers = []string{"enode://" + strings.Repeat("ab", 64) + "@10.0.0.1:30303"}
+	cfg.Schedule = []ScheduleWindow{{Days: []string{"mon", "tue"}, Start: "22:00", End: "06:00"}}
+	syncActiveProfile(cfg)
+	normalizeConfig(cfg)
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("test config does not validate: %v", err)
+	}
+	return cfg
+}
+
+// testAPIServer serves the API for a controller that keeps its config in
+// memory only.
+func testAPIServer(cfg *Config) *apiServer {
+	ctrl := newController(cfg, "", nil)
+	ctrl.save = func(*Config) error { return nil }
+	return &apiServer{ctrl: ctrl, updateConfig: ctrl.Update}
+}
+
+func patchTestConfig(t *testing.T, s *apiServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/config", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.handleConfigPatch(rec, req)
+	return rec
+}
+
+func TestConfigPatchRejectedLeavesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{
+			name: "invalid pool",
+			body: `{"pools":[{"mode":"stratum","host":"evil.example.org","port":0}],"nodeStaticPeers":["x"],"schedule":[{"days":["fri"],"start":"01:00","end":"02:00"}]}`,
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "invalid profile",
+			body: `{"profiles":[{"name":"Default","mode":"stratum","stratumHost":"evil.example.org"}],"activeProfile":"Other"}`,
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "wrong type after arrays",
+			body: `{"pools":[{"host":"evil.example.org"}],"addressBook":[{"name":"x","address":"y"}],"cpuThreads":"many"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "unknown field",
+			body: `{"pools":[{"host":"evil.example.org"}],"bogus":true}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "not an object",
+			body: `[1, 2]`,
+			code: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := testAPIConfig(t)
+			s := testAPIServer(want)
+			rec := patchTestConfig(t, s, tt.body)
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.code)
+			}
+			if cfg := s.ctrl.Snapshot(); !reflect.DeepEqual(cfg, want) {
+				t.Errorf("rejected PATCH changed the config:\n got %+v\nwant %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestConfigPatchReplacesArrays(t *testing.T) {
+	s := testAPIServer(testAPIConfig(t))
+	rec := patchTestConfig(t, s, `{"pools":[{"mode":"stratum","host":"new.example.org","port":5555,"walletAddress":"`+testWallet+`"}],"cpuThreads":2}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s), want 200", rec.Code, rec.Body)
+	}
+	cfg := s.ctrl.Snapshot()
+	// The worker name of the old first pool must not leak into the new one.
+	want := []PoolConfig{{Mode: modeStratum, Host: "new.example.org", Port: 5555, WalletAddress: testWallet}}
+	if !reflect.DeepEqual(cfg.Pools, want) {
+		t.Errorf("pools = %+v, want %+v", cfg.Pools, want)
+	}
+	if cfg.CPUThreads != 2 {
+		t.Errorf("cpuThreads = %d, want 2", cfg.CPUThreads)
+	}
+	if len(cfg.Schedule) != 1 || cfg.WalletAddress != testWallet {
+		t.Errorf("PATCH dropped fields it did not set: %+v", cfg)
+	}
+}
+
+// Concurrent PATCH requests must not lose each other's changes, and readers
+// of the config must not race them (run with -race).
+func TestConfigPatchConcurrent(t *testing.T) {
+	s := testAPIServer(testAPIConfig(t))
+	const rounds = 20
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			cfg := s.ctrl.Snapshot()
+			_ = configuredThreads(cfg) + cfg.DonateLevel + len(cfg.Pools)
+		}
+	}()
+
+	var writers sync.WaitGroup
+	for _, key := range []string{"cpuThreads", "donateLevel"} {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for i := 1; i <= rounds; i++ {
+				if rec := patchTestConfig(t, s, fmt.Sprintf(`{%q:%d}`, key, i)); rec.Code != http.StatusOK {
+					t.Errorf("PATCH %s: status = %d (%s)", key, rec.Code, rec.Body)
+					return
+				}
+			}
+		}()
+	}
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	cfg := s.ctrl.Snapshot()
+	if cfg.CPUThreads != rounds || cfg.DonateLevel != rounds {
+		t.Errorf("cpuThreads = %d, donateLevel = %d, want %d for both", cfg.CPUThreads, cfg.DonateLevel, rounds)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// randomXScratchpadBytes is the L3 share one RandomX thread needs to run
+	// at full speed.
+	randomXScratchpadBytes = 2 << 20
+
+	defaultAutotuneRunSec = 60
+	autotuneConfigName    = "autotune.json"
+)
+
+var errAutotuneRunning = errors.New("auto-tune in progress")
+
+// tuneCandidate is one thread layout tried by the auto-tuner. Affinity lists
+// the logical CPUs to pin one thread each to; with no affinity and no thread
+// count xmrig picks the threads itself.
+type tuneCandidate struct {
+	Name     string
+	Threads  int
+	Affinity []int
+}
+
+type tuneResult struct {
+	Candidate tuneCandidate
+	Hashrate  float64
+	Err       error
+}
+
+// autotuneCandidates derives the layouts worth benchmarking from the CPU
+// topology: xmrig's own choice, every logical CPU, one thread per physical
+// core, as many threads as the L3 cache holds scratchpads for, and each NUMA
+// node on its own.
+func autotuneCandidates(devices []Device, l3Bytes int64) []tuneCandidate {
+	candidates := []tuneCandidate{{Name: "Auto (xmrig default)"}}
+	seen := map[string]bool{}
+	add := func(name string, cpus []int) {
+		if len(cpus) == 0 {
+			return
+		}
+		sorted := append([]int(nil), cpus...)
+		sort.Ints(sorted)
+		key := fmt.Sprint(sorted)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		candidates = append(candidates, tuneCandidate{
+			Name:     fmt.Sprintf("%s (%d threads)", name, len(sorted)),
+			Threads:  len(sorted),
+			Affinity: sorted,
+		})
+	}
+
+	all := make([]int, 0, len(devices))
+	for _, d := range devices {
+		all = append(all, d.Index)
+	}
+	add("All logical CPUs", all)
+
+	// First SMT sibling of every core, then the remaining siblings, so any
+	// prefix spreads threads over as many cores as possible.
+	type coreKey struct{ socket, core int }
+	seenCore := map[coreKey]bool{}
+	var firstSiblings, otherSiblings []int
+	for _, d := range devices {
+		if d.Core < 0 {
+			firstSiblings = append(firstSiblings, d.Index)
+			continue
+		}
+		key := coreKey{d.Socket, d.Core}
+		if seenCore[key] {
+			otherSiblings = append(otherSiblings, d.Index)
+			continue
+		}
+		seenCore[key] = true
+		firstSiblings = append(firstSiblings, d.Index)
+	}
+	if len(otherSiblings) > 0 {
+		add("One per core", firstSiblings)
+	}
+
+	if l3Bytes > 0 {
+		n := int(l3Bytes / randomXScratchpadBytes)
+		ordered := append(append([]int(nil), firstSiblings...), otherSiblings...)
+		if n > 0 && n < len(ordered) {
+			add("L3-sized", ordered[:n])
+		}
+	}
+
+	nodes := map[int][]int{}
+	for _, d := range devices {
+		if d.Node >= 0 {
+			nodes[d.Node] = append(nodes[d.Node], d.Index)
+		}
+	}
+	if len(nodes) > 1 {
+		ids := make([]int, 0, len(nodes))
+		for id := range nodes {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			add(fmt.Sprintf("NUMA node %d", id), nodes[id])
+		}
+	}
+	return candidates
+}
+
+// bestTuneResult returns the successful result with the highest hashrate.
+func bestTuneResult(results []tuneResult) (tuneResult, bool) {
+	var best tuneResult
+	found := false
+	for _, r := range results {
+		if r.Err != nil || r.Hashrate <= 0 {
+			continue
+		}
+		if !found || r.Hashrate > best.Hashrate {
+			best = r
+			found = true
+		}
+	}
+	return best, found
+}
+
+func (c *Controller) Autotuning() bool {
+	return c.autotuning.Load()
+}
+
+// RunAutotune benchmarks each candidate in turn with xmrig's offline
+// benchmark (--stress), so nothing is submitted to a pool. progress is
+// called after every candidate. Mining cannot start while it runs.
+func (c *Controller) RunAutotune(ctx context.Context, candidates []tuneCandidate, runFor time.Duration, progress func(i int, res tuneResult)) ([]tuneResult, error) {
+	if c.xmrigErr != nil {
+		return nil, fmt.Errorf("xmrig not found: %w", c.xmrigErr)
+	}
+	c.procMu.Lock()
+	if c.minerCmd != nil && c.minerCmd.Process != nil {
+		c.procMu.Unlock()
+		return nil, errMinerAlreadyRunning
+	}
+	if !c.autotuning.CompareAndSwap(false, true) {
+		c.procMu.Unlock()
+		return nil, errAutotuneRunning
+	}
+	c.procMu.Unlock()
+	defer c.autotuning.Store(false)
+
+	runXMRigPath := c.xmrigPath
+	if runtime.GOOS == "linux" {
+		p, err := prepareXMRigBinary(c.xmrigPath)
+		if err != nil {
+			return nil, err
+		}
+		runXMRigPath = p
+	}
+	dir, err := xmrigCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	configFile := filepath.Join(dir, autotuneConfigName)
+	defer os.Remove(configFile)
+
+	c.appendMinerLog(fmt.Sprintf("[autotune] Benchmarking %d layouts, %s each\n", len(candidates), runFor))
+	results := make([]tuneResult, 0, len(candidates))
+	for i, cand := range candidates {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		c.appendMinerLog(fmt.Sprintf("[autotune] %s...\n", cand.Name))
+		hashrate, err := c.benchmarkLayout(ctx, runXMRigPath, configFile, cand, runFor)
+		if err != nil && ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		res := tuneResult{Candidate: cand, Hashrate: hashrate, Err: err}
+		if err != nil {
+			c.appendMinerLog(fmt.Sprintf("[autotune] %s: %v\n", cand.Name, err))
+		} else {
+			c.appendMinerLog(fmt.Sprintf("[autotune] %s: %s\n", cand.Name, formatHashrate(hashrate)))
+		}
+		results = append(results, res)
+		if progress != nil {
+			progress(i, res)
+		}
+	}
+	if best, ok := bestTuneResult(results); ok {
+		c.appendMinerLog(fmt.Sprintf("[autotune] Best: %s at %s\n", best.Candidate.Name, formatHashrate(best.Hashrate)))
+	}
+	return results, nil
+}
+
+// benchmarkLayout runs xmrig --stress with cand's threads and returns the
+// average hashrate over runFor, measured once the RandomX dataset is ready
+// and the first 10-second average has settled.
+func (c *Controller) benchmarkLayout(ctx context.Context, xmrigPath, configFile string, cand tuneCandidate, runFor time.Duration) (float64, error) {
+	port, err := pickFreePort()
+	if err != nil {
+		return 0, err
+	}
+	token, err := generateAPIToken()
+	if err != nil {
+		return 0, err
+	}
+	// The pool is not contacted in benchmark mode; a local daemon pool keeps
+	// the config valid without a wallet.
+	trial := c.Snapshot()
+	trial.Mode = modeRPCLocal
+	trial.RPCURL = defaultRPCURL
+	trial.Pools = nil
+	trial.CPUThreads = cand.Threads
+	trial.CPUAffinity = cand.Affinity
+	xc, err := buildXMRigConfig(trial, port, token)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeXMRigConfigFile(xc, configFile); err != nil {
+		return 0, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := c.commandContext(runCtx, xmrigPath, "--config", configFile, "--stress")
+	configureChildProcess(cmd)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	defer func() {
+		select {
+		case <-exited:
+			return
+		default:
+		}
+		_ = sendProcessInterrupt(cmd.Process)
+		select {
+		case <-exited:
+		case <-time.After(10 * time.Second):
+			cancel()
+			<-exited
+		}
+	}()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	readyBy := time.Now().Add(3 * time.Minute)
+	var (
+		measureFrom time.Time
+		sum         float64
+		samples     int
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case err := <-exited:
+			exited <- err
+			return 0, fmt.Errorf("xmrig exited early: %s", lastOutputLine(output.String(), err))
+		case <-ticker.C:
+		}
+
+		st, err := getSummary("127.0.0.1", port, token)
+		if err != nil || st.TotalHashrate <= 0 {
+			if measureFrom.IsZero() && time.Now().After(readyBy) {
+				return 0, errors.New("no hashrate reported within 3 minutes")
+			}
+			continue
+		}
+		if measureFrom.IsZero() {
+			measureFrom = time.Now().Add(10 * time.Second)
+			continue
+		}
+		if time.Now().Before(measureFrom) {
+			continue
+		}
+		sum += st.TotalHashrate
+		samples++
+		if time.Since(measureFrom) >= runFor {
+			break
+		}
+	}
+	if samples == 0 {
+		return 0, errors.New("no hashrate samples")
+	}
+	return sum / float64(samples), nil
+}
+
+func lastOutputLine(output string, err error) string {
+	lines := strings.Split(strings.TrimSpace(sanitizeLogLine(output)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return "no output"
+}
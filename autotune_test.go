@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// smtDevices returns cores*threads logical CPUs numbered like Linux does:
+// the first sibling of every core, then the second ones.
+func smtDevices(cores, threads int) []Device {
+	var devices []Device
+	for t := range threads {
+		for c := range cores {
+			devices = append(devices, Device{Index: t*cores + c, Core: c, Node: 0})
+		}
+	}
+	return devices
+}
+
+func TestAutotuneCandidates(t *testing.T) {
+	auto := tuneCandidate{Name: "Auto (xmrig default)"}
+	cand := func(name string, cpus ...int) tuneCandidate {
+		return tuneCandidate{Name: name, Threads: len(cpus), Affinity: cpus}
+	}
+	tests := []struct {
+		name    string
+		devices []Device
+		l3      int64
+		want    []tuneCandidate
+	}{
+		{
+			name:    "SMT with a small L3",
+			devices: smtDevices(4, 2),
+			l3:      6 << 20,
+			want: []tuneCandidate{
+				auto,
+				cand("All logical CPUs (8 threads)", 0, 1, 2, 3, 4, 5, 6, 7),
+				cand("One per core (4 threads)", 0, 1, 2, 3),
+				cand("L3-sized (3 threads)", 0, 1, 2),
+			},
+		},
+		{
+			name:    "L3 fits more than the cores",
+			devices: smtDevices(4, 2),
+			l3:      12 << 20,
+			want: []tuneCandidate{
+				auto,
+				cand("All logical CPUs (8 threads)", 0, 1, 2, 3, 4, 5, 6, 7),
+				cand("One per core (4 threads)", 0, 1, 2, 3),
+				cand("L3-sized (6 threads)", 0, 1, 2, 3, 4, 5),
+			},
+		},
+		{
+			name:    "L3-sized equals one per core",
+			devices: smtDevices(2, 2),
+			l3:      4 << 20,
+			want: []tuneCandidate{
+				auto,
+				cand("All logical CPUs (4 threads)", 0, 1, 2, 3),
+				cand("One per core (2 threads)", 0, 1),
+			},
+		},
+		{
+			name:    "no SMT and an L3 for every CPU",
+			devices: smtDevices(4, 1),
+			l3:      32 << 20,
+			want: []tuneCandidate{
+				auto,
+				cand("All logical CPUs (4 threads)", 0, 1, 2, 3),
+			},
+		},
+		{
+			name: "two NUMA nodes",
+			devices: []Device{
+				{Index: 0, Core: 0, Socket: 0, Node: 0},
+				{Index: 1, Core: 1, Socket: 0, Node: 0},
+				{Index: 2, Core: 0, Socket: 1, Node: 1},
+				{Index: 3, Core: 1, Socket: 1, Node: 1},
+				{Index: 4, Core: 0, Socket: 0, Node: 0},
+				{Index: 5, Core: 1, Socket: 0, Node: 0},
+				{Index: 6, Core: 0, Socket: 1, Node: 1},
+				{Index: 7, Core: 1, Socket: 1, Node: 1},
+			},
+			want: []tuneCandidate{
+				auto,
+				cand("All logical CPUs (8 threads)", 0, 1, 2, 3, 4, 5, 6, 7),
+				cand("One per core (4 threads)", 0, 1, 2, 3),
+				cand("NUMA node 0 (4 threads)", 0, 1, 4, 5),
+				cand("NUMA node 1 (4 threads)", 2, 3, 6, 7),
+			},
+		},
+		{
+			name: "unknown topology",
+			devices: []Device{
+				{Index: 0, Core: -1, Node: -1},
+				{Index: 1, Core: -1, Node: -1},
+			},
+			l3: 2 << 20,
+			want: []tuneCandidate{
+				auto,
+				cand("All logical CPUs (2 threads)", 0, 1),
+				cand("L3-sized (1 threads)", 0),
+			},
+		},
+		{
+			name: "no CPUs",
+			want: []tuneCandidate{auto},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autotuneCandidates(tt.devices, tt.l3); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("autotuneCandidates() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestTuneResult(t *testing.T) {
+	results := []tuneResult{
+		{Candidate: tuneCandidate{Name: "a"}, Hashrate: 900},
+		{Candidate: tuneCandidate{Name: "b"}, Hashrate: 2000, Err: errAutotuneRunning},
+		{Candidate: tuneCandidate{Name: "c"}, Hashrate: 1200},
+		{Candidate: tuneCandidate{Name: "d"}, Hashrate: 1200},
+	}
+	if best, ok := bestTuneResult(results); !ok || best.Candidate.Name != "c" {
+		t.Errorf("bestTuneResult() = %+v, %v, want c", best, ok)
+	}
+	if _, ok := bestTuneResult(results[1:2]); ok {
+		t.Error("bestTuneResult() picked a failed run")
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	blocksFileName = "blocks.json"
+
+	blockCheckInterval = time.Minute
+	// A canonical block is re-checked until it is blockFinalConfirmations
+	// deep. An uncle can be included up to blockUncleWindow blocks later;
+	// a block that is neither canonical nor an uncle after that is orphaned.
+	blockFinalConfirmations = 64
+	blockUncleWindow        = 7
+	maxLedgerBlocks         = 1000
+
+	blockSourceNode  = "node"
+	blockSourceShare = "share"
+
+	blockStatusPending   = "pending"
+	blockStatusCanonical = "canonical"
+	blockStatusUncle     = "uncle"
+	blockStatusOrphaned  = "orphaned"
+)
+
+// nodeBlockHash matches the hash geth logs for a sealed block, either full
+// or shortened to its first and last bytes ("abcdef..123456").
+var nodeBlockHash = regexp.MustCompile(`\bhash=(0x[0-9a-fA-F]{64}|[0-9a-fA-F]+\.\.[0-9a-fA-F]+)`)
+
+// foundBlock is a candidate block in the ledger. Source tells whether geth
+// logged it as sealed or xmrig had a share accepted at that height.
+type foundBlock struct {
+	Height        int64     `json:"height"`
+	FoundAt       time.Time `json:"foundAt"`
+	Mode          string    `json:"mode"`
+	Source        string    `json:"source"`
+	Hash          string    `json:"hash,omitempty"`
+	Status        string    `json:"status"`
+	Confirmations int64     `json:"confirmations,omitempty"`
+	CheckedAt     time.Time `json:"checkedAt,omitempty"`
+}
+
+// blockLedger keeps every candidate block in blocks.json next to the config
+// and verifies them against the chain through the node RPC.
+type blockLedger struct {
+	path string
+	// config returns the current settings; the ledger only reads them.
+	config func() *Config
+
+	mu       sync.Mutex
+	blocks   []foundBlock
+	onChange func()
+	cancel   context.CancelFunc
+}
+
+func blocksPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), blocksFileName), nil
+}
+
+func openBlockLedger(config func() *Config) (*blockLedger, error) {
+	path, err := blocksPath()
+	if err != nil {
+		return nil, err
+	}
+	l := &blockLedger{path: path, config: config}
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &l.blocks); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return l, nil
+}
+
+// Start verifies the ledger every blockCheckInterval while a node RPC is
+// reachable. onChange runs on the ledger goroutine after every update.
+func (l *blockLedger) Start(ctrl *Controller, onChange func()) {
+	if l == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.mu.Lock()
+	l.onChange = onChange
+	l.cancel = cancel
+	l.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(blockCheckInterval)
+		defer ticker.Stop()
+		failing := false
+		for {
+			if endpoint := chainRPCEndpoint(ctrl); endpoint != "" {
+				err := l.verify(ctx, endpoint, time.Now())
+				if err != nil && !failing && ctx.Err() == nil && l.hasUnsettled() {
+					ctrl.appendMinerLog(fmt.Sprintf("[blocks] Verification failed: %v\n", err))
+				}
+				failing = err != nil
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (l *blockLedger) Close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	cancel := l.cancel
+	l.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Record adds a candidate block from a found-block event. A second report
+// of the same height only fills in the hash.
+func (l *blockLedger) Record(ev Event) error {
+	if l == nil || ev.Block <= 0 {
+		return nil
+	}
+	mode := l.config().Mode
+	l.mu.Lock()
+	i := slices.IndexFunc(l.blocks, func(b foundBlock) bool { return b.Height == ev.Block })
+	if i >= 0 {
+		if l.blocks[i].Hash != "" || ev.Hash == "" {
+			l.mu.Unlock()
+			return nil
+		}
+		l.blocks[i].Hash = ev.Hash
+	} else {
+		l.blocks = append(l.blocks, foundBlock{
+			Height:  ev.Block,
+			FoundAt: time.Now(),
+			Mode:    mode,
+			Source:  ev.Source,
+			Hash:    ev.Hash,
+			Status:  blockStatusPending,
+		})
+		if len(l.blocks) > maxLedgerBlocks {
+			l.blocks = slices.Delete(l.blocks, 0, len(l.blocks)-maxLedgerBlocks)
+		}
+	}
+	err := l.saveLocked()
+	onChange := l.onChange
+	l.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+	return err
+}
+
+// Blocks returns the ledger, newest first.
+func (l *blockLedger) Blocks() []foundBlock {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	out := slices.Clone(l.blocks)
+	l.mu.Unlock()
+	slices.Reverse(out)
+	return out
+}
+
+func (l *blockLedger) hasUnsettled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.ContainsFunc(l.blocks, func(b foundBlock) bool { return b.Status == blockStatusPending })
+}
+
+func (l *blockLedger) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(l.blocks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(l.path, append(b, '\n'), 0o644)
+}
+
+// needsCheck reports whether b can still change: it is undecided, or recent
+// enough that a reorg could still replace it.
+func (b foundBlock) needsCheck(head int64) bool {
+	if b.Status == blockStatusPending {
+		return true
+	}
+	if b.Status == blockStatusCanonical && b.Confirmations < blockFinalConfirmations {
+		return true
+	}
+	return head-b.Height < blockFinalConfirmations
+}
+
+type chainBlock struct {
+	Hash      string   `json:"hash"`
+	Miner     string   `json:"miner"`
+	Timestamp string   `json:"timestamp"`
+	Uncles    []string `json:"uncles"`
+}
+
+// verify settles the ledger against the chain behind endpoint: a block is
+// canonical when the block at its height was mined by one of our addresses,
+// an uncle when a later block includes it as one, and orphaned when neither
+// holds once the uncle window has passed.
+func (l *blockLedger) verify(ctx context.Context, endpoint string, now time.Time) error {
+	head, err := rpcHexInt(ctx, endpoint, "eth_blockNumber")
+	if err != nil {
+		return err
+	}
+	cfg := l.config()
+	ours := miningAddresses(cfg)
+	mode := cfg.Mode
+	l.mu.Lock()
+	todo := slices.Clone(l.blocks)
+	l.mu.Unlock()
+	if mode == modeRPCLocal {
+		// Our own node mines to its coinbase when no mining address is set.
+		if result, err := rpcCall(ctx, endpoint, "eth_coinbase", nil); err == nil {
+			var coinbase string
+			if json.Unmarshal(result, &coinbase) == nil && isHexAddress(coinbase) {
+				ours[strings.ToLower(coinbase)] = true
+			}
+		}
+	}
+
+	changed := false
+	var verifyErr error
+check:
+	for i, b := range todo {
+		if b.Height > head || !b.needsCheck(head) {
+			continue
+		}
+		blk, err := rpcBlockByNumber(ctx, endpoint, b.Height)
+		if err != nil {
+			verifyErr = err
+			break
+		}
+		next := b
+		next.CheckedAt = now
+		switch {
+		case blk != nil && ours[strings.ToLower(blk.Miner)] && blockHashMatches(b.Hash, blk.Hash):
+			next.Status = blockStatusCanonical
+			next.Hash = blk.Hash
+			next.Confirmations = head - b.Height + 1
+		default:
+			uncle, err := findOurUncle(ctx, endpoint, b, head, ours)
+			if err != nil {
+				verifyErr = err
+				break check
+			}
+			next.Confirmations = 0
+			switch {
+			case uncle != "":
+				next.Status = blockStatusUncle
+				next.Hash = uncle
+			case head >= b.Height+blockUncleWindow:
+				next.Status = blockStatusOrphaned
+			default:
+				next.Status = blockStatusPending
+			}
+		}
+		if next != b {
+			todo[i] = next
+			changed = true
+		}
+	}
+	if !changed {
+		return verifyErr
+	}
+
+	l.mu.Lock()
+	for _, b := range todo {
+		i := slices.IndexFunc(l.blocks, func(o foundBlock) bool { return o.Height == b.Height })
+		if i < 0 {
+			continue
+		}
+		// Record may have added the hash meanwhile.
+		if b.Hash == "" {
+			b.Hash = l.blocks[i].Hash
+		}
+		l.blocks[i] = b
+	}
+	err = l.saveLocked()
+	onChange := l.onChange
+	l.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+	if verifyErr != nil {
+		return verifyErr
+	}
+	return err
+}
+
+// findOurUncle looks for b among the uncles of the blocks that could
+// include it and returns the uncle's hash.
+func findOurUncle(ctx context.Context, endpoint string, b foundBlock, head int64, ours map[string]bool) (string, error) {
+	for n := b.Height + 1; n <= min(head, b.Height+blockUncleWindow); n++ {
+		blk, err := rpcBlockByNumber(ctx, endpoint, n)
+		if err != nil {
+			return "", err
+		}
+		if blk == nil {
+			continue
+		}
+		for i := range blk.Uncles {
+			result, err := rpcCall(ctx, endpoint, "eth_getUncleByBlockNumberAndIndex", []any{fmt.Sprintf("0x%x", n), fmt.Sprintf("0x%x", i)})
+			if err != nil {
+				return "", err
+			}
+			var uncle struct {
+				chainBlock
+				Number string `json:"number"`
+			}
+			if err := json.Unmarshal(result, &uncle); err != nil {
+				return "", err
+			}
+			if uncle.Number != fmt.Sprintf("0x%x", b.Height) {
+				continue
+			}
+			if ours[strings.ToLower(uncle.Miner)] && blockHashMatches(b.Hash, uncle.Hash) {
+				return uncle.Hash, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// rpcBlockByNumber returns the canonical block at height, or nil when the
+// node does not have it.
+func rpcBlockByNumber(ctx context.Context, endpoint string, height int64) (*chainBlock, error) {
+	result, err := rpcCall(ctx, endpoint, "eth_getBlockByNumber", []any{fmt.Sprintf("0x%x", height), false})
+	if err != nil {
+		return nil, err
+	}
+	var blk *chainBlock
+	if err := json.Unmarshal(result, &blk); err != nil {
+		return nil, err
+	}
+	return blk, nil
+}
+
+// blockHashMatches compares a recorded hash, which may be empty or shortened
+// by geth's log output, with a full block hash.
+func blockHashMatches(recorded, full string) bool {
+	recorded = strings.ToLower(strings.TrimPrefix(recorded, "0x"))
+	full = strings.ToLower(strings.TrimPrefix(full, "0x"))
+	if recorded == "" {
+		return true
+	}
+	if prefix, suffix, ok := strings.Cut(recorded, ".."); ok {
+		return strings.HasPrefix(full, prefix) && strings.HasSuffix(full, suffix)
+	}
+	return recorded == full
+}
+
+// miningAddresses is the set of lowercase addresses our blocks pay to: the
+// wallets of all pools and the node's mining address.
+func miningAddresses(cfg *Config) map[string]bool {
+	ours := make(map[string]bool)
+	add := func(addr string) {
+		if isHexAddress(addr) {
+			ours[strings.ToLower(addr)] = true
+		}
+	}
+	add(cfg.WalletAddress)
+	add(cfg.NodeEtherbase)
+	for _, p := range cfg.Pools {
+		add(p.WalletAddress)
+	}
+	return ours
+}
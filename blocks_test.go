@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestRPC serves JSON-RPC from handle and returns its endpoint. An error
+// from handle is sent as an RPC error.
+func newTestRPC(t *testing.T, handle func(method string, params []json.RawMessage) (any, error)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := map[string]any{"jsonrpc": "2.0", "id": 1}
+		if result, err := handle(req.Method, req.Params); err != nil {
+			resp["error"] = map[string]any{"code": -32000, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func testBlockHash(n int64) string {
+	return fmt.Sprintf("0x%064x", 0xabc000+n)
+}
+
+func TestBlockHashMatches(t *testing.T) {
+	full := "0xabcdef" + fmt.Sprintf("%052d", 0) + "001234"
+	tests := []struct {
+		recorded string
+		want     bool
+	}{
+		{"", true},
+		{full, true},
+		{"0xABCDEF" + full[8:], true},
+		{full[2:], true},
+		{"abcd..1234", true},
+		{"ABCDEF..001234", true},
+		{"abcd..9999", false},
+		{"ffff..1234", false},
+		{"0x" + fmt.Sprintf("%064d", 1), false},
+	}
+	for _, tt := range tests {
+		if got := blockHashMatches(tt.recorded, full); got != tt.want {
+			t.Errorf("blockHashMatches(%q) = %v, want %v", tt.recorded, got, tt.want)
+		}
+	}
+}
+
+func TestBlockNeedsCheck(t *testing.T) {
+	const head = 1000
+	shallow, deep := int64(head-10), int64(head-blockFinalConfirmations)
+	tests := []struct {
+		status        string
+		height        int64
+		confirmations int64
+		want          bool
+	}{
+		{blockStatusPending, shallow, 0, true},
+		{blockStatusPending, deep, 0, true},
+		{blockStatusCanonical, shallow, 11, true},
+		{blockStatusCanonical, deep, blockFinalConfirmations - 1, true},
+		{blockStatusCanonical, deep, blockFinalConfirmations, false},
+		{blockStatusUncle, shallow, 0, true},
+		{blockStatusUncle, deep, 0, false},
+		{blockStatusOrphaned, shallow, 0, true},
+		{blockStatusOrphaned, deep, 0, false},
+	}
+	for _, tt := range tests {
+		b := foundBlock{Height: tt.height, Status: tt.status, Confirmations: tt.confirmations}
+		if got := b.needsCheck(head); got != tt.want {
+			t.Errorf("%s at depth %d with %d confirmations: needsCheck = %v, want %v", tt.status, head-tt.height, tt.confirmations, got, tt.want)
+		}
+	}
+}
+
+func TestBlockLedgerVerify(t *testing.T) {
+	const head = 110
+	other := "0x" + fmt.Sprintf("%040d", 7)
+	// Block 101 lost to another miner but was included as an uncle of 103.
+	endpoint := newTestRPC(t, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "eth_blockNumber":
+			return fmt.Sprintf("0x%x", head), nil
+		case "eth_getBlockByNumber":
+			var hex string
+			_ = json.Unmarshal(params[0], &hex)
+			n, err := parseHexQuantity(hex)
+			if err != nil || n > head {
+				return nil, nil
+			}
+			if n == 20 {
+				return nil, fmt.Errorf("settled block %d re-checked", n)
+			}
+			blk := chainBlock{Hash: testBlockHash(n), Miner: other, Uncles: []string{}}
+			switch n {
+			case 100:
+				blk.Miner = testWallet
+			case 103:
+				blk.Uncles = []string{testBlockHash(1101)}
+			}
+			return blk, nil
+		case "eth_getUncleByBlockNumberAndIndex":
+			if string(params[0]) != `"0x67"` || string(params[1]) != `"0x0"` {
+				return nil, fmt.Errorf("unexpected uncle %s/%s", params[0], params[1])
+			}
+			return map[string]any{"hash": testBlockHash(1101), "miner": testWallet, "number": "0x65"}, nil
+		}
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+
+	cfg := defaultConfig()
+	cfg.Mode = modeStratum
+	cfg.WalletAddress = testWallet
+	short := testBlockHash(100)
+	l := &blockLedger{
+		path:   filepath.Join(t.TempDir(), blocksFileName),
+		config: func() *Config { return cfg },
+		blocks: []foundBlock{
+			{Height: 20, Status: blockStatusCanonical, Confirmations: blockFinalConfirmations, Hash: testBlockHash(20)},
+			{Height: 100, Status: blockStatusPending, Hash: short[2:8] + ".." + short[60:]},
+			{Height: 101, Status: blockStatusPending},
+			{Height: 102, Status: blockStatusPending},
+			{Height: 108, Status: blockStatusPending},
+			{Height: 111, Status: blockStatusPending},
+		},
+	}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := l.verify(context.Background(), endpoint, now); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]foundBlock{
+		20:  {Height: 20, Status: blockStatusCanonical, Confirmations: blockFinalConfirmations, Hash: testBlockHash(20)},
+		100: {Height: 100, Status: blockStatusCanonical, Confirmations: head - 100 + 1, Hash: testBlockHash(100), CheckedAt: now},
+		101: {Height: 101, Status: blockStatusUncle, Hash: testBlockHash(1101), CheckedAt: now},
+		// Past the uncle window without being included.
+		102: {Height: 102, Status: blockStatusOrphaned, CheckedAt: now},
+		// Still inside the uncle window.
+		108: {Height: 108, Status: blockStatusPending, CheckedAt: now},
+		// Above the head.
+		111: {Height: 111, Status: blockStatusPending},
+	}
+	check := func(blocks []foundBlock) {
+		t.Helper()
+		if len(blocks) != len(want) {
+			t.Fatalf("%d blocks, want %d", len(blocks), len(want))
+		}
+		for _, b := range blocks {
+			if w := want[b.Height]; !b.CheckedAt.Equal(w.CheckedAt) || b.Status != w.Status || b.Hash != w.Hash || b.Confirmations != w.Confirmations {
+				t.Errorf("block %d = %+v, want %+v", b.Height, b, w)
+			}
+		}
+	}
+	check(l.Blocks())
+
+	b, err := os.ReadFile(l.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []foundBlock
+	if err := json.Unmarshal(b, &saved); err != nil {
+		t.Fatal(err)
+	}
+	check(saved)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// configChanges compares the config the running miner was started with to
+// next. hot lists the settings xmrig takes over PUT /1/config (or that only
+// drive the watchdog and failback sessions); restart lists the ones xmrig
+// reads at start only. The mode is restart-only because StartMiner checks
+// the node and RPC endpoint for local solo mining.
+func configChanges(running, next *Config) (hot, restart []string) {
+	if running.Mode != next.Mode {
+		restart = append(restart, "Mode")
+	} else if !slices.Equal(miningPools(running), miningPools(next)) {
+		hot = append(hot, "Pools")
+	}
+	if running.CPUThreads != next.CPUThreads || !slices.Equal(running.CPUAffinity, next.CPUAffinity) {
+		hot = append(hot, "CPU threads")
+	}
+	if running.DonateLevel != next.DonateLevel {
+		hot = append(hot, "Donate level")
+	}
+	if running.CPUPriority != next.CPUPriority {
+		hot = append(hot, "Thread priority")
+	}
+	if running.CPUYield != next.CPUYield {
+		hot = append(hot, "Yield")
+	}
+	if running.DisplayInterval != next.DisplayInterval {
+		hot = append(hot, "Display interval")
+	}
+	if watchdogChanged(running, next) {
+		hot = append(hot, "Watchdog")
+	}
+	if running.PoolFailbackEnabled != next.PoolFailbackEnabled || running.PoolFailbackMin != next.PoolFailbackMin {
+		hot = append(hot, "Pool failback")
+	}
+
+	if running.UseHugePages != next.UseHugePages {
+		restart = append(restart, "Huge pages")
+	}
+	if running.RandomX1GBPages != next.RandomX1GBPages {
+		restart = append(restart, "1 GB pages")
+	}
+	if running.RandomXMode != next.RandomXMode {
+		restart = append(restart, "RandomX mode")
+	}
+	if running.EnableMSR != next.EnableMSR {
+		restart = append(restart, "MSR")
+	}
+	return hot, restart
+}
+
+func watchdogChanged(running, next *Config) bool {
+	return running.WatchdogEnabled != next.WatchdogEnabled ||
+		running.WatchdogNoJobTimeoutSec != next.WatchdogNoJobTimeoutSec ||
+		running.WatchdogRestartDelaySec != next.WatchdogRestartDelaySec ||
+		running.WatchdogRetryWindowMin != next.WatchdogRetryWindowMin
+}
+
+// mergeHotSettings returns running with the hot settings of next, keeping
+// the restart-only ones xmrig is still running with.
+func mergeHotSettings(running, next *Config) *Config {
+	merged := cloneConfig(running)
+	if running.Mode == next.Mode {
+		merged.StratumHost = next.StratumHost
+		merged.StratumPort = next.StratumPort
+		merged.RPCURL = next.RPCURL
+		merged.WalletAddress = next.WalletAddress
+		merged.WorkerName = next.WorkerName
+		merged.Pools = slices.Clone(next.Pools)
+	}
+	merged.CPUThreads = next.CPUThreads
+	merged.CPUAffinity = slices.Clone(next.CPUAffinity)
+	merged.DonateLevel = next.DonateLevel
+	merged.CPUPriority = next.CPUPriority
+	merged.CPUYield = next.CPUYield
+	merged.DisplayInterval = next.DisplayInterval
+	merged.WatchdogEnabled = next.WatchdogEnabled
+	merged.WatchdogNoJobTimeoutSec = next.WatchdogNoJobTimeoutSec
+	merged.WatchdogRestartDelaySec = next.WatchdogRestartDelaySec
+	merged.WatchdogRetryWindowMin = next.WatchdogRetryWindowMin
+	merged.PoolFailbackEnabled = next.PoolFailbackEnabled
+	merged.PoolFailbackMin = next.PoolFailbackMin
+	return merged
+}
+
+// PendingRestart lists the saved settings the running miner does not use
+// yet because xmrig only reads them at start. It is empty while the miner
+// is stopped.
+func (c *Controller) PendingRestart() []string {
+	c.procMu.Lock()
+	running := c.minerConfig
+	if c.minerCmd == nil || c.minerCmd.Process == nil || running == nil {
+		c.procMu.Unlock()
+		return nil
+	}
+	next := c.Snapshot()
+	c.procMu.Unlock()
+	_, restart := configChanges(running, next)
+	return restart
+}
+
+// ApplyConfig pushes the saved settings that can change at runtime to the
+// running miner and returns the ones that still need a restart. Callers
+// must validate and save the config first. It does nothing while the miner
+// is stopped; the next start uses the saved config anyway.
+func (c *Controller) ApplyConfig() ([]string, error) {
+	c.procMu.Lock()
+	running := c.minerConfig
+	if c.minerCmd == nil || c.minerCmd.Process == nil || running == nil {
+		c.procMu.Unlock()
+		return nil, nil
+	}
+	port := c.apiPort
+	token := c.xmrigToken
+	limit := c.minerLimit
+	next := c.Snapshot()
+	c.procMu.Unlock()
+
+	hot, restart := configChanges(running, next)
+	if len(hot) == 0 {
+		return restart, nil
+	}
+	merged := mergeHotSettings(running, next)
+	limited := applyThreadLimit(merged, limit)
+	xc, err := buildXMRigConfig(limited, port, token)
+	if err != nil {
+		return restart, err
+	}
+	if err := putXMRigConfig("127.0.0.1", port, token, xc); err != nil {
+		return restart, fmt.Errorf("failed to update the running miner: %w", err)
+	}
+	if _, err := writeXMRigConfig(xc); err != nil {
+		c.appendMinerLog(fmt.Sprintf("[config] failed to write xmrig config: %v\n", err))
+	}
+
+	c.procMu.Lock()
+	// A restart in the meantime already picked up the saved config.
+	if c.minerConfig == running {
+		c.minerConfig = merged
+		c.minerPools = miningPools(merged)
+		c.setDeviceMap(limited.CPUAffinity)
+	}
+	c.procMu.Unlock()
+	c.appendMinerLog(fmt.Sprintf("[config] Applied to running miner: %s\n", strings.Join(hot, ", ")))
+
+	if watchdogChanged(running, merged) {
+		c.stopWatchdogSession()
+		if merged.WatchdogEnabled {
+			c.startWatchdogSession(watchdogSettings{
+				NoJobTimeout: time.Duration(merged.WatchdogNoJobTimeoutSec) * time.Second,
+				RestartDelay: time.Duration(merged.WatchdogRestartDelaySec) * time.Second,
+				RetryWindow:  time.Duration(merged.WatchdogRetryWindowMin) * time.Minute,
+			})
+		}
+	}
+	if slices.Contains(hot, "Pools") || slices.Contains(hot, "Pool failback") {
+		c.stopFailbackSession()
+		if merged.PoolFailbackEnabled && len(c.poolsSnapshot()) > 1 {
+			c.startFailbackSession(primaryPool(merged), time.Duration(merged.PoolFailbackMin)*time.Minute)
+		}
+	}
+	c.emit(Event{Kind: eventMinerState, MinerState: c.MinerState()})
+	return restart, nil
+}
+
+// RestartMiner restarts a running miner so it picks up settings that need a
+// restart. Pause holds and the watchdog and failback sessions carry over.
+func (c *Controller) RestartMiner() error {
+	if !c.MinerRunning() {
+		return errMinerNotRunning
+	}
+	if !c.watchdogRestarting.CompareAndSwap(false, true) {
+		return nil
+	}
+	c.appendMinerLog("[config] Restarting miner to apply settings.\n")
+	go c.restartMiner(context.Background(), time.Second, "[config]")
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestConfigChanges(t *testing.T) {
+	base := defaultConfig()
+	base.Mode = modeStratum
+	base.StratumHost = "pool.example.org"
+	base.StratumPort = 8008
+	base.WalletAddress = testWallet
+	tests := []struct {
+		name    string
+		change  func(cfg *Config)
+		hot     []string
+		restart []string
+	}{
+		{"unchanged", func(cfg *Config) {}, nil, nil},
+		{"primary pool", func(cfg *Config) { cfg.StratumPort = 3333 }, []string{"Pools"}, nil},
+		{"backup pool", func(cfg *Config) {
+			cfg.Pools = []PoolConfig{{Mode: modeStratum, Host: "b1.example.org", Port: 3333}}
+		}, []string{"Pools"}, nil},
+		// Pools of the other mode are not compared: the mode needs a restart.
+		{"mode", func(cfg *Config) {
+			cfg.Mode = modeRPCGateway
+			cfg.RPCURL = "https://gw.example.org"
+		}, nil, []string{"Mode"}},
+		{"threads and affinity", func(cfg *Config) { cfg.CPUAffinity = []int{0, 1} }, []string{"CPU threads"}, nil},
+		{"hot settings", func(cfg *Config) {
+			cfg.DonateLevel = 3
+			cfg.CPUPriority = 4
+			cfg.CPUYield = !cfg.CPUYield
+			cfg.DisplayInterval = 120
+			cfg.WatchdogNoJobTimeoutSec++
+			cfg.PoolFailbackMin++
+		}, []string{"Donate level", "Thread priority", "Yield", "Display interval", "Watchdog", "Pool failback"}, nil},
+		{"restart settings", func(cfg *Config) {
+			cfg.UseHugePages = !cfg.UseHugePages
+			cfg.RandomX1GBPages = !cfg.RandomX1GBPages
+			cfg.RandomXMode = randomXModeLight
+			cfg.EnableMSR = !cfg.EnableMSR
+		}, nil, []string{"Huge pages", "1 GB pages", "RandomX mode", "MSR"}},
+		{"mixed", func(cfg *Config) {
+			cfg.CPUThreads = 2
+			cfg.RandomXMode = randomXModeLight
+		}, []string{"CPU threads"}, []string{"RandomX mode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := cloneConfig(base)
+			tt.change(next)
+			hot, restart := configChanges(base, next)
+			if !slices.Equal(hot, tt.hot) || !slices.Equal(restart, tt.restart) {
+				t.Errorf("configChanges() = %q, %q, want %q, %q", hot, restart, tt.hot, tt.restart)
+			}
+		})
+	}
+}
+
+func TestMergeHotSettings(t *testing.T) {
+	running := defaultConfig()
+	running.Mode = modeStratum
+	running.StratumHost = "pool.example.org"
+	running.StratumPort = 8008
+	running.WalletAddress = testWallet
+	running.Pools = []PoolConfig{{Mode: modeStratum, Host: "b1.example.org", Port: 3333}}
+	running.RandomXMode = randomXModeAuto
+
+	t.Run("same mode", func(t *testing.T) {
+		next := cloneConfig(running)
+		next.StratumHost = "other.example.org"
+		next.Pools = nil
+		next.CPUThreads = 2
+		next.CPUAffinity = []int{0, 1}
+		next.DonateLevel = 3
+		next.WatchdogEnabled = !running.WatchdogEnabled
+		next.PoolFailbackMin = 15
+		next.RandomXMode = randomXModeLight
+		next.UseHugePages = !running.UseHugePages
+
+		want := cloneConfig(next)
+		want.RandomXMode = running.RandomXMode
+		want.UseHugePages = running.UseHugePages
+		got := mergeHotSettings(running, next)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("mergeHotSettings() =\n%+v\nwant\n%+v", got, want)
+		}
+		next.CPUAffinity[0] = 7
+		if got.CPUAffinity[0] != 0 {
+			t.Error("merged config shares the affinity slice")
+		}
+	})
+
+	t.Run("mode change keeps the running pools", func(t *testing.T) {
+		next := cloneConfig(running)
+		next.Mode = modeRPCGateway
+		next.RPCURL = "https://gw.example.org"
+		next.Pools = []PoolConfig{{Mode: modeRPCGateway, RPCURL: "https://gw2.example.org"}}
+		next.DonateLevel = 3
+
+		got := mergeHotSettings(running, next)
+		if got.Mode != modeStratum || got.RPCURL != running.RPCURL || !slices.Equal(got.Pools, running.Pools) {
+			t.Errorf("merged mode %q, RPC URL %q, pools %+v, want the running ones", got.Mode, got.RPCURL, got.Pools)
+		}
+		if !slices.Equal(miningPools(got), miningPools(running)) {
+			t.Errorf("merged pools = %+v, want %+v", miningPools(got), miningPools(running))
+		}
+		if got.DonateLevel != 3 {
+			t.Errorf("donate level = %d, want 3", got.DonateLevel)
+		}
+	})
+}
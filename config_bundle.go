@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// configBundleVersion marks an exported config bundle. A file without it is
+// imported as a plain config.json.
+const configBundleVersion = 1
+
+// configBundle is the portable export of the configuration. Hosts holds
+// per-host overrides, keyed by host name, that are merged over Config on
+// the machine with that name.
+type configBundle struct {
+	Bundle     int                        `json:"olivetumBundle"`
+	ExportedAt string                     `json:"exportedAt,omitempty"`
+	Config     json.RawMessage            `json:"config"`
+	Hosts      map[string]json.RawMessage `json:"hosts,omitempty"`
+}
+
+// bundleVarPattern matches ${NAME} and ${NAME:-default}.
+var bundleVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// writeConfigBundle exports cfg as a bundle. Without secrets the control API
+// token is left out; importing then generates a new one.
+func writeConfigBundle(w io.Writer, cfg *Config, withSecrets bool) error {
+	c := *cfg
+	syncActiveProfile(&c)
+	c.SchemaVersion = configSchemaVersion
+	if !withSecrets {
+		c.APIToken = ""
+	}
+	raw, err := json.Marshal(&c)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(configBundle{
+		Bundle:     configBundleVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Config:     raw,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// parseConfigBundle reads a bundle or a plain config.json for this host:
+// ${NAME} variables are expanded, the overrides for this host name are
+// applied, and the result is migrated, normalized and validated.
+func parseConfigBundle(b []byte) (*Config, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	var bundle configBundle
+	if _, ok := probe["olivetumBundle"]; !ok {
+		bundle.Config = b
+	} else {
+		if err := json.Unmarshal(b, &bundle); err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		if bundle.Bundle < 1 || bundle.Bundle > configBundleVersion {
+			return nil, fmt.Errorf("unsupported bundle version %d", bundle.Bundle)
+		}
+		if len(bundle.Config) == 0 {
+			return nil, errors.New("bundle has no config")
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	lookup := func(name string) (string, bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+		switch name {
+		case "HOSTNAME":
+			return hostname, hostname != ""
+		case "HOSTNAME_SHORT":
+			short := hostWorkerName(hostname)
+			return short, short != ""
+		}
+		return "", false
+	}
+
+	var raw map[string]any
+	if err := decodeExpanded(bundle.Config, lookup, &raw); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if raw == nil {
+		return nil, errors.New("config: not an object")
+	}
+	if override, host := hostOverride(bundle.Hosts, hostname); override != nil {
+		var extra map[string]any
+		if err := decodeExpanded(override, lookup, &extra); err != nil {
+			return nil, fmt.Errorf("host %q: %w", host, err)
+		}
+		for k, v := range extra {
+			raw[k] = v
+		}
+	}
+
+	merged, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(merged)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.APIEnabled && len(cfg.APIToken) < 16 {
+		if cfg.APIToken, err = generateAPIToken(); err != nil {
+			return nil, err
+		}
+	}
+	normalizeConfig(cfg)
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// hostOverride returns the overrides for hostname, matched on the full name
+// or its first label, ignoring case.
+func hostOverride(hosts map[string]json.RawMessage, hostname string) (json.RawMessage, string) {
+	short, _, _ := strings.Cut(hostname, ".")
+	for _, name := range []string{hostname, short} {
+		for key, override := range hosts {
+			if name != "" && strings.EqualFold(key, name) {
+				return override, key
+			}
+		}
+	}
+	return nil, ""
+}
+
+// decodeExpanded decodes JSON into v with ${NAME} expanded in every string
+// value. An unset variable without a default is an error, so a bundle never
+// rolls out with a blank wallet or worker.
+func decodeExpanded(b []byte, lookup func(string) (string, bool), v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+	tree, err := expandBundleVars(tree, lookup)
+	if err != nil {
+		return err
+	}
+	expanded, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	dec = json.NewDecoder(bytes.NewReader(expanded))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func expandBundleVars(v any, lookup func(string) (string, bool)) (any, error) {
+	switch v := v.(type) {
+	case string:
+		var missing string
+		out := bundleVarPattern.ReplaceAllStringFunc(v, func(m string) string {
+			sub := bundleVarPattern.FindStringSubmatch(m)
+			if value, ok := lookup(sub[1]); ok {
+				return value
+			}
+			if strings.Contains(m, ":-") {
+				return sub[2]
+			}
+			if missing == "" {
+				missing = sub[1]
+			}
+			return ""
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("variable %s is not set", missing)
+		}
+		return out, nil
+	case []any:
+		for i := range v {
+			x, err := expandBundleVars(v[i], lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = x
+		}
+		return v, nil
+	case map[string]any:
+		for k := range v {
+			x, err := expandBundleVars(v[k], lookup)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			v[k] = x
+		}
+		return v, nil
+	}
+	return v, nil
+}
+
+// hostWorkerName turns a host name into a valid worker name: the first
+// label, with other characters than 0-9 A-Z a-z _ - replaced by "-", cut to
+// 16 characters.
+func hostWorkerName(hostname string) string {
+	short, _, _ := strings.Cut(strings.TrimSpace(hostname), ".")
+	b := []byte(short)
+	for i, c := range b {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || c == '-') {
+			b[i] = '-'
+		}
+	}
+	if len(b) > 16 {
+		b = b[:16]
+	}
+	return string(b)
+}
+
+// importConfigBundleFile imports a bundle into the config file, keeping the
+// previous one as a backup.
+func importConfigBundleFile(path string) error {
+	path, err := expandUserPath(path)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cfg, err := parseConfigBundle(b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	dst, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s into %s (profile %q)\n", path, dst, cfg.ActiveProfile)
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandBundleVars(t *testing.T) {
+	env := map[string]string{"WALLET": testWallet, "RIG": "rig7", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "set variable",
+			in:   `{"walletAddress":"${WALLET}","workerName":"cpu-${RIG}"}`,
+			want: `{"walletAddress":"` + testWallet + `","workerName":"cpu-rig7"}`,
+		},
+		{
+			name: "default value",
+			in:   `{"workerName":"${WORKER:-spare}","stratumHost":"${HOST:-}x"}`,
+			want: `{"stratumHost":"x","workerName":"spare"}`,
+		},
+		{
+			name: "set variable wins over the default",
+			in:   `{"workerName":"${RIG:-spare}","rpcUrl":"${EMPTY:-unused}"}`,
+			want: `{"rpcUrl":"","workerName":"rig7"}`,
+		},
+		{
+			name: "nested array",
+			in:   `{"pools":[{"host":"${RIG}.example.org","port":3333,"walletAddress":"${WALLET}"}],"nodeStaticPeers":["${RIG:-a}","b"]}`,
+			want: `{"nodeStaticPeers":["rig7","b"],"pools":[{"host":"rig7.example.org","port":3333,"walletAddress":"` + testWallet + `"}]}`,
+		},
+		{
+			name: "other values",
+			in:   `{"cpuThreads":4,"useHugePages":true,"cpuAffinity":null,"text":"$HOME {RIG} $"}`,
+			want: `{"cpuAffinity":null,"cpuThreads":4,"text":"$HOME {RIG} $","useHugePages":true}`,
+		},
+		{
+			name:    "unset variable",
+			in:      `{"walletAddress":"${MISSING}"}`,
+			wantErr: "walletAddress: variable MISSING is not set",
+		},
+		{
+			name:    "unset variable in an array",
+			in:      `{"pools":[{"walletAddress":"${WALLET}"},{"walletAddress":"${MISSING2}"}]}`,
+			wantErr: "pools: walletAddress: variable MISSING2 is not set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]any
+			err := decodeExpanded([]byte(tt.in), lookup, &v)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(v)
+			if string(got) != tt.want {
+				t.Errorf("expanded to %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostOverride(t *testing.T) {
+	hosts := map[string]json.RawMessage{
+		"rig1":             json.RawMessage(`"short"`),
+		"RIG2.farm.local":  json.RawMessage(`"full"`),
+		"rig2":             json.RawMessage(`"short2"`),
+		"other.farm.local": json.RawMessage(`"other"`),
+	}
+	tests := []struct {
+		hostname string
+		want     string
+		key      string
+	}{
+		{"rig1", `"short"`, "rig1"},
+		{"rig1.farm.local", `"short"`, "rig1"},
+		{"RIG1.Farm.Local", `"short"`, "rig1"},
+		// The full name wins over the short one.
+		{"rig2.farm.local", `"full"`, "RIG2.farm.local"},
+		{"rig2.elsewhere", `"short2"`, "rig2"},
+		{"other", "", ""},
+		{"rig3.farm.local", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, key := hostOverride(hosts, tt.hostname)
+		if string(got) != tt.want || key != tt.key {
+			t.Errorf("hostOverride(%q) = %s, %q, want %s, %q", tt.hostname, got, key, tt.want, tt.key)
+		}
+	}
+}
+
+func TestParseConfigBundle(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		t.Skip("no host name")
+	}
+	t.Setenv("OLIVETUM_TEST_WALLET", testWallet)
+
+	t.Run("plain config", func(t *testing.T) {
+		b := []byte(`{"walletAddress":"${OLIVETUM_TEST_WALLET}","workerName":"${HOSTNAME_SHORT}","cpuThreads":2}`)
+		orig := bytes.Clone(b)
+		cfg, err := parseConfigBundle(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.WalletAddress != testWallet || cfg.WorkerName != hostWorkerName(hostname) || cfg.CPUThreads != 2 {
+			t.Errorf("imported wallet %q, worker %q, threads %d", cfg.WalletAddress, cfg.WorkerName, cfg.CPUThreads)
+		}
+		if !bytes.Equal(b, orig) {
+			t.Error("parseConfigBundle modified its input")
+		}
+	})
+
+	t.Run("bundle with host override", func(t *testing.T) {
+		b, _ := json.Marshal(map[string]any{
+			"olivetumBundle": configBundleVersion,
+			"config": map[string]any{
+				"walletAddress": "${OLIVETUM_TEST_WALLET}",
+				"cpuThreads":    2,
+				"apiEnabled":    true,
+			},
+			"hosts": map[string]any{
+				strings.ToUpper(hostname): map[string]any{"cpuThreads": 6},
+				"not-" + hostname:         map[string]any{"cpuThreads": 1},
+			},
+		})
+		cfg, err := parseConfigBundle(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.WalletAddress != testWallet || cfg.CPUThreads != 6 {
+			t.Errorf("imported wallet %q, threads %d", cfg.WalletAddress, cfg.CPUThreads)
+		}
+		if len(cfg.APIToken) < 16 {
+			t.Errorf("no API token generated: %q", cfg.APIToken)
+		}
+	})
+
+	errs := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{"not JSON", `{`, "invalid JSON"},
+		{"unsupported version", `{"olivetumBundle":2,"config":{}}`, "unsupported bundle version"},
+		{"no config", `{"olivetumBundle":1}`, "bundle has no config"},
+		{"config not an object", `{"olivetumBundle":1,"config":[]}`, "config:"},
+		{"unset variable", `{"walletAddress":"${OLIVETUM_TEST_UNSET}"}`, "variable OLIVETUM_TEST_UNSET is not set"},
+		{"invalid settings", `{"walletAddress":"0x1234"}`, "invalid wallet address"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseConfigBundle([]byte(tt.in)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseConfigBundle() = %v, want error with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteConfigBundle(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.WalletAddress = testWallet
+	cfg.APIEnabled = true
+	cfg.APIToken = strings.Repeat("s", 32)
+	for _, withSecrets := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := writeConfigBundle(&buf, cfg, withSecrets); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseConfigBundle(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got.APIToken == cfg.APIToken) != withSecrets {
+			t.Errorf("with secrets %v: token %q", withSecrets, got.APIToken)
+		}
+		got.APIToken = cfg.APIToken
+		want := cloneConfig(cfg)
+		syncActiveProfile(want)
+		normalizeConfig(want)
+		if !reflect.DeepEqual(got.ProfileSettings, want.ProfileSettings) || got.ActiveProfile != want.ActiveProfile {
+			t.Errorf("with secrets %v: round trip changed the settings:\n got %+v\nwant %+v", withSecrets, got.ProfileSettings, want.ProfileSettings)
+		}
+	}
+	if cfg.APIToken != strings.Repeat("s", 32) {
+		t.Error("export changed the config")
+	}
+}
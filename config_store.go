@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// configBackupCount is how many previous versions of config.json are
+	// kept as config.json.1 (newest) to config.json.N.
+	configBackupCount = 5
+
+	// configInvalidSuffix is appended to a config.json that failed to load
+	// when it is moved out of the way.
+	configInvalidSuffix = ".invalid"
+)
+
+// configMigration upgrades the raw JSON of a config from version-1 to
+// version.
+type configMigration struct {
+	version int
+	name    string
+	apply   func(raw map[string]any)
+}
+
+// configMigrations run in order on load, from the version after the one in
+// the file. Files without schemaVersion are version 0. Migrations only change
+// the layout; values are checked by fillConfigDefaults and normalizeConfig,
+// which also run on restored backups, imports and hand edits.
+var configMigrations = []configMigration{
+	{1, "CPU affinity from selected devices", func(raw map[string]any) {
+		affinity, _ := raw["cpuAffinity"].([]any)
+		if devices, ok := raw["selectedDevices"].([]any); ok && len(affinity) == 0 && len(devices) > 0 {
+			raw["cpuAffinity"] = devices
+		}
+	}},
+	// Version 2 stored the default node data directory as empty. That is a
+	// normalization, not a layout change, so fillConfigDefaults now does it
+	// on every load; the step stays so the versions remain contiguous.
+	{2, "default node data directory stored empty", func(raw map[string]any) {}},
+	{3, "single configuration to the Default profile", func(raw map[string]any) {
+		// The profile itself is filled from the flat settings by
+		// syncActiveProfile.
+		if _, ok := raw["profiles"]; !ok {
+			raw["activeProfile"] = defaultProfileName
+		}
+	}},
+}
+
+var configSchemaVersion = configMigrations[len(configMigrations)-1].version
+
+// decodeConfig migrates the JSON in b to the current schema and decodes it
+// over cfg. Unlike a plain json.Unmarshal it fails on any value it cannot
+// use, instead of keeping the defaults for the rest of the file.
+func decodeConfig(b []byte, cfg *Config) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if raw == nil {
+		return errors.New("invalid JSON: not an object")
+	}
+	version := 0
+	if v, ok := raw["schemaVersion"]; ok {
+		n, ok := v.(json.Number)
+		i, err := strconv.Atoi(n.String())
+		if !ok || err != nil || i < 0 {
+			return fmt.Errorf("invalid schemaVersion %v", v)
+		}
+		version = i
+	}
+	if version > configSchemaVersion {
+		return fmt.Errorf("written by a newer version of the app (schema %d, this version reads up to %d)", version, configSchemaVersion)
+	}
+	for _, m := range configMigrations {
+		if m.version > version {
+			m.apply(raw)
+		}
+	}
+	raw["schemaVersion"] = configSchemaVersion
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(migrated, cfg); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path, syncs it
+// and renames it over path, so a crash leaves either the old or the new
+// file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	// Sync the directory so the rename itself survives a crash. Windows
+	// cannot open directories for that, which is fine there.
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+	return nil
+}
+
+func configBackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// rotateConfigBackups shifts config.json.1..N-1 up by one, dropping the
+// oldest, and writes old as config.json.1.
+func rotateConfigBackups(path string, old []byte) error {
+	for n := configBackupCount - 1; n >= 1; n-- {
+		err := os.Rename(configBackupPath(path, n), configBackupPath(path, n+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFileAtomic(configBackupPath(path, 1), old, 0o644)
+}
+
+// configBackup is one of the rolling backups of config.json.
+type configBackup struct {
+	Path    string
+	ModTime time.Time
+}
+
+// listConfigBackups returns the backups of path, newest first.
+func listConfigBackups(path string) []configBackup {
+	var backups []configBackup
+	for n := 1; n <= configBackupCount; n++ {
+		p := configBackupPath(path, n)
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		backups = append(backups, configBackup{Path: p, ModTime: info.ModTime()})
+	}
+	return backups
+}
+
+// setAsideInvalidConfig moves a config.json that failed to load to
+// config.json.invalid, so saving the defaults neither overwrites it nor
+// pushes a good backup out of the rotation.
+func setAsideInvalidConfig(path string) (string, error) {
+	dst := path + configInvalidSuffix
+	if err := os.Rename(path, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
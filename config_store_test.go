@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigMigrationSteps(t *testing.T) {
+	tests := []struct {
+		version int
+		name    string
+		in      string
+		want    string
+	}{
+		{1, "affinity from selected devices", `{"selectedDevices":[0,2]}`, `{"cpuAffinity":[0,2],"selectedDevices":[0,2]}`},
+		{1, "affinity already set", `{"cpuAffinity":[1],"selectedDevices":[0,2]}`, `{"cpuAffinity":[1],"selectedDevices":[0,2]}`},
+		{1, "no devices", `{"selectedDevices":[]}`, `{"selectedDevices":[]}`},
+		{2, "node data directory left to the defaults", `{"nodeDataDir":"/srv/olivetum"}`, `{"nodeDataDir":"/srv/olivetum"}`},
+		{3, "single config", `{"mode":"stratum"}`, `{"activeProfile":"Default","mode":"stratum"}`},
+		{3, "profiles already", `{"activeProfile":"Night","profiles":[]}`, `{"activeProfile":"Night","profiles":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d %s", tt.version, tt.name), func(t *testing.T) {
+			var m *configMigration
+			for i := range configMigrations {
+				if configMigrations[i].version == tt.version {
+					m = &configMigrations[i]
+				}
+			}
+			if m == nil {
+				t.Fatalf("no migration to version %d", tt.version)
+			}
+			var raw map[string]any
+			if err := json.Unmarshal([]byte(tt.in), &raw); err != nil {
+				t.Fatal(err)
+			}
+			m.apply(raw)
+			got, _ := json.Marshal(raw)
+			if string(got) != tt.want {
+				t.Errorf("migrated to %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigMigrationVersions(t *testing.T) {
+	prev := 0
+	for _, m := range configMigrations {
+		if m.version != prev+1 {
+			t.Errorf("migration %q to version %d does not follow version %d", m.name, m.version, prev)
+		}
+		prev = m.version
+	}
+	if configSchemaVersion != prev {
+		t.Errorf("configSchemaVersion = %d, want %d", configSchemaVersion, prev)
+	}
+}
+
+func TestDecodeConfigFixtures(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		affinity []int
+		active   string
+		wantErr  string
+	}{
+		{
+			name:     "version 0",
+			in:       `{"mode":"stratum","selectedDevices":[1,3]}`,
+			affinity: []int{1, 3},
+			active:   defaultProfileName,
+		},
+		{
+			name:   "version 1",
+			in:     `{"schemaVersion":1,"selectedDevices":[1,3]}`,
+			active: defaultProfileName,
+		},
+		{
+			name: "current version",
+			in:   `{"schemaVersion":3,"selectedDevices":[1,3],"activeProfile":"Night","profiles":[{"name":"Night"}]}`,
+			// Only older files are migrated.
+			active: "Night",
+		},
+		{name: "newer version", in: `{"schemaVersion":99}`, wantErr: "newer version"},
+		{name: "invalid version", in: `{"schemaVersion":"x"}`, wantErr: "invalid schemaVersion"},
+		{name: "negative version", in: `{"schemaVersion":-1}`, wantErr: "invalid schemaVersion"},
+		{name: "invalid value", in: `{"cpuThreads":"many"}`, wantErr: "invalid value"},
+		{name: "not an object", in: `[]`, wantErr: "invalid JSON"},
+		{name: "truncated", in: `{"mode":`, wantErr: "invalid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			err := decodeConfig([]byte(tt.in), cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeConfig() = %v, want error with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg.CPUAffinity, tt.affinity) || cfg.ActiveProfile != tt.active || cfg.SchemaVersion != configSchemaVersion {
+				t.Errorf("decoded affinity %v, profile %q, version %d; want %v, %q, %d",
+					cfg.CPUAffinity, cfg.ActiveProfile, cfg.SchemaVersion, tt.affinity, tt.active, configSchemaVersion)
+			}
+		})
+	}
+}
+
+// The default node data directory is stored empty on every load, not only
+// when an old file is migrated.
+func TestParseConfigNodeDataDir(t *testing.T) {
+	for _, version := range []int{0, configSchemaVersion} {
+		b, _ := json.Marshal(map[string]any{"schemaVersion": version, "nodeDataDir": defaultNodeDataDir() + string(filepath.Separator)})
+		cfg, err := parseConfig(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.NodeDataDir != "" {
+			t.Errorf("version %d: nodeDataDir = %q, want empty", version, cfg.NodeDataDir)
+		}
+	}
+	cfg, err := parseConfig([]byte(`{"nodeDataDir":"/srv/olivetum"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NodeDataDir != "/srv/olivetum" {
+		t.Errorf("nodeDataDir = %q, want /srv/olivetum", cfg.NodeDataDir)
+	}
+}
+
+func TestRotateConfigBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	for i := 1; i <= configBackupCount+3; i++ {
+		if err := rotateConfigBackups(path, []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+		backups := listConfigBackups(path)
+		if want := min(i, configBackupCount); len(backups) != want {
+			t.Fatalf("after %d saves: %d backups, want %d", i, len(backups), want)
+		}
+		for n, b := range backups {
+			got, err := os.ReadFile(b.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprint(i - n); string(got) != want {
+				t.Errorf("after %d saves: backup %d holds %s, want %s", i, n+1, got, want)
+			}
+		}
+	}
+	if _, err := os.Stat(configBackupPath(path, configBackupCount+1)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup %d exists: %v", configBackupCount+1, err)
+	}
+}
+
+func TestSaveConfigBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	prev := configPathOverride
+	configPathOverride = path
+	t.Cleanup(func() { configPathOverride = prev })
+
+	cfg := defaultConfig()
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the same config does not push a backup.
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(listConfigBackups(path)); n != 0 {
+		t.Fatalf("%d backups after unchanged saves, want 0", n)
+	}
+	first, _ := os.ReadFile(path)
+
+	cfg.CPUThreads = 3
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	backups := listConfigBackups(path)
+	if len(backups) != 1 {
+		t.Fatalf("%d backups, want 1", len(backups))
+	}
+	if got, _ := os.ReadFile(backups[0].Path); !bytes.Equal(got, first) {
+		t.Error("backup does not hold the previous config")
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	loaded, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CPUThreads != 3 || loaded.SchemaVersion != configSchemaVersion {
+		t.Errorf("read back cpuThreads %d, version %d", loaded.CPUThreads, loaded.SchemaVersion)
+	}
+}
+
+func TestSetAsideInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfigFile(path); err == nil {
+		t.Fatal("read a broken config")
+	}
+	dst, err := setAsideInvalidConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != path+configInvalidSuffix {
+		t.Errorf("set aside to %s", dst)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("config still in place: %v", err)
+	}
+}
@@ -0,0 +1,1592 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type minerStartOrigin int
+
+const (
+	minerStartOriginUser minerStartOrigin = iota
+	minerStartOriginWatchdog
+	minerStartOriginScheduler
+)
+
+type minerStopOrigin int
+
+const (
+	minerStopOriginUser minerStopOrigin = iota
+	minerStopOriginWatchdog
+)
+
+type minerState int
+
+const (
+	minerStateStopped minerState = iota
+	minerStateStarting
+	minerStateRunning
+)
+
+func (s minerState) String() string {
+	switch s {
+	case minerStateStarting:
+		return "starting"
+	case minerStateRunning:
+		return "running"
+	default:
+		return "stopped"
+	}
+}
+
+type nodeState int
+
+const (
+	nodeStateOff nodeState = iota
+	nodeStateStarting
+	nodeStateRunning
+	nodeStateResetting
+)
+
+func (s nodeState) String() string {
+	switch s {
+	case nodeStateStarting:
+		return "starting"
+	case nodeStateRunning:
+		return "running"
+	case nodeStateResetting:
+		return "resetting"
+	default:
+		return "off"
+	}
+}
+
+type nodeStartSettings struct {
+	Enabled    bool
+	CleanStart bool
+	Mode       string
+	DataDir    string
+	RPCPort    int
+	P2PPort    int
+	Bootnodes  string
+	Verbosity  int
+	Wallet     string
+
+	StaticPeers  []string
+	TrustedPeers []string
+}
+
+type watchdogSettings struct {
+	NoJobTimeout time.Duration
+	RestartDelay time.Duration
+	RetryWindow  time.Duration
+}
+
+// minerStats is a Stat sample after device remapping, together with the
+// values derived from it and from the miner log.
+type minerStats struct {
+	Stat
+	Hashrate   float64
+	Threads    int
+	NewAccept  bool
+	JobBlock   int64
+	Difficulty string
+	LastFound  int64
+	// PoolIndex is the position of the active pool in the list xmrig was
+	// started with (0 is the primary), or -1 when it is not known.
+	PoolIndex int
+}
+
+type eventKind int
+
+const (
+	eventMinerLog eventKind = iota
+	eventMinerLogReset
+	eventNodeLog
+	eventNodeLogReset
+	eventMinerState
+	eventNodeState
+	eventStats
+	eventFoundBlock
+	eventNodeIssue
+)
+
+// Event is published by the Controller to every subscriber. Only the fields
+// relevant to Kind are set.
+type Event struct {
+	Kind       eventKind
+	Line       string
+	MinerState minerState
+	NodeState  nodeState
+	Stats      minerStats
+	Fatal      bool
+
+	// Block, Hash and Source describe a found block. Hash is empty or
+	// shortened when the source did not report it in full.
+	Block  int64
+	Hash   string
+	Source string
+}
+
+var (
+	errMinerAlreadyRunning = errors.New("miner already running")
+	errMinerNotRunning     = errors.New("miner is not running")
+)
+
+// Controller owns the xmrig and geth processes, the watchdog and the stats
+// poller. It does not depend on any UI toolkit; front-ends observe it through
+// Subscribe.
+type Controller struct {
+	xmrigPath string
+	xmrigErr  error
+
+	// cfgMu guards cfg, the one shared copy of the settings. Other
+	// goroutines read it through Snapshot and change it through Update.
+	cfgMu sync.RWMutex
+	cfg   *Config
+
+	// commandContext creates child processes; tests can replace it to run
+	// fake miners and nodes.
+	commandContext func(ctx context.Context, name string, args ...string) *exec.Cmd
+	// save writes an updated config to disk; tests can replace it.
+	save func(cfg *Config) error
+
+	subsMu  sync.Mutex
+	subs    map[int]chan Event
+	nextSub int
+
+	minerLog *ringLogs
+	nodeLog  *ringLogs
+
+	procMu         sync.Mutex
+	minerCmd       *exec.Cmd
+	minerCancel    context.CancelFunc
+	apiPort        int
+	xmrigToken     string
+	pollCancel     context.CancelFunc
+	watchdogCancel context.CancelFunc
+	failbackCancel context.CancelFunc
+	minerPools     []PoolConfig
+	minerLimit     int
+	minerConfig    *Config
+	nodeCmd        *exec.Cmd
+	nodeCancel     context.CancelFunc
+	nodeRunMode    string
+
+	deviceMapMu sync.RWMutex
+	deviceMap   []int
+
+	waitingForStats    atomic.Bool
+	lastAccepted       atomic.Int64
+	watchdogRestarting atomic.Bool
+	minerStartedAt     atomic.Int64
+	lastJobAt          atomic.Int64
+	currentJobBlock    atomic.Int64
+	lastFoundBlock     atomic.Int64
+	jobDifficulty      atomic.Value
+	watchdogRestarts   atomic.Int64
+	minerStateNow      atomic.Int32
+	nodeStateNow       atomic.Int32
+	// minerStopRequested and nodeStopRequested tell an exit that was asked
+	// for (Stop button, API) apart from a crash.
+	minerStopRequested atomic.Bool
+	nodeStopRequested  atomic.Bool
+	lastStats          atomic.Pointer[minerStats]
+	activePool         atomic.Int32
+	autotuning         atomic.Bool
+	minerPaused        atomic.Bool
+	throttled          atomic.Bool
+
+	// limitMu guards the thread caps and pause holds that the schedule and
+	// the idle governor place on the miner, keyed by limit source.
+	limitMu      sync.Mutex
+	threadLimits map[string]int
+	pauseHolds   map[string]bool
+
+	nodeIssueCount   atomic.Int64
+	nodeIssueFirstAt atomic.Int64
+}
+
+func newController(cfg *Config, xmrigPath string, xmrigErr error) *Controller {
+	c := &Controller{
+		cfg:            cloneConfig(cfg),
+		xmrigPath:      xmrigPath,
+		xmrigErr:       xmrigErr,
+		commandContext: exec.CommandContext,
+		save:           saveConfig,
+		subs:           make(map[int]chan Event),
+		minerLog:       newRingLogs(5000),
+		nodeLog:        newRingLogs(5000),
+		threadLimits:   make(map[string]int),
+		pauseHolds:     make(map[string]bool),
+	}
+	c.jobDifficulty.Store("")
+	c.activePool.Store(-1)
+	return c
+}
+
+// cloneConfig copies cfg together with all of its slices, so later edits of
+// cfg do not reach the copy.
+func cloneConfig(cfg *Config) *Config {
+	c := *cfg
+	c.ProfileSettings = cfg.ProfileSettings.clone()
+	c.SelectedDevices = slices.Clone(cfg.SelectedDevices)
+	c.AddressBook = slices.Clone(cfg.AddressBook)
+	c.Schedule = slices.Clone(cfg.Schedule)
+	for i := range c.Schedule {
+		c.Schedule[i].Days = slices.Clone(c.Schedule[i].Days)
+	}
+	c.Profiles = slices.Clone(cfg.Profiles)
+	for i := range c.Profiles {
+		c.Profiles[i].ProfileSettings = c.Profiles[i].ProfileSettings.clone()
+	}
+	return &c
+}
+
+// Snapshot returns a copy of the current config that the caller owns.
+func (c *Controller) Snapshot() *Config {
+	c.cfgMu.RLock()
+	defer c.cfgMu.RUnlock()
+	return cloneConfig(c.cfg)
+}
+
+// Update calls change with a copy of the current config and, when it
+// returns nil, makes the copy current and saves it. Updates run one at a
+// time, so a read-modify-write in change never loses a concurrent one.
+// change must not call back into the Controller.
+func (c *Controller) Update(change func(next *Config) error) error {
+	c.cfgMu.Lock()
+	defer c.cfgMu.Unlock()
+	next := cloneConfig(c.cfg)
+	if err := change(next); err != nil {
+		return err
+	}
+	c.cfg = next
+	return c.save(next)
+}
+
+// Replace makes a copy of cfg the current config and saves it. The GUI
+// edits its own Config on the UI goroutine and replaces the shared one
+// after each change.
+func (c *Controller) Replace(cfg *Config) error {
+	next := cloneConfig(cfg)
+	return c.Update(func(cur *Config) error {
+		*cur = *next
+		return nil
+	})
+}
+
+// Subscribe registers a new event listener. Events are delivered without
+// blocking the controller: when the channel buffer is full the event is
+// dropped for that subscriber. The returned function unsubscribes and closes
+// the channel.
+func (c *Controller) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer < 1 {
+		buffer = 1
+	}
+	ch := make(chan Event, buffer)
+	c.subsMu.Lock()
+	id := c.nextSub
+	c.nextSub++
+	c.subs[id] = ch
+	c.subsMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.subsMu.Lock()
+			delete(c.subs, id)
+			c.subsMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (c *Controller) emit(ev Event) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	for _, ch := range c.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (c *Controller) MinerRunning() bool {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	return c.minerCmd != nil && c.minerCmd.Process != nil
+}
+
+func (c *Controller) NodeRunning() bool {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	return c.nodeCmd != nil && c.nodeCmd.Process != nil
+}
+
+func (c *Controller) NodeRunMode() string {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	return c.nodeRunMode
+}
+
+func (c *Controller) CurrentJobBlock() int64 {
+	return c.currentJobBlock.Load()
+}
+
+func (c *Controller) LastFoundBlock() int64 {
+	return c.lastFoundBlock.Load()
+}
+
+func (c *Controller) JobDifficulty() string {
+	diff, _ := c.jobDifficulty.Load().(string)
+	return diff
+}
+
+// LastStats returns the most recent stats sample of the running miner.
+func (c *Controller) LastStats() (minerStats, bool) {
+	st := c.lastStats.Load()
+	if st == nil {
+		return minerStats{}, false
+	}
+	return *st, true
+}
+
+// ActivePool is the index of the pool xmrig is mining on (0 is the primary,
+// then the enabled backups in order), or -1 when it is not known.
+func (c *Controller) ActivePool() int {
+	return int(c.activePool.Load())
+}
+
+// Limit sources place independent thread caps and pause holds on the miner.
+const (
+	limitSourceUser     = "user"
+	limitSourceSchedule = "schedule"
+	limitSourceIdle     = "idle"
+	limitSourceLoad     = "load"
+	limitSourceThermal  = "thermal"
+)
+
+// Governors either cap the miner threads or pause it, per their action
+// setting.
+const (
+	throttleActionThreads = "threads"
+	throttleActionPause   = "pause"
+)
+
+// setThreadLimit sets the thread cap of source for the next miner start; 0
+// removes it. The lowest cap of all sources applies. It reports whether the
+// effective limit changed.
+func (c *Controller) setThreadLimit(source string, n int) bool {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	prev := c.threadLimitLocked()
+	if n > 0 {
+		c.threadLimits[source] = n
+	} else {
+		delete(c.threadLimits, source)
+	}
+	return c.threadLimitLocked() != prev
+}
+
+// threadLimit is the effective thread cap (0 = config).
+func (c *Controller) threadLimit() int {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	return c.threadLimitLocked()
+}
+
+func (c *Controller) threadLimitLocked() int {
+	limit := 0
+	for _, n := range c.threadLimits {
+		if limit == 0 || n < limit {
+			limit = n
+		}
+	}
+	return limit
+}
+
+// setPauseHold places or releases the pause hold of source. xmrig is paused
+// through its HTTP API while any hold is set, and resumed when the last one
+// is released.
+func (c *Controller) setPauseHold(source string, on bool) {
+	c.limitMu.Lock()
+	was := len(c.pauseHolds) > 0
+	if on {
+		c.pauseHolds[source] = true
+	} else {
+		delete(c.pauseHolds, source)
+	}
+	now := len(c.pauseHolds) > 0
+	c.limitMu.Unlock()
+	if was != now {
+		c.syncPause(now)
+	}
+}
+
+func (c *Controller) pauseHeld() bool {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	return len(c.pauseHolds) > 0
+}
+
+// PauseReasons lists the sources holding the miner paused, sorted.
+func (c *Controller) PauseReasons() []string {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	reasons := make([]string, 0, len(c.pauseHolds))
+	for source := range c.pauseHolds {
+		reasons = append(reasons, source)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// PauseMiner pauses the running xmrig without stopping it, so hashing
+// resumes without re-initialising the RandomX dataset.
+func (c *Controller) PauseMiner() error {
+	if !c.MinerRunning() {
+		return errMinerNotRunning
+	}
+	c.setPauseHold(limitSourceUser, true)
+	return nil
+}
+
+// ResumeMiner lifts a manual or scheduled pause. Pauses placed by the idle
+// and thermal governors stay until their condition clears.
+func (c *Controller) ResumeMiner() error {
+	if !c.MinerRunning() {
+		return errMinerNotRunning
+	}
+	c.setPauseHold(limitSourceUser, false)
+	c.setPauseHold(limitSourceSchedule, false)
+	return nil
+}
+
+// restartIfLimitChanged restarts a running miner whose thread limit differs
+// from the one it was started with. Governors call it after changing their
+// limit. It reports false when another restart was in progress, which may
+// have started xmrig before the new limit was set; the caller has to try
+// again later.
+func (c *Controller) restartIfLimitChanged(ctx context.Context, logPrefix string) bool {
+	limit := c.threadLimit()
+	c.procMu.Lock()
+	stale := c.minerCmd != nil && c.minerCmd.Process != nil && c.minerLimit != limit
+	c.procMu.Unlock()
+	if !stale {
+		return true
+	}
+	if !c.watchdogRestarting.CompareAndSwap(false, true) {
+		return false
+	}
+	c.appendMinerLog(fmt.Sprintf("%s Restarting miner with %s.\n", logPrefix, describeThreadLimit(limit)))
+	c.restartMiner(ctx, time.Second, logPrefix)
+	return true
+}
+
+func describeThreadLimit(limit int) string {
+	if limit <= 0 {
+		return "the configured threads"
+	}
+	return "at most " + threadsText(limit)
+}
+
+// Throttled reports whether the thermal governor is limiting the miner.
+func (c *Controller) Throttled() bool {
+	return c.throttled.Load()
+}
+
+func (c *Controller) setThrottled(on bool) {
+	if c.throttled.Swap(on) != on {
+		c.emit(Event{Kind: eventMinerState, MinerState: c.MinerState()})
+	}
+}
+
+// minerPID is the process ID of the running xmrig, or 0.
+func (c *Controller) minerPID() int {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	if c.minerCmd == nil || c.minerCmd.Process == nil {
+		return 0
+	}
+	return c.minerCmd.Process.Pid
+}
+
+// MinerPaused reports whether the running xmrig is paused.
+func (c *Controller) MinerPaused() bool {
+	return c.minerPaused.Load()
+}
+
+// syncPause sends pause or resume to the running xmrig. It is a no-op while
+// the miner is stopped; handleStat calls it again once the API answers, so a
+// hold placed before or during a start is applied too.
+func (c *Controller) syncPause(pause bool) {
+	c.procMu.Lock()
+	running := c.minerCmd != nil && c.minerCmd.Process != nil
+	port := c.apiPort
+	token := c.xmrigToken
+	c.procMu.Unlock()
+	if !running || c.waitingForStats.Load() {
+		return
+	}
+	method := "resume"
+	if pause {
+		method = "pause"
+	}
+	if err := callXMRigRPC("127.0.0.1", port, token, method); err != nil {
+		c.appendMinerLog(fmt.Sprintf("[api] %s failed: %v\n", method, err))
+		return
+	}
+	if pause {
+		c.appendMinerLog(fmt.Sprintf("[miner] Paused (%s)\n", strings.Join(c.PauseReasons(), ", ")))
+	} else {
+		c.appendMinerLog("[miner] Resumed\n")
+	}
+	c.setMinerPaused(pause)
+}
+
+func (c *Controller) setMinerPaused(paused bool) {
+	if c.minerPaused.Swap(paused) != paused {
+		c.emit(Event{Kind: eventMinerState, MinerState: c.MinerState()})
+	}
+}
+
+// applyThreadLimit returns cfg with at most limit mining threads. With CPU
+// affinity the first limit selected CPUs are kept.
+func applyThreadLimit(cfg *Config, limit int) *Config {
+	if limit <= 0 {
+		return cfg
+	}
+	limited := *cfg
+	switch {
+	case len(limited.CPUAffinity) > limit:
+		limited.CPUAffinity = append([]int(nil), limited.CPUAffinity[:limit]...)
+	case len(limited.CPUAffinity) == 0 && (limited.CPUThreads == 0 || limited.CPUThreads > limit):
+		limited.CPUThreads = limit
+	}
+	return &limited
+}
+
+// WatchdogRestarts is the number of miner restarts performed by the
+// watchdog since the app started.
+func (c *Controller) WatchdogRestarts() int64 {
+	return c.watchdogRestarts.Load()
+}
+
+// MinerState is the last state reported to subscribers.
+func (c *Controller) MinerState() minerState {
+	return minerState(c.minerStateNow.Load())
+}
+
+// NodeState is the last state reported to subscribers.
+func (c *Controller) NodeState() nodeState {
+	return nodeState(c.nodeStateNow.Load())
+}
+
+func (c *Controller) setMinerState(state minerState) {
+	c.minerStateNow.Store(int32(state))
+	c.emit(Event{Kind: eventMinerState, MinerState: state})
+}
+
+func (c *Controller) setNodeState(state nodeState) {
+	c.nodeStateNow.Store(int32(state))
+	c.emit(Event{Kind: eventNodeState, NodeState: state})
+}
+
+func (c *Controller) resetMinerLog() {
+	c.minerLog.Clear()
+	c.emit(Event{Kind: eventMinerLogReset})
+}
+
+func (c *Controller) resetNodeLog() {
+	c.nodeLog.Clear()
+	c.emit(Event{Kind: eventNodeLogReset})
+}
+
+func (c *Controller) appendMinerLog(text string) {
+	text = sanitizeLogLine(text)
+	for _, line := range strings.Split(text, "\n") {
+		if m := xmrigJobLine.FindStringSubmatch(line); len(m) == 3 {
+			if diff := strings.TrimSpace(m[1]); diff != "" {
+				c.jobDifficulty.Store(diff)
+			}
+			if block, err := strconv.ParseInt(m[2], 10, 64); err == nil && block > 0 {
+				c.currentJobBlock.Store(block)
+			}
+			c.lastJobAt.Store(time.Now().UnixNano())
+		}
+		c.minerLog.Append(line)
+		c.emit(Event{Kind: eventMinerLog, Line: line})
+	}
+}
+
+func (c *Controller) appendNodeLog(text string) {
+	text = sanitizeLogLine(text)
+	for _, line := range strings.Split(text, "\n") {
+		var found []string
+		if m := nodeMinedPotentialBlockLine.FindStringSubmatch(line); len(m) == 2 {
+			found = m
+		} else if m := nodeSealedNewBlockLine.FindStringSubmatch(line); len(m) == 2 {
+			found = m
+		}
+		if found != nil {
+			n := strings.ReplaceAll(found[1], ",", "")
+			if block, err := strconv.ParseInt(n, 10, 64); err == nil && block > 0 {
+				var hash string
+				if m := nodeBlockHash.FindStringSubmatch(line); len(m) == 2 {
+					hash = m[1]
+				}
+				c.lastFoundBlock.Store(block)
+				c.emit(Event{Kind: eventFoundBlock, Block: block, Hash: hash, Source: blockSourceNode})
+			}
+		}
+		c.detectNodeIssue(line)
+		c.nodeLog.Append(line)
+		c.emit(Event{Kind: eventNodeLog, Line: line})
+	}
+}
+
+// detectNodeIssue looks for signs of a damaged local chain database. A fatal
+// message, or several suspicious ones within a short window, publishes an
+// eventNodeIssue so the front-end can offer a resync.
+func (c *Controller) detectNodeIssue(line string) {
+	lower := strings.ToLower(line)
+	isLocalDBIssue := (strings.Contains(lower, "failed to read") && strings.Contains(lower, "last block")) ||
+		strings.Contains(lower, "missing trie node") ||
+		(strings.Contains(lower, "failed to restore") && strings.Contains(lower, "runtime")) ||
+		(strings.Contains(lower, "database") && strings.Contains(lower, "corrupt")) ||
+		strings.Contains(lower, "chaindata is corrupt") ||
+		strings.Contains(lower, "corruption") ||
+		strings.Contains(lower, "fatal")
+	if !isLocalDBIssue {
+		return
+	}
+	isFatal := (strings.Contains(lower, "failed to read") && strings.Contains(lower, "last block")) ||
+		(strings.Contains(lower, "database") && strings.Contains(lower, "corrupt")) ||
+		strings.Contains(lower, "chaindata is corrupt") ||
+		strings.Contains(lower, "corruption") ||
+		strings.Contains(lower, "fatal")
+
+	now := time.Now().UnixNano()
+	const issueWindow = 45 * time.Second
+	const issueThreshold = int64(3)
+
+	firstAt := c.nodeIssueFirstAt.Load()
+	if firstAt == 0 || now-firstAt > int64(issueWindow) {
+		c.nodeIssueFirstAt.Store(now)
+		c.nodeIssueCount.Store(1)
+	} else {
+		c.nodeIssueCount.Add(1)
+	}
+
+	if isFatal || c.nodeIssueCount.Load() >= issueThreshold {
+		c.emit(Event{Kind: eventNodeIssue, Line: line, Fatal: isFatal})
+	}
+}
+
+func (c *Controller) setDeviceMap(selected []int) {
+	c.deviceMapMu.Lock()
+	c.deviceMap = append([]int(nil), selected...)
+	c.deviceMapMu.Unlock()
+}
+
+func (c *Controller) getDeviceMap() []int {
+	c.deviceMapMu.RLock()
+	defer c.deviceMapMu.RUnlock()
+	return append([]int(nil), c.deviceMap...)
+}
+
+func threadsText(n int) string {
+	if n == 1 {
+		return "1 thread"
+	}
+	return fmt.Sprintf("%d threads", n)
+}
+
+// configuredThreads returns the number of mining threads the config asks for.
+func configuredThreads(cfg *Config) int {
+	if len(cfg.CPUAffinity) > 0 {
+		return len(cfg.CPUAffinity)
+	}
+	if cfg.CPUThreads > 0 {
+		return cfg.CPUThreads
+	}
+	return runtime.NumCPU()
+}
+
+// nodeSettingsFromConfig validates the node part of cfg the same way the Setup
+// form does and returns the settings used to start geth.
+func nodeSettingsFromConfig(cfg *Config, requireMiningService bool) (nodeStartSettings, error) {
+	settings := nodeStartSettings{
+		Enabled:    cfg.NodeEnabled,
+		CleanStart: cfg.NodeCleanStart,
+		Mode:       cfg.NodeMode,
+		DataDir:    strings.TrimSpace(cfg.NodeDataDir),
+		RPCPort:    cfg.NodeRPCPort,
+		P2PPort:    cfg.NodeP2PPort,
+		Bootnodes:  strings.TrimSpace(cfg.NodeBootnodes),
+		Verbosity:  cfg.NodeVerbosity,
+
+		StaticPeers:  cfg.NodeStaticPeers,
+		TrustedPeers: cfg.NodeTrustedPeers,
+	}
+	if settings.RPCPort < 1 || settings.RPCPort > 65535 {
+		return settings, errors.New("invalid node RPC port")
+	}
+	if settings.P2PPort < 1 || settings.P2PPort > 65535 {
+		return settings, errors.New("invalid node P2P port")
+	}
+	if settings.Bootnodes == "" {
+		settings.Bootnodes = defaultNodeBootnodes
+	}
+	if settings.Verbosity < 0 || settings.Verbosity > 5 {
+		return settings, errors.New("invalid node verbosity (0..5)")
+	}
+	if err := validatePeerList("static", settings.StaticPeers); err != nil {
+		return settings, err
+	}
+	if err := validatePeerList("trusted", settings.TrustedPeers); err != nil {
+		return settings, err
+	}
+	wallet := strings.TrimSpace(cfg.NodeEtherbase)
+	if wallet == "" {
+		wallet = strings.TrimSpace(cfg.WalletAddress)
+	}
+	if err := validateAddressChecksum("mining address", wallet); err != nil {
+		return settings, err
+	}
+	if settings.Enabled && (settings.Mode == nodeModeMine || requireMiningService) {
+		if !isHexAddress(wallet) {
+			return settings, errors.New("mining address is required for node mining (expected 0x + 40 hex chars)")
+		}
+		settings.Wallet = strings.ToLower(wallet)
+	} else if isHexAddress(wallet) {
+		settings.Wallet = strings.ToLower(wallet)
+	}
+	return settings, nil
+}
+
+// StartNode prepares the data directory and launches geth. It blocks while
+// the chain is initialised, so front-ends usually call it from a goroutine.
+func (c *Controller) StartNode(settings nodeStartSettings, requireMiningService bool) (err error) {
+	c.procMu.Lock()
+	if c.nodeCmd != nil && c.nodeCmd.Process != nil {
+		c.procMu.Unlock()
+		return nil
+	}
+	c.procMu.Unlock()
+
+	c.nodeStopRequested.Store(false)
+	c.setNodeState(nodeStateStarting)
+	defer func() {
+		if err != nil {
+			c.setNodeState(nodeStateOff)
+		}
+	}()
+
+	gethPath, err := findGeth()
+	if err != nil {
+		return fmt.Errorf("geth not found: %w", err)
+	}
+	genesisPath, err := ensureGenesisFile()
+	if err != nil {
+		return fmt.Errorf("failed to prepare genesis file: %w", err)
+	}
+
+	dataDir := strings.TrimSpace(settings.DataDir)
+	if dataDir == "" {
+		dataDir = defaultNodeDataDir()
+	}
+	dataDir, err = expandUserPath(dataDir)
+	if err != nil {
+		return err
+	}
+	if dataDir == "" {
+		return errors.New("node data directory is required")
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return err
+	}
+
+	if settings.CleanStart {
+		c.appendNodeLog("\n[node] Cleaning local chain data...\n")
+		if err := wipeNodeData(dataDir); err != nil {
+			return err
+		}
+		settings.CleanStart = false
+		_ = c.Update(func(next *Config) error {
+			next.NodeCleanStart = false
+			return nil
+		})
+	}
+
+	if !isGethInitialized(dataDir) {
+		c.appendNodeLog("\n[node] Initializing chain data...\n")
+		out, err := runGethInit(gethPath, dataDir, genesisPath)
+		if strings.TrimSpace(out) != "" {
+			c.appendNodeLog(out + "\n")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	effectiveMode := settings.Mode
+	if requireMiningService {
+		effectiveMode = nodeModeMine
+	}
+
+	args := []string{
+		"--datadir", dataDir,
+		"--http", "--http.addr", "127.0.0.1", "--http.port", strconv.Itoa(settings.RPCPort),
+		"--http.api", "eth,net,web3,miner,admin,olivetumhash,olivetum",
+		"--port", strconv.Itoa(settings.P2PPort),
+		"--syncmode", "snap",
+		"--gcmode", "full",
+		"--bootnodes", strings.TrimSpace(settings.Bootnodes),
+		"--verbosity", strconv.Itoa(settings.Verbosity),
+	}
+	peersConfig, err := writeGethPeersConfig(dataDir, settings.StaticPeers, settings.TrustedPeers)
+	if err != nil {
+		return fmt.Errorf("failed to write peers config: %w", err)
+	}
+	if peersConfig != "" {
+		args = append(args, "--config", peersConfig)
+	}
+	autoStartMiningServiceAfterSync := false
+	if effectiveMode == nodeModeMine {
+		if !isHexAddress(settings.Wallet) {
+			return errors.New("wallet address is required for node mining")
+		}
+		// Do not start mining immediately: in core-geth this disables snap sync.
+		// We'll enable the mining service after the initial sync completes.
+		autoStartMiningServiceAfterSync = true
+		args = append(args,
+			"--miner.recommit=10s",
+			"--miner.etherbase", settings.Wallet,
+		)
+	}
+
+	nodeCtx, nodeCancel := context.WithCancel(context.Background())
+	cmd := c.commandContext(nodeCtx, gethPath, args...)
+	configureChildProcess(cmd)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+
+	c.appendNodeLog(fmt.Sprintf("\nStarting node: %s %s\n\n", gethPath, strings.Join(args, " ")))
+	if err := cmd.Start(); err != nil {
+		nodeCancel()
+		return err
+	}
+
+	c.procMu.Lock()
+	c.nodeCmd = cmd
+	c.nodeCancel = nodeCancel
+	c.nodeRunMode = effectiveMode
+	c.procMu.Unlock()
+
+	go streamLines(stdout, c.appendNodeLog)
+	go streamLines(stderr, c.appendNodeLog)
+
+	if autoStartMiningServiceAfterSync {
+		go autoStartMiningService(nodeCtx, settings.RPCPort, c.appendNodeLog)
+	}
+
+	go func(ctx context.Context, port int) {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 750*time.Millisecond)
+			if err == nil {
+				_ = conn.Close()
+				c.setNodeState(nodeStateRunning)
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}(nodeCtx, settings.RPCPort)
+
+	go func() {
+		err := cmd.Wait()
+		c.procMu.Lock()
+		c.nodeCmd = nil
+		c.nodeRunMode = ""
+		if c.nodeCancel != nil {
+			c.nodeCancel()
+			c.nodeCancel = nil
+		}
+		c.procMu.Unlock()
+
+		c.setNodeState(nodeStateOff)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			c.appendNodeLog(fmt.Sprintf("\n[node exit] %v\n", err))
+		} else {
+			c.appendNodeLog("\n[node exit] node stopped\n")
+		}
+	}()
+	return nil
+}
+
+func (c *Controller) StopNode() {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	if c.nodeCmd == nil || c.nodeCmd.Process == nil {
+		return
+	}
+	c.nodeStopRequested.Store(true)
+	c.appendNodeLog("\nStopping node...\n")
+	cmd := c.nodeCmd
+	proc := c.nodeCmd.Process
+	if err := sendProcessInterrupt(proc); err != nil {
+		c.appendNodeLog(fmt.Sprintf("[node] interrupt failed: %v\n", err))
+	}
+	go func(cmd *exec.Cmd, p *os.Process) {
+		time.Sleep(60 * time.Second)
+		c.procMu.Lock()
+		still := c.nodeCmd == cmd
+		c.procMu.Unlock()
+		if still {
+			c.appendNodeLog("[node] Force-killing node (timeout)\n")
+			_ = p.Kill()
+		}
+	}(cmd, proc)
+}
+
+// ResetNodeData stops the node, waits for it to exit and removes the local
+// chain data in dataDir. The keystore is kept. The node is left stopped.
+func (c *Controller) ResetNodeData(dataDir string) error {
+	c.setNodeState(nodeStateResetting)
+	c.StopNode()
+	if !c.waitForNodeExit(context.Background(), 90*time.Second) {
+		c.setNodeState(nodeStateOff)
+		return errors.New("node did not stop in time")
+	}
+
+	c.appendNodeLog("\n[node] Removing local chain data...\n")
+	if err := wipeNodeData(dataDir); err != nil {
+		c.setNodeState(nodeStateOff)
+		return err
+	}
+	c.nodeIssueCount.Store(0)
+	c.nodeIssueFirstAt.Store(0)
+	c.setNodeState(nodeStateOff)
+	return nil
+}
+
+func (c *Controller) waitForMinerExit(ctx context.Context, timeout time.Duration) bool {
+	return waitUntil(ctx, timeout, func() bool { return !c.MinerRunning() })
+}
+
+func (c *Controller) waitForNodeExit(ctx context.Context, timeout time.Duration) bool {
+	return waitUntil(ctx, timeout, func() bool { return !c.NodeRunning() })
+}
+
+func waitUntil(ctx context.Context, timeout time.Duration, done func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if done() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func (c *Controller) stopWatchdogSession() {
+	c.procMu.Lock()
+	cancel := c.watchdogCancel
+	c.watchdogCancel = nil
+	c.watchdogRestarting.Store(false)
+	c.procMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (c *Controller) startWatchdogSession(settings watchdogSettings) {
+	c.procMu.Lock()
+	if c.watchdogCancel != nil {
+		c.procMu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watchdogCancel = cancel
+	c.procMu.Unlock()
+
+	c.appendMinerLog(fmt.Sprintf("[watchdog] Enabled (no-job %s, retry %s)\n",
+		settings.NoJobTimeout, settings.RetryWindow))
+
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		var (
+			outageStart  time.Time
+			lastSeenJob  int64
+			restartCount int
+		)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			jobAt := c.lastJobAt.Load()
+			if jobAt != 0 && jobAt != lastSeenJob {
+				lastSeenJob = jobAt
+				outageStart = time.Time{}
+				restartCount = 0
+				continue
+			}
+
+			refAt := jobAt
+			if refAt == 0 {
+				refAt = c.minerStartedAt.Load()
+			}
+			if refAt == 0 {
+				refAt = time.Now().UnixNano()
+			}
+			elapsed := time.Since(time.Unix(0, refAt))
+			if elapsed <= settings.NoJobTimeout {
+				continue
+			}
+
+			if outageStart.IsZero() {
+				outageStart = time.Now()
+			}
+			if settings.RetryWindow > 0 && time.Since(outageStart) > settings.RetryWindow {
+				c.appendMinerLog(fmt.Sprintf("[watchdog] No jobs for %s (retry window reached). Stopping miner.\n", elapsed))
+				c.StopMiner(minerStopOriginUser)
+				// Giving up is a failure, not a requested stop.
+				c.minerStopRequested.Store(false)
+				return
+			}
+
+			if !c.watchdogRestarting.CompareAndSwap(false, true) {
+				continue
+			}
+			restartCount++
+			c.watchdogRestarts.Add(1)
+			c.appendMinerLog(fmt.Sprintf("[watchdog] No jobs for %s. Restarting miner (attempt %d).\n", elapsed, restartCount))
+
+			c.restartMiner(ctx, settings.RestartDelay, "[watchdog]")
+		}
+	}()
+}
+
+// restartMiner stops xmrig and starts it again after delay. The caller must
+// have set watchdogRestarting; it is cleared once the miner is started
+// again, so front-ends treat the stop as a restart rather than a crash.
+func (c *Controller) restartMiner(ctx context.Context, delay time.Duration, logPrefix string) {
+	defer c.watchdogRestarting.Store(false)
+
+	c.StopMiner(minerStopOriginWatchdog)
+	_ = c.waitForMinerExit(ctx, 25*time.Second)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+
+	c.minerStartedAt.Store(time.Now().UnixNano())
+	c.lastJobAt.Store(0)
+	c.currentJobBlock.Store(0)
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err := c.StartMiner(minerStartOriginWatchdog); err != nil {
+		c.appendMinerLog(fmt.Sprintf("%s Restart failed: %v\n", logPrefix, err))
+	}
+}
+
+func (c *Controller) stopFailbackSession() {
+	c.procMu.Lock()
+	cancel := c.failbackCancel
+	c.failbackCancel = nil
+	c.procMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// startFailbackSession restarts the miner on the primary pool once it has
+// accepted TCP connections for healthyFor while xmrig mines on a backup.
+// xmrig itself only moves back when the backup fails.
+func (c *Controller) startFailbackSession(primary PoolConfig, healthyFor time.Duration) {
+	endpoint := poolEndpoint(primary)
+	if endpoint == "" {
+		return
+	}
+	c.procMu.Lock()
+	if c.failbackCancel != nil {
+		c.procMu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.failbackCancel = cancel
+	c.procMu.Unlock()
+
+	c.appendMinerLog(fmt.Sprintf("[pool] Failback to %s enabled (after %s healthy)\n", endpoint, healthyFor))
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+
+		var healthySince time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if c.ActivePool() <= 0 || c.watchdogRestarting.Load() {
+				healthySince = time.Time{}
+				continue
+			}
+			conn, err := net.DialTimeout("tcp", endpoint, 3*time.Second)
+			if err != nil {
+				healthySince = time.Time{}
+				continue
+			}
+			_ = conn.Close()
+			if healthySince.IsZero() {
+				healthySince = time.Now()
+				continue
+			}
+			if time.Since(healthySince) < healthyFor {
+				continue
+			}
+
+			if !c.watchdogRestarting.CompareAndSwap(false, true) {
+				continue
+			}
+			healthySince = time.Time{}
+			c.appendMinerLog(fmt.Sprintf("[pool] Primary pool %s healthy for %s. Restarting miner on it.\n", endpoint, healthyFor))
+			c.restartMiner(ctx, time.Second, "[pool]")
+		}
+	}()
+}
+
+func (c *Controller) StopMiner(origin minerStopOrigin) {
+	if origin != minerStopOriginWatchdog {
+		c.stopWatchdogSession()
+		c.stopFailbackSession()
+	}
+	if origin == minerStopOriginUser {
+		// A manual pause ends with the process; no resume is sent.
+		c.limitMu.Lock()
+		delete(c.pauseHolds, limitSourceUser)
+		c.limitMu.Unlock()
+	}
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	if c.minerCmd == nil || c.minerCmd.Process == nil {
+		return
+	}
+	if origin != minerStopOriginWatchdog {
+		c.minerStopRequested.Store(true)
+	}
+	c.appendMinerLog("\nStopping miner...\n")
+	cmd := c.minerCmd
+	proc := c.minerCmd.Process
+	if err := sendProcessInterrupt(proc); err != nil {
+		c.appendMinerLog(fmt.Sprintf("[miner] interrupt failed: %v\n", err))
+	}
+	go func(cmd *exec.Cmd, p *os.Process) {
+		time.Sleep(10 * time.Second)
+		c.procMu.Lock()
+		still := c.minerCmd == cmd
+		c.procMu.Unlock()
+		if still {
+			c.appendMinerLog("[miner] Force-killing miner (timeout)\n")
+			_ = p.Kill()
+		}
+	}(cmd, proc)
+}
+
+// StartMiner launches xmrig with the current config. Callers that edit the
+// config (like the Setup form) must validate and save it first.
+func (c *Controller) StartMiner(origin minerStartOrigin) error {
+	if origin == minerStartOriginUser {
+		c.setThreadLimit(limitSourceSchedule, 0)
+		c.setPauseHold(limitSourceSchedule, false)
+		c.setPauseHold(limitSourceUser, false)
+	}
+	limit := c.threadLimit()
+	saved := c.Snapshot()
+	cfg := applyThreadLimit(saved, limit)
+	if c.xmrigErr != nil {
+		return fmt.Errorf("xmrig not found: %w", c.xmrigErr)
+	}
+
+	c.procMu.Lock()
+	if c.minerCmd != nil && c.minerCmd.Process != nil {
+		c.procMu.Unlock()
+		return errMinerAlreadyRunning
+	}
+	if c.autotuning.Load() {
+		c.procMu.Unlock()
+		return errAutotuneRunning
+	}
+
+	port, err := pickFreePort()
+	if err != nil {
+		c.procMu.Unlock()
+		return err
+	}
+	c.apiPort = port
+
+	poolURL, err := buildPoolURL(cfg)
+	if err != nil {
+		c.procMu.Unlock()
+		return err
+	}
+
+	if cfg.Mode == modeRPCLocal {
+		nodeRunning := c.nodeCmd != nil && c.nodeCmd.Process != nil
+		runningMode := c.nodeRunMode
+		if cfg.NodeEnabled {
+			if !nodeRunning {
+				c.procMu.Unlock()
+				return errors.New("node is enabled but not running; start it in Setup → Node")
+			}
+			if runningMode != nodeModeMine {
+				c.procMu.Unlock()
+				return errors.New("node is running without mining service enabled; restart the node with mining service enabled")
+			}
+		}
+
+		u, err := url.Parse(poolURL)
+		if err != nil || u.Host == "" {
+			c.procMu.Unlock()
+			return errors.New("invalid RPC URL")
+		}
+		host := u.Host
+		if !strings.Contains(host, ":") {
+			if strings.Contains(strings.ToLower(u.Scheme), "https") {
+				host += ":443"
+			} else {
+				host += ":80"
+			}
+		}
+		conn, err := net.DialTimeout("tcp", host, 750*time.Millisecond)
+		if err != nil {
+			c.procMu.Unlock()
+			return fmt.Errorf("RPC is not reachable at %s", host)
+		}
+		_ = conn.Close()
+	}
+
+	c.resetMinerLog()
+
+	accessToken, err := generateAPIToken()
+	if err != nil {
+		c.procMu.Unlock()
+		return err
+	}
+	xc, err := buildXMRigConfig(cfg, c.apiPort, accessToken)
+	if err != nil {
+		c.procMu.Unlock()
+		return err
+	}
+	configFile, err := writeXMRigConfig(xc)
+	if err != nil {
+		c.procMu.Unlock()
+		return fmt.Errorf("failed to write xmrig config: %w", err)
+	}
+	c.xmrigToken = accessToken
+	c.minerPools = miningPools(cfg)
+	c.minerLimit = limit
+	c.minerConfig = saved
+	args := []string{"--config", configFile}
+
+	runXMRigPath := c.xmrigPath
+	if runtime.GOOS == "linux" {
+		p, err := prepareXMRigBinary(c.xmrigPath)
+		if err != nil {
+			c.procMu.Unlock()
+			return err
+		}
+		runXMRigPath = p
+		if cfg.EnableMSR && cfg.AutoGrantMSR {
+			if err := ensureLinuxMSRAccess(runXMRigPath); err != nil {
+				c.appendMinerLog(fmt.Sprintf("[msr] Auto grant failed: %v\n", err))
+			}
+		}
+		if cfg.EnableMSR {
+			if ok, err := hasLinuxMSRCaps(runXMRigPath); err == nil {
+				if ok {
+					c.appendMinerLog("[msr] CAP_SYS_RAWIO and CAP_DAC_OVERRIDE detected on xmrig binary.\n")
+				} else {
+					c.appendMinerLog("[msr] Required Linux capabilities missing (CAP_SYS_RAWIO + CAP_DAC_OVERRIDE); MSR tweak may fail.\n")
+				}
+			}
+		}
+	}
+
+	c.setDeviceMap(cfg.CPUAffinity)
+
+	c.minerStartedAt.Store(time.Now().UnixNano())
+	c.lastJobAt.Store(0)
+	c.currentJobBlock.Store(0)
+	c.lastFoundBlock.Store(0)
+	c.jobDifficulty.Store("")
+	c.activePool.Store(-1)
+
+	minerCtx, minerCancel := context.WithCancel(context.Background())
+	cmd := c.commandContext(minerCtx, runXMRigPath, args...)
+	configureChildProcess(cmd)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+
+	c.appendMinerLog(fmt.Sprintf("Starting: %s %s\n\n", runXMRigPath, strings.Join(args, " ")))
+
+	if err := cmd.Start(); err != nil {
+		minerCancel()
+		c.procMu.Unlock()
+		return err
+	}
+	c.minerCmd = cmd
+	c.minerStopRequested.Store(false)
+	c.minerCancel = minerCancel
+	c.waitingForStats.Store(true)
+	c.lastAccepted.Store(0)
+
+	pollCtx, pollCancelFn := context.WithCancel(context.Background())
+	c.pollCancel = pollCancelFn
+	apiPort := c.apiPort
+	xmrigToken := c.xmrigToken
+	c.procMu.Unlock()
+
+	c.setMinerState(minerStateStarting)
+
+	if origin != minerStartOriginWatchdog && cfg.WatchdogEnabled {
+		c.startWatchdogSession(watchdogSettings{
+			NoJobTimeout: time.Duration(cfg.WatchdogNoJobTimeoutSec) * time.Second,
+			RestartDelay: time.Duration(cfg.WatchdogRestartDelaySec) * time.Second,
+			RetryWindow:  time.Duration(cfg.WatchdogRetryWindowMin) * time.Minute,
+		})
+	}
+	if origin != minerStartOriginWatchdog && cfg.PoolFailbackEnabled && len(c.poolsSnapshot()) > 1 {
+		c.startFailbackSession(primaryPool(cfg), time.Duration(cfg.PoolFailbackMin)*time.Minute)
+	}
+
+	go streamLines(stdout, c.appendMinerLog)
+	go streamLines(stderr, c.appendMinerLog)
+
+	go pollStats(pollCtx, "127.0.0.1", apiPort, xmrigToken, c.handleStat, func(err error) {
+		if c.waitingForStats.Load() {
+			msg := strings.ToLower(err.Error())
+			if strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host") {
+				return
+			}
+		}
+		c.appendMinerLog(fmt.Sprintf("[api] %v\n", err))
+	})
+
+	go func() {
+		err := cmd.Wait()
+		c.procMu.Lock()
+		c.minerCmd = nil
+		c.setDeviceMap(nil)
+		if c.pollCancel != nil {
+			c.pollCancel()
+			c.pollCancel = nil
+		}
+		if c.minerCancel != nil {
+			c.minerCancel()
+			c.minerCancel = nil
+		}
+		c.procMu.Unlock()
+
+		c.waitingForStats.Store(false)
+		c.lastAccepted.Store(0)
+		c.lastJobAt.Store(0)
+		c.currentJobBlock.Store(0)
+		c.lastFoundBlock.Store(0)
+		c.jobDifficulty.Store("")
+		c.lastStats.Store(nil)
+		c.activePool.Store(-1)
+		c.minerPaused.Store(false)
+		c.setMinerState(minerStateStopped)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			c.appendMinerLog(fmt.Sprintf("\n[exit] %v\n", err))
+		} else {
+			c.appendMinerLog("\n[exit] miner stopped\n")
+		}
+	}()
+	return nil
+}
+
+// remapThreadStats moves per-thread values from xmrig thread order to the
+// logical CPU each thread is pinned to. deviceMap[i] is the CPU of thread i;
+// CPUs without a thread get -1 hashrate and power.
+func remapThreadStats(s Stat, deviceMap []int) Stat {
+	maxCPU := -1
+	for _, cpu := range deviceMap {
+		if cpu > maxCPU {
+			maxCPU = cpu
+		}
+	}
+	if maxCPU < 0 {
+		return s
+	}
+	hashes := make([]int64, maxCPU+1)
+	temps := make([]int, maxCPU+1)
+	fans := make([]int, maxCPU+1)
+	power := make([]float64, maxCPU+1)
+	for i := range hashes {
+		hashes[i] = -1
+		power[i] = -1
+	}
+	for thread, cpu := range deviceMap {
+		if cpu < 0 {
+			continue
+		}
+		if thread < len(s.PerGPU_KHs) {
+			if hashes[cpu] < 0 {
+				hashes[cpu] = 0
+			}
+			hashes[cpu] += s.PerGPU_KHs[thread]
+		}
+		if thread < len(s.Temps) {
+			temps[cpu] = s.Temps[thread]
+		}
+		if thread < len(s.Fans) {
+			fans[cpu] = s.Fans[thread]
+		}
+		if thread < len(s.PerGPU_Power) {
+			power[cpu] = s.PerGPU_Power[thread]
+		}
+	}
+	s.PerGPU_KHs = hashes
+	s.Temps = temps
+	s.Fans = fans
+	s.PerGPU_Power = power
+	s.CPUIndexed = true
+	return s
+}
+
+// poolsSnapshot returns the pool list the running miner was started with.
+func (c *Controller) poolsSnapshot() []PoolConfig {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	return c.minerPools
+}
+
+// runningConfig returns the settings the running miner uses, including the
+// active thread limit, or the saved config while no miner was started.
+func (c *Controller) runningConfig() *Config {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+	if c.minerConfig == nil {
+		return c.Snapshot()
+	}
+	return applyThreadLimit(c.minerConfig, c.minerLimit)
+}
+
+func (c *Controller) handleStat(s Stat) {
+	cfg := c.runningConfig()
+	if deviceMap := c.getDeviceMap(); len(deviceMap) > 0 && !s.CPUIndexed {
+		s = remapThreadStats(s, deviceMap)
+	}
+	firstStat := c.waitingForStats.Swap(false)
+	prevAccepted := c.lastAccepted.Swap(s.Accepted)
+	hasNewAccept := s.Accepted > prevAccepted
+	if s.Difficulty > 0 {
+		c.jobDifficulty.Store(formatDifficulty(s.Difficulty))
+	}
+	updateLastFoundFromAccept := cfg.Mode != modeRPCLocal
+	if hasNewAccept && updateLastFoundFromAccept {
+		if block := c.currentJobBlock.Load(); block > 0 {
+			c.lastFoundBlock.Store(block)
+			c.emit(Event{Kind: eventFoundBlock, Block: block, Source: blockSourceShare})
+		}
+	}
+	totalHashrate := s.TotalHashrate
+	if totalHashrate <= 0 {
+		totalHashrate = float64(s.TotalKHs)
+	}
+	if totalHashrate <= 0 {
+		for _, v := range s.PerGPU_KHs {
+			if v > 0 {
+				totalHashrate += float64(v)
+			}
+		}
+	}
+	threadCount := s.ActiveThreads
+	if threadCount <= 0 {
+		if len(cfg.CPUAffinity) > 0 {
+			threadCount = len(cfg.CPUAffinity)
+		} else if cfg.CPUThreads > 0 {
+			threadCount = cfg.CPUThreads
+		} else if len(s.PerGPU_KHs) > 0 {
+			threadCount = len(s.PerGPU_KHs)
+		}
+	}
+	if firstStat {
+		c.setMinerState(minerStateRunning)
+	}
+	c.setMinerPaused(s.Paused)
+	if held := c.pauseHeld(); held != s.Paused {
+		c.syncPause(held)
+	}
+	poolIndex := activePoolIndex(c.poolsSnapshot(), s.Pool)
+	if prev := int(c.activePool.Swap(int32(poolIndex))); prev != poolIndex && poolIndex >= 0 {
+		if poolIndex == 0 {
+			if prev > 0 {
+				c.appendMinerLog(fmt.Sprintf("[pool] Back on primary pool %s\n", s.Pool))
+			}
+		} else {
+			c.appendMinerLog(fmt.Sprintf("[pool] Switched to backup pool %d (%s)\n", poolIndex, s.Pool))
+		}
+	}
+	st := minerStats{
+		Stat:       s,
+		Hashrate:   totalHashrate,
+		Threads:    threadCount,
+		NewAccept:  hasNewAccept,
+		JobBlock:   c.currentJobBlock.Load(),
+		Difficulty: c.JobDifficulty(),
+		LastFound:  c.lastFoundBlock.Load(),
+		PoolIndex:  poolIndex,
+	}
+	c.lastStats.Store(&st)
+	c.emit(Event{Kind: eventStats, Stats: st})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemapThreadStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		stat      Stat
+		deviceMap []int
+		want      Stat
+	}{
+		{
+			name:      "in order",
+			stat:      Stat{PerGPU_KHs: []int64{10, 20}, Temps: []int{50, 60}, Fans: []int{1, 2}, PerGPU_Power: []float64{5, 6}},
+			deviceMap: []int{0, 1},
+			want:      Stat{PerGPU_KHs: []int64{10, 20}, Temps: []int{50, 60}, Fans: []int{1, 2}, PerGPU_Power: []float64{5, 6}, CPUIndexed: true},
+		},
+		{
+			name:      "gaps are marked idle",
+			stat:      Stat{PerGPU_KHs: []int64{10, 20}, PerGPU_Power: []float64{5, 6}},
+			deviceMap: []int{3, 1},
+			want:      Stat{PerGPU_KHs: []int64{-1, 20, -1, 10}, Temps: []int{0, 0, 0, 0}, Fans: []int{0, 0, 0, 0}, PerGPU_Power: []float64{-1, 6, -1, 5}, CPUIndexed: true},
+		},
+		{
+			name:      "threads sharing a CPU add up",
+			stat:      Stat{PerGPU_KHs: []int64{10, 20, 30}},
+			deviceMap: []int{0, 0, 1},
+			want:      Stat{PerGPU_KHs: []int64{30, 30}, Temps: []int{0, 0}, Fans: []int{0, 0}, PerGPU_Power: []float64{-1, -1}, CPUIndexed: true},
+		},
+		{
+			name:      "more threads than reported",
+			stat:      Stat{PerGPU_KHs: []int64{10}},
+			deviceMap: []int{0, 1},
+			want:      Stat{PerGPU_KHs: []int64{10, -1}, Temps: []int{0, 0}, Fans: []int{0, 0}, PerGPU_Power: []float64{-1, -1}, CPUIndexed: true},
+		},
+		{
+			name:      "no pinned CPU",
+			stat:      Stat{PerGPU_KHs: []int64{10}},
+			deviceMap: []int{-1},
+			want:      Stat{PerGPU_KHs: []int64{10}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remapThreadStats(tt.stat, tt.deviceMap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("remapThreadStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleStatThreads(t *testing.T) {
+	tests := []struct {
+		name    string
+		saved   Config
+		running *Config
+		limit   int
+		stat    Stat
+		want    int
+	}{
+		{
+			name: "reported by xmrig",
+			stat: Stat{ActiveThreads: 3},
+			want: 3,
+		},
+		{
+			name:  "saved config while stopped",
+			saved: Config{ProfileSettings: ProfileSettings{CPUThreads: 4}},
+			want:  4,
+		},
+		{
+			name:    "config the miner was started with",
+			saved:   Config{ProfileSettings: ProfileSettings{CPUThreads: 8}},
+			running: &Config{ProfileSettings: ProfileSettings{CPUThreads: 2}},
+			want:    2,
+		},
+		{
+			name:    "affinity of the running miner",
+			saved:   Config{ProfileSettings: ProfileSettings{CPUAffinity: []int{0, 1, 2, 3}}},
+			running: &Config{ProfileSettings: ProfileSettings{CPUAffinity: []int{0, 1}}},
+			want:    2,
+		},
+		{
+			name:    "thread limit",
+			saved:   Config{ProfileSettings: ProfileSettings{CPUThreads: 8}},
+			running: &Config{ProfileSettings: ProfileSettings{CPUThreads: 8}},
+			limit:   3,
+			want:    3,
+		},
+		{
+			name: "per-thread hashrates",
+			stat: Stat{PerGPU_KHs: []int64{1, 2, 3, 4, 5}},
+			want: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := tt.saved
+			c := newController(&saved, "", nil)
+			c.minerConfig = tt.running
+			c.minerLimit = tt.limit
+			events, unsubscribe := c.Subscribe(8)
+			defer unsubscribe()
+
+			c.handleStat(tt.stat)
+			for {
+				select {
+				case ev := <-events:
+					if ev.Kind != eventStats {
+						continue
+					}
+					if ev.Stats.Threads != tt.want {
+						t.Errorf("Threads = %d, want %d", ev.Stats.Threads, tt.want)
+					}
+				default:
+					t.Fatal("no stats event")
+				}
+				return
+			}
+		})
+	}
+}
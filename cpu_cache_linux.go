@@ -0,0 +1,52 @@
+//go:build linux
+
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// l3CacheBytes sums the distinct L3 caches under root (normally /sys), or
+// returns 0 when sysfs has no cache information.
+func l3CacheBytes(root string) int64 {
+	dir := filepath.Join(root, "devices", "system", "cpu")
+	seen := make(map[string]bool)
+	var total int64
+	for _, cpu := range sysfsIndexedEntries(dir, "cpu") {
+		cacheDir := filepath.Join(dir, cpu, "cache")
+		for _, index := range sysfsIndexedEntries(cacheDir, "index") {
+			base := filepath.Join(cacheDir, index)
+			if level, ok := readSysfsInt(filepath.Join(base, "level")); !ok || level != 3 {
+				continue
+			}
+			shared := readSysfsString(filepath.Join(base, "shared_cpu_list"))
+			if shared == "" || seen[shared] {
+				continue
+			}
+			seen[shared] = true
+			total += parseCacheSize(readSysfsString(filepath.Join(base, "size")))
+		}
+	}
+	return total
+}
+
+// parseCacheSize parses sysfs cache sizes such as "32768K" or "32M".
+func parseCacheSize(text string) int64 {
+	text = strings.TrimSpace(text)
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(text, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(text, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(text, "G"):
+		mult = 1 << 30
+	}
+	n, err := strconv.ParseInt(strings.TrimRight(text, "KMG"), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n * mult
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func l3CacheBytes(root string) int64 {
+	return 0
+}
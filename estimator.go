@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	secondsPerDay = 24 * 60 * 60
+
+	difficultySourceNode = "node"
+	difficultySourceJob  = "pool job"
+)
+
+// miningEstimate is the expected outcome of solo mining. A hash finds a block
+// with probability 1/difficulty, so blocks arrive as a Poisson process with
+// rate hashrate/difficulty per second.
+type miningEstimate struct {
+	Hashrate   float64 // H/s
+	Difficulty float64
+
+	BlockTimeSec float64 // expected time to the next block
+	Chance24h    float64 // probability of at least one block, 0..1
+	Chance7d     float64
+	BlocksPerDay float64
+	// RewardPerDay is the expected OLIVO per day; zero without a block
+	// reward.
+	RewardPerDay float64
+}
+
+func estimateMining(hashrate, difficulty, blockReward float64) (miningEstimate, bool) {
+	if hashrate <= 0 || difficulty <= 0 {
+		return miningEstimate{}, false
+	}
+	rate := hashrate / difficulty
+	e := miningEstimate{
+		Hashrate:     hashrate,
+		Difficulty:   difficulty,
+		BlockTimeSec: 1 / rate,
+		Chance24h:    -math.Expm1(-rate * secondsPerDay),
+		Chance7d:     -math.Expm1(-rate * 7 * secondsPerDay),
+		BlocksPerDay: rate * secondsPerDay,
+	}
+	if blockReward > 0 {
+		e.RewardPerDay = e.BlocksPerDay * blockReward
+	}
+	return e, true
+}
+
+// networkDifficulty returns the difficulty blocks are mined at and its
+// source: the latest block of the embedded node or, in the RPC modes, of the
+// configured RPC endpoint; otherwise the current job of the solo pool.
+func networkDifficulty(ctx context.Context, ctrl *Controller) (float64, string, error) {
+	var rpcErr error
+	if endpoint := chainRPCEndpoint(ctrl); endpoint != "" {
+		d, err := rpcLatestDifficulty(ctx, endpoint)
+		if err == nil && d > 0 {
+			return d, difficultySourceNode, nil
+		}
+		rpcErr = err
+	}
+	if st, ok := ctrl.LastStats(); ok && st.Stat.Difficulty > 0 {
+		return st.Stat.Difficulty, difficultySourceJob, nil
+	}
+	// xmrig logs the job difficulty as a plain number.
+	if d, err := strconv.ParseFloat(ctrl.JobDifficulty(), 64); err == nil && d > 0 {
+		return d, difficultySourceJob, nil
+	}
+	if rpcErr != nil {
+		return 0, "", rpcErr
+	}
+	return 0, "", errors.New("start the miner or the node first")
+}
+
+// chainRPCEndpoint is the node RPC to read chain data from: the embedded
+// node while it runs, else the RPC endpoint of the RPC modes.
+func chainRPCEndpoint(ctrl *Controller) string {
+	cfg := ctrl.Snapshot()
+	if ctrl.NodeRunning() {
+		return fmt.Sprintf("http://127.0.0.1:%d", cfg.NodeRPCPort)
+	}
+	if cfg.Mode == modeRPCLocal || cfg.Mode == modeRPCGateway {
+		if endpoint, err := normalizeRPCURL(cfg.RPCURL); err == nil {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+func rpcLatestDifficulty(ctx context.Context, endpoint string) (float64, error) {
+	result, err := rpcCall(ctx, endpoint, "eth_getBlockByNumber", []any{"latest", false})
+	if err != nil {
+		return 0, err
+	}
+	var block struct {
+		Difficulty string `json:"difficulty"`
+	}
+	if err := json.Unmarshal(result, &block); err != nil {
+		return 0, err
+	}
+	d, ok := new(big.Int).SetString(strings.TrimPrefix(block.Difficulty, "0x"), 16)
+	if !ok {
+		return 0, fmt.Errorf("invalid block difficulty %q", block.Difficulty)
+	}
+	f, _ := new(big.Float).SetInt(d).Float64()
+	return f, nil
+}
+
+// parseHashrate reads a hashrate like "850", "12.5k", "3 MH/s" or "1.2 KH/s"
+// in H/s.
+func parseHashrate(s string) (float64, error) {
+	text := strings.TrimSpace(s)
+	lower := strings.ToLower(text)
+	lower = strings.TrimSuffix(lower, "/s")
+	lower = strings.TrimSuffix(lower, "h")
+	lower = strings.TrimSpace(lower)
+	mult := 1.0
+	if n := len(lower); n > 0 {
+		switch lower[n-1] {
+		case 'k':
+			mult = 1e3
+		case 'm':
+			mult = 1e6
+		case 'g':
+			mult = 1e9
+		}
+		if mult > 1 {
+			lower = strings.TrimSpace(lower[:n-1])
+		}
+	}
+	v, err := strconv.ParseFloat(lower, 64)
+	if err != nil || v <= 0 || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid hashrate %q", text)
+	}
+	return v * mult, nil
+}
+
+// formatExpectedTime renders an expected duration in seconds from minutes up
+// to years.
+func formatExpectedTime(sec float64) string {
+	switch {
+	case sec < 60:
+		return "< 1 min"
+	case sec < 3600:
+		return fmt.Sprintf("%.0f min", sec/60)
+	case sec < 2*secondsPerDay:
+		return fmt.Sprintf("%.1f h", sec/3600)
+	case sec < 365*secondsPerDay:
+		return fmt.Sprintf("%.1f days", sec/secondsPerDay)
+	default:
+		return fmt.Sprintf("%.1f years", sec/(365*secondsPerDay))
+	}
+}
+
+func formatChance(p float64) string {
+	switch {
+	case p >= 0.9995:
+		return "> 99.9%"
+	case p < 0.001:
+		return "< 0.1%"
+	default:
+		return fmt.Sprintf("%.1f%%", p*100)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseHashrate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"850", 850, true},
+		{" 850 H/s ", 850, true},
+		{"12.5k", 12500, true},
+		{"1.2 KH/s", 1200, true},
+		{"3 MH/s", 3e6, true},
+		{"2gh", 2e9, true},
+		{"", 0, false},
+		{"k", 0, false},
+		{"fast", 0, false},
+		{"0", 0, false},
+		{"-5k", 0, false},
+		{"1e999", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseHashrate(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseHashrate(%q) = %v, %v, want %v (ok %v)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEstimateMining(t *testing.T) {
+	// One expected block per day.
+	e, ok := estimateMining(1000, 1000*secondsPerDay, 2)
+	if !ok {
+		t.Fatal("estimateMining() not ok")
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"expected time", e.BlockTimeSec, secondsPerDay},
+		{"chance in 24h", e.Chance24h, 1 - math.Exp(-1)},
+		{"chance in 7d", e.Chance7d, 1 - math.Exp(-7)},
+		{"blocks per day", e.BlocksPerDay, 1},
+		{"reward per day", e.RewardPerDay, 2},
+	}
+	for _, tt := range tests {
+		if !near(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if e, _ := estimateMining(1000, 1000*secondsPerDay, 0); e.RewardPerDay != 0 {
+		t.Errorf("reward per day without a block reward = %v", e.RewardPerDay)
+	}
+	for _, in := range [][2]float64{{0, 1e6}, {1000, 0}, {-1, 1e6}} {
+		if e, ok := estimateMining(in[0], in[1], 2); ok || e != (miningEstimate{}) {
+			t.Errorf("estimateMining(%v, %v) = %+v, %v, want no estimate", in[0], in[1], e, ok)
+		}
+	}
+}
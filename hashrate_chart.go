@@ -17,6 +17,7 @@ type hashrateChart struct {
 	raster    *canvas.Raster
 	view      fyne.CanvasObject
 	maxPoints int
+	unit      string
 
 	mu     sync.Mutex
 	points []float64
@@ -121,7 +122,7 @@ func newHashrateChart(maxPoints int) *hashrateChart {
 	if maxPoints < 2 {
 		maxPoints = 2
 	}
-	c := &hashrateChart{maxPoints: maxPoints}
+	c := &hashrateChart{maxPoints: maxPoints, unit: "H/s"}
 	c.raster = canvas.NewRaster(func(w, h int) image.Image {
 		return c.render(w, h)
 	})
@@ -131,7 +132,7 @@ func newHashrateChart(maxPoints int) *hashrateChart {
 	tickColor := toNRGBA(theme.Color(theme.ColorNamePlaceHolder))
 	tickColor.A = 0xCC
 
-	c.unitText = canvas.NewText("H/s", tickColor)
+	c.unitText = canvas.NewText(c.unit, tickColor)
 	c.unitText.Alignment = fyne.TextAlignLeading
 	c.unitText.TextStyle = fyne.TextStyle{Bold: true, Monospace: true}
 	c.unitText.TextSize = theme.TextSize() * 0.85
@@ -164,6 +165,14 @@ func (c *hashrateChart) Object() fyne.CanvasObject {
 	return c.view
 }
 
+// SetUnit changes the unit shown above the axis labels, H/s by default.
+// Call it before the first points are added.
+func (c *hashrateChart) SetUnit(unit string) {
+	c.unit = unit
+	c.unitText.Text = unit
+	c.unitText.Refresh()
+}
+
 func (c *hashrateChart) Add(mhs float64) {
 	if mhs < 0 || math.IsNaN(mhs) || math.IsInf(mhs, 0) {
 		return
@@ -182,17 +191,47 @@ func (c *hashrateChart) Add(mhs float64) {
 	c.raster.Refresh()
 }
 
+// SetPoints replaces the plotted series, e.g. with a range loaded from the
+// history store. Unlike Add it does not trim to maxPoints. NaN values are
+// gaps: nothing is drawn there.
+func (c *hashrateChart) SetPoints(values []float64) {
+	points := make([]float64, 0, len(values))
+	for _, v := range values {
+		if v < 0 || math.IsInf(v, 0) {
+			v = 0
+		}
+		points = append(points, v)
+	}
+	if len(points) == 0 {
+		c.Reset()
+		return
+	}
+	c.mu.Lock()
+	c.points = points
+	axisMin, axisMax, axisStep := c.axisRangeLocked()
+	c.axisMin = axisMin
+	c.axisMax = axisMax
+	c.axisStep = axisStep
+	c.mu.Unlock()
+	c.setScale(axisMin, axisMax, axisStep)
+	c.raster.Refresh()
+}
+
 func (c *hashrateChart) Average() (float64, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if len(c.points) == 0 {
-		return 0, false
-	}
 	var sum float64
+	n := 0
 	for _, v := range c.points {
-		sum += v
+		if !math.IsNaN(v) {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
 	}
-	return sum / float64(len(c.points)), true
+	return sum / float64(n), true
 }
 
 func (c *hashrateChart) Reset() {
@@ -214,18 +253,21 @@ func (c *hashrateChart) Reset() {
 }
 
 func (c *hashrateChart) axisRangeLocked() (axisMin, axisMax, axisStep float64) {
-	if len(c.points) == 0 {
-		return 0, 0, 0
-	}
-	dataMin, dataMax := c.points[0], c.points[0]
-	for _, v := range c.points[1:] {
-		if v < dataMin {
+	dataMin, dataMax := math.NaN(), math.NaN()
+	for _, v := range c.points {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(dataMin) || v < dataMin {
 			dataMin = v
 		}
-		if v > dataMax {
+		if math.IsNaN(dataMax) || v > dataMax {
 			dataMax = v
 		}
 	}
+	if math.IsNaN(dataMin) {
+		return 0, 0, 0
+	}
 	if dataMax-dataMin < 1e-6 {
 		dataMax = dataMin + 1
 	}
@@ -285,7 +327,7 @@ func (c *hashrateChart) setScale(axisMin, axisMax, axisStep float64) {
 	c.tickMid.Show()
 	c.tickBottom.Show()
 
-	c.unitText.Text = "H/s"
+	c.unitText.Text = c.unit
 	c.tickTop.Text = top
 	c.tickMid.Text = mid
 	c.tickBottom.Text = bottom
@@ -354,6 +396,10 @@ func (c *hashrateChart) render(w, h int) image.Image {
 		}
 		f := t - float64(i0)
 		v := data[i0]*(1-f) + data[i1]*f
+		if math.IsNaN(v) {
+			ys[x] = -1
+			continue
+		}
 		yy := (maxV - v) / (maxV - minV)
 		y := topPad + int(math.Round(yy*float64(chartH-1)))
 		if y < topPad {
@@ -366,17 +412,20 @@ func (c *hashrateChart) render(w, h int) image.Image {
 	}
 
 	for x := 0; x < chartW; x++ {
-		y := ys[x]
-		drawVLine(img, leftPad+x, y, topPad+chartH-1, fill)
+		if y := ys[x]; y >= 0 {
+			drawVLine(img, leftPad+x, y, topPad+chartH-1, fill)
+		}
 	}
 	for x := 1; x < chartW; x++ {
-		drawLine(img, leftPad+x-1, ys[x-1], leftPad+x, ys[x], line)
+		if ys[x-1] >= 0 && ys[x] >= 0 {
+			drawLine(img, leftPad+x-1, ys[x-1], leftPad+x, ys[x], line)
+		}
 	}
 
 	// Highlight last point.
-	lastX := leftPad + chartW - 1
-	lastY := ys[chartW-1]
-	drawCircle(img, lastX, lastY, 3, line)
+	if lastY := ys[chartW-1]; lastY >= 0 {
+		drawCircle(img, leftPad+chartW-1, lastY, 3, line)
+	}
 
 	return img
 }
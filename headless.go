@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// isHeadlessInvocation reports whether the command line asks for the
+// daemon mode (`olivetum-miner-gui run` or `--headless`).
+func isHeadlessInvocation(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if args[0] == "run" {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "--headless" || arg == "-headless" {
+			return true
+		}
+	}
+	return false
+}
+
+type headlessLogger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	files map[string]*os.File
+}
+
+func newHeadlessLogger(out io.Writer, logDir string) (*headlessLogger, error) {
+	l := &headlessLogger{out: out, files: make(map[string]*os.File)}
+	if logDir == "" {
+		return l, nil
+	}
+	dir, err := expandUserPath(logDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	for _, source := range []string{"miner", "node"} {
+		f, err := os.OpenFile(filepath.Join(dir, source+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.files[source] = f
+	}
+	return l, nil
+}
+
+func (l *headlessLogger) Line(source, line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%-5s | %s\n", source, line)
+	if f := l.files[source]; f != nil {
+		fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), line)
+	}
+}
+
+func (l *headlessLogger) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for source, f := range l.files {
+		_ = f.Close()
+		delete(l.files, source)
+	}
+}
+
+// runHeadless starts the node and miner from config.json without any UI and
+// supervises them until SIGINT/SIGTERM. It returns the process exit code.
+func runHeadless(args []string) int {
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Bool("headless", true, "run without the GUI")
+	logDir := fs.String("log-dir", "", "also append miner.log and node.log to this directory")
+	noNode := fs.Bool("no-node", false, "do not start the embedded node even if it is enabled in config")
+	noMiner := fs.Bool("no-miner", false, "do not start xmrig (node only)")
+	profile := fs.String("profile", "", "run with this profile instead of the active one")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	logger, err := newHeadlessLogger(os.Stdout, *logDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log directory: %v\n", err)
+		return 1
+	}
+	defer logger.Close()
+	logf := func(format string, a ...any) {
+		logger.Line("run", fmt.Sprintf(format, a...))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		logf("Config: %v", err)
+		logf("Fix the file or restore a backup (%s.1 is the newest)", configFileName)
+		return 1
+	}
+	if path, err := configPath(); err == nil {
+		logf("Using config %s", path)
+	}
+	if *profile != "" {
+		if err := switchProfile(cfg, *profile); err != nil {
+			logf("Profile: %v", err)
+			return 2
+		}
+	}
+	logf("Using profile %q", cfg.ActiveProfile)
+
+	runMiner := !*noMiner
+	runNode := cfg.NodeEnabled && !*noNode
+	if !runMiner && !runNode {
+		logf("Nothing to run: node is disabled in config and --no-miner was given")
+		return 2
+	}
+
+	xmrigPath, xmrigErr := findXMRig()
+	if runMiner && xmrigErr != nil {
+		logf("xmrig not found. Place it next to this binary, in PATH or set OLIVETUM_XMRIG_PATH: %v", xmrigErr)
+		return 1
+	}
+
+	var shuttingDown atomic.Bool
+	fatal := make(chan string, 1)
+	reportFatal := func(msg string) {
+		select {
+		case fatal <- msg:
+		default:
+		}
+	}
+
+	ctrl := newController(cfg, xmrigPath, xmrigErr)
+	var history *historyStore
+	if cfg.HistoryEnabled {
+		if history, err = openHistoryStore(ctrl.Snapshot); err != nil {
+			logf("History disabled: %v", err)
+		}
+		defer history.Close()
+	}
+
+	ledger, err := openBlockLedger(ctrl.Snapshot)
+	if err != nil {
+		logf("Block ledger disabled: %v", err)
+	}
+
+	ledger.Start(ctrl, nil)
+	defer ledger.Close()
+	wallet, err := startWalletTracker(ctrl, nil)
+	if err != nil {
+		logf("Wallet history: %v", err)
+	}
+	defer wallet.Close()
+	events, unsubscribe := ctrl.Subscribe(4096)
+	defer unsubscribe()
+	go func() {
+		for ev := range events {
+			switch ev.Kind {
+			case eventStats:
+				history.Record(ev.Stats)
+			case eventFoundBlock:
+				if err := ledger.Record(ev); err != nil {
+					logf("Block ledger: %v", err)
+				}
+			case eventMinerLog:
+				logger.Line("miner", ev.Line)
+			case eventNodeLog:
+				logger.Line("node", ev.Line)
+			case eventNodeIssue:
+				logf("Possible local chain database issue detected; consider a resync (%s)", ev.Line)
+			case eventMinerState:
+				if ev.MinerState == minerStateStopped {
+					history.MarkStopped()
+				}
+				if ev.MinerState == minerStateStopped && !shuttingDown.Load() && !ctrl.watchdogRestarting.Load() && !ctrl.minerStopRequested.Load() {
+					reportFatal("miner exited")
+				}
+			case eventNodeState:
+				if ev.NodeState == nodeStateOff && !shuttingDown.Load() && !ctrl.nodeStopRequested.Load() {
+					reportFatal("node exited")
+				}
+			}
+		}
+	}()
+
+	if cfg.MetricsEnabled {
+		metrics, err := startMetricsServer(ctrl, metricsListenAddress(cfg))
+		if err != nil {
+			logf("Metrics exporter failed to start: %v", err)
+			return 1
+		}
+		defer metrics.Close()
+		logf("Serving metrics on http://%s/metrics", metrics.Addr())
+	}
+
+	if cfg.APIEnabled {
+		api, err := startAPIServer(ctrl, cfg, nil)
+		if err != nil {
+			logf("Control API failed to start: %v", err)
+			return 1
+		}
+		defer api.Close()
+		logf("Control API listening on http://%s/api/v1/", api.Addr())
+	}
+
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case sig := <-sigCh:
+			logf("Received %s, shutting down", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	shutdown := func() {
+		shuttingDown.Store(true)
+		cancel()
+		ctrl.StopMiner(minerStopOriginUser)
+		ctrl.StopNode()
+		if !ctrl.waitForMinerExit(context.Background(), 15*time.Second) {
+			logf("Miner did not exit in time")
+		}
+		if !ctrl.waitForNodeExit(context.Background(), 65*time.Second) {
+			logf("Node did not exit in time")
+		}
+	}
+
+	if runNode {
+		requireMiningService := runMiner && cfg.Mode == modeRPCLocal
+		settings, err := nodeSettingsFromConfig(cfg, requireMiningService)
+		if err != nil {
+			logf("Node config: %v", err)
+			return 1
+		}
+		if err := ctrl.StartNode(settings, requireMiningService); err != nil {
+			logf("Node start failed: %v", err)
+			shutdown()
+			return 1
+		}
+		if requireMiningService {
+			logf("Waiting for node RPC on port %d", settings.RPCPort)
+			if !waitForTCP(ctx, fmt.Sprintf("127.0.0.1:%d", settings.RPCPort), 2*time.Minute) {
+				if ctx.Err() != nil {
+					shutdown()
+					return 0
+				}
+				logf("Node RPC did not come up in time")
+				shutdown()
+				return 1
+			}
+		}
+	}
+
+	if runMiner {
+		governor := startIdleGovernor(ctrl, systemIdleDetector{}, newProcLoadSampler())
+		defer governor.Close()
+		thermal := startThermalGovernor(ctrl, newSensorReader(cfg.SysfsRoot))
+		defer thermal.Close()
+	}
+	if runMiner && cfg.ScheduleEnabled && len(cfg.Schedule) > 0 {
+		scheduler := startScheduler(ctrl)
+		defer scheduler.Close()
+		logf("Mining follows the schedule in config.json")
+	} else if runMiner {
+		if err := ctrl.StartMiner(minerStartOriginUser); err != nil {
+			logf("Miner start failed: %v", err)
+			shutdown()
+			return 1
+		}
+	}
+
+	code := 0
+	select {
+	case <-ctx.Done():
+	case msg := <-fatal:
+		logf("Stopping: %s", msg)
+		code = 1
+	}
+	shutdown()
+	logf("Stopped")
+	return code
+}
+
+func waitForTCP(ctx context.Context, addr string, timeout time.Duration) bool {
+	return waitUntil(ctx, timeout, func() bool {
+		conn, err := net.DialTimeout("tcp", addr, 750*time.Millisecond)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	})
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	historyDirName      = "history"
+	historySegmentExt   = ".jsonl"
+	historySegmentDay   = "20060102"
+	historySegmentMonth = "200601"
+	historySegmentYear  = "2006"
+
+	historyTierRaw    = "raw"
+	historyTierMinute = "1m"
+	historyTierHour   = "1h"
+	historyTierDay    = "1d"
+
+	defaultHistoryRawRetentionHours   = 24
+	defaultHistoryMinuteRetentionDays = 7
+	defaultHistoryHourRetentionDays   = 90
+	defaultHistoryDayRetentionDays    = 730
+
+	historyRecentWindow      = time.Hour
+	historyPruneInterval     = time.Hour
+	historyRawSpanLimit      = time.Hour
+	historyMinuteSpanLimit   = 48 * time.Hour
+	historyHourSpanLimit     = 60 * 24 * time.Hour
+	historyMaxGapFillBuckets = 100000
+)
+
+// historyRecord is one line in a segment file. Raw samples have N == 0 and
+// Avg holds the sampled hashrate; rollups hold the mean, extremes and the
+// number of samples in the bucket starting at T. Share counts are deltas.
+type historyRecord struct {
+	T   int64   `json:"t"`
+	Avg float64 `json:"h"`
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	N   int     `json:"n,omitempty"`
+	Acc int64   `json:"a,omitempty"`
+	Rej int64   `json:"r,omitempty"`
+	Inv int64   `json:"i,omitempty"`
+}
+
+func (r historyRecord) samples() int {
+	if r.N <= 0 {
+		return 1
+	}
+	return r.N
+}
+
+// merge folds o into r. Both records must describe the same bucket; this
+// happens when a bucket was flushed on shutdown and continued after restart.
+func (r *historyRecord) merge(o historyRecord) {
+	n1, n2 := r.samples(), o.samples()
+	r.Avg = (r.Avg*float64(n1) + o.Avg*float64(n2)) / float64(n1+n2)
+	r.Min = math.Min(r.Min, o.Min)
+	r.Max = math.Max(r.Max, o.Max)
+	r.N = n1 + n2
+	r.Acc += o.Acc
+	r.Rej += o.Rej
+	r.Inv += o.Inv
+}
+
+type historyPoint struct {
+	Time     time.Time
+	Hashrate float64
+	Accepted int64
+	Rejected int64
+	Invalid  int64
+}
+
+type historyTier struct {
+	name    string
+	bucket  time.Duration
+	segment string
+}
+
+var historyTiers = []historyTier{
+	{name: historyTierRaw, segment: historySegmentDay},
+	{name: historyTierMinute, bucket: time.Minute, segment: historySegmentDay},
+	{name: historyTierHour, bucket: time.Hour, segment: historySegmentMonth},
+	{name: historyTierDay, bucket: 24 * time.Hour, segment: historySegmentYear},
+}
+
+func (t historyTier) segmentFile(dir string, at time.Time) string {
+	return filepath.Join(dir, t.name, at.UTC().Format(t.segment)+historySegmentExt)
+}
+
+func (t historyTier) segmentEnd(start time.Time) time.Time {
+	switch t.segment {
+	case historySegmentYear:
+		return start.AddDate(1, 0, 0)
+	case historySegmentMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func (t historyTier) segmentStep() (years, months, days int) {
+	switch t.segment {
+	case historySegmentYear:
+		return 1, 0, 0
+	case historySegmentMonth:
+		return 0, 1, 0
+	default:
+		return 0, 0, 1
+	}
+}
+
+// historyStore persists miner samples as append-only JSON-lines segments
+// under the config directory, one directory per tier. Rollup buckets are
+// aggregated in memory and appended once they close.
+type historyStore struct {
+	dir string
+	// config returns the current settings; it is read for the retention
+	// periods on every prune.
+	config func() *Config
+
+	mu        sync.Mutex
+	open      map[string]*historyRecord
+	recent    []historyRecord
+	files     map[string]*os.File
+	lastPrune time.Time
+
+	lastAccepted int64
+	lastRejected int64
+	lastInvalid  int64
+}
+
+func historyDir() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), historyDirName), nil
+}
+
+func openHistoryStore(config func() *Config) (*historyStore, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return nil, err
+	}
+	for _, tier := range historyTiers {
+		if err := os.MkdirAll(filepath.Join(dir, tier.name), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	h := &historyStore{
+		dir:    dir,
+		config: config,
+		open:   make(map[string]*historyRecord),
+		files:  make(map[string]*os.File),
+	}
+	now := time.Now()
+	raw := historyTiers[0]
+	records, err := h.readTier(raw, now.Add(-historyRecentWindow), now)
+	if err != nil {
+		return nil, err
+	}
+	h.recent = records
+	h.pruneLocked(now)
+	return h, nil
+}
+
+func historyRetention(cfg *Config, tier historyTier) time.Duration {
+	switch tier.name {
+	case historyTierRaw:
+		hours := cfg.HistoryRawRetentionHours
+		if hours <= 0 {
+			hours = defaultHistoryRawRetentionHours
+		}
+		return time.Duration(hours) * time.Hour
+	case historyTierMinute:
+		days := cfg.HistoryMinuteRetentionDays
+		if days <= 0 {
+			days = defaultHistoryMinuteRetentionDays
+		}
+		return time.Duration(days) * 24 * time.Hour
+	case historyTierHour:
+		days := cfg.HistoryHourRetentionDays
+		if days <= 0 {
+			days = defaultHistoryHourRetentionDays
+		}
+		return time.Duration(days) * 24 * time.Hour
+	default:
+		days := cfg.HistoryDayRetentionDays
+		if days <= 0 {
+			days = defaultHistoryDayRetentionDays
+		}
+		return time.Duration(days) * 24 * time.Hour
+	}
+}
+
+// Record stores one stats sample taken now.
+func (h *historyStore) Record(st minerStats) {
+	if h == nil {
+		return
+	}
+	h.record(time.Now(), st.Hashrate, st.Accepted, st.Rejected, st.Invalid)
+}
+
+func (h *historyStore) record(now time.Time, hashrate float64, accepted, rejected, invalid int64) {
+	if hashrate < 0 || math.IsNaN(hashrate) || math.IsInf(hashrate, 0) {
+		hashrate = 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dAcc := accepted - h.lastAccepted
+	dRej := rejected - h.lastRejected
+	dInv := invalid - h.lastInvalid
+	if dAcc < 0 || dRej < 0 || dInv < 0 {
+		// Counters went backwards: xmrig was restarted (e.g. by the watchdog).
+		dAcc, dRej, dInv = accepted, rejected, invalid
+	}
+	h.lastAccepted, h.lastRejected, h.lastInvalid = accepted, rejected, invalid
+
+	sample := historyRecord{T: now.Unix(), Avg: hashrate, Acc: dAcc, Rej: dRej, Inv: dInv}
+	h.recent = append(h.recent, sample)
+	cutoff := now.Add(-historyRecentWindow).Unix()
+	drop := 0
+	for drop < len(h.recent) && h.recent[drop].T < cutoff {
+		drop++
+	}
+	if drop > 0 {
+		h.recent = append([]historyRecord(nil), h.recent[drop:]...)
+	}
+
+	for _, tier := range historyTiers {
+		if tier.bucket == 0 {
+			h.appendLocked(tier, now, sample)
+			continue
+		}
+		start := now.Truncate(tier.bucket).Unix()
+		cur := h.open[tier.name]
+		if cur != nil && cur.T != start {
+			h.appendLocked(tier, time.Unix(cur.T, 0), *cur)
+			cur = nil
+		}
+		if cur == nil {
+			h.open[tier.name] = &historyRecord{
+				T: start, Avg: hashrate, Min: hashrate, Max: hashrate, N: 1,
+				Acc: dAcc, Rej: dRej, Inv: dInv,
+			}
+			continue
+		}
+		cur.merge(historyRecord{T: start, Avg: hashrate, Min: hashrate, Max: hashrate, N: 1, Acc: dAcc, Rej: dRej, Inv: dInv})
+	}
+
+	if now.Sub(h.lastPrune) >= historyPruneInterval {
+		h.pruneLocked(now)
+	}
+}
+
+// MarkStopped resets share tracking; the next miner session counts from zero.
+func (h *historyStore) MarkStopped() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.lastAccepted, h.lastRejected, h.lastInvalid = 0, 0, 0
+	h.mu.Unlock()
+}
+
+func (h *historyStore) appendLocked(tier historyTier, at time.Time, rec historyRecord) {
+	path := tier.segmentFile(h.dir, at)
+	f := h.files[path]
+	if f == nil {
+		// Only the newest segment of a tier is written to; close the others.
+		prefix := filepath.Join(h.dir, tier.name) + string(os.PathSeparator)
+		for p, old := range h.files {
+			if strings.HasPrefix(p, prefix) {
+				_ = old.Close()
+				delete(h.files, p)
+			}
+		}
+		var err error
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return
+		}
+		h.files[path] = f
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	_, _ = f.Write(b)
+}
+
+func (h *historyStore) pruneLocked(now time.Time) {
+	h.lastPrune = now
+	cfg := h.config()
+	for _, tier := range historyTiers {
+		cutoff := now.Add(-historyRetention(cfg, tier))
+		entries, err := os.ReadDir(filepath.Join(h.dir, tier.name))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, historySegmentExt) {
+				continue
+			}
+			start, err := time.ParseInLocation(tier.segment, strings.TrimSuffix(name, historySegmentExt), time.UTC)
+			if err != nil {
+				continue
+			}
+			if tier.segmentEnd(start).Before(cutoff) {
+				path := filepath.Join(h.dir, tier.name, name)
+				if f := h.files[path]; f != nil {
+					_ = f.Close()
+					delete(h.files, path)
+				}
+				_ = os.Remove(path)
+			}
+		}
+	}
+}
+
+// readTier loads the records of one tier within [from, to], merging
+// duplicate buckets.
+func (h *historyStore) readTier(tier historyTier, from, to time.Time) ([]historyRecord, error) {
+	var out []historyRecord
+	years, months, days := tier.segmentStep()
+	first, err := time.ParseInLocation(tier.segment, from.UTC().Format(tier.segment), time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	for seg := first; !seg.After(to); seg = seg.AddDate(years, months, days) {
+		f, err := os.Open(tier.segmentFile(h.dir, seg))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var rec historyRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				continue // torn write at crash time
+			}
+			if rec.T < from.Unix() || rec.T > to.Unix() {
+				continue
+			}
+			out = append(out, rec)
+		}
+		_ = f.Close()
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].T < out[j].T })
+	if tier.bucket == 0 {
+		return out, nil
+	}
+	merged := out[:0]
+	for _, rec := range out {
+		if n := len(merged); n > 0 && merged[n-1].T == rec.T {
+			merged[n-1].merge(rec)
+			continue
+		}
+		merged = append(merged, rec)
+	}
+	return merged, nil
+}
+
+// Query returns the history for the last span, using the finest tier that
+// keeps the number of points reasonable. Rollup tiers are gap-filled with
+// zero so stopped periods show up as such.
+func (h *historyStore) Query(span time.Duration) ([]historyPoint, error) {
+	if h == nil {
+		return nil, errors.New("history is disabled")
+	}
+	return h.query(time.Now(), span)
+}
+
+func (h *historyStore) query(now time.Time, span time.Duration) ([]historyPoint, error) {
+	from := now.Add(-span)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if span <= historyRawSpanLimit {
+		points := make([]historyPoint, 0, len(h.recent))
+		for _, rec := range h.recent {
+			if rec.T < from.Unix() {
+				continue
+			}
+			points = append(points, recordPoint(rec))
+		}
+		return points, nil
+	}
+
+	tier := historyTiers[1]
+	switch {
+	case span > historyHourSpanLimit:
+		tier = historyTiers[3]
+	case span > historyMinuteSpanLimit:
+		tier = historyTiers[2]
+	}
+	records, err := h.readTier(tier, from.Truncate(tier.bucket), now)
+	if err != nil {
+		return nil, err
+	}
+	if cur := h.open[tier.name]; cur != nil {
+		if n := len(records); n > 0 && records[n-1].T == cur.T {
+			records[n-1].merge(*cur)
+		} else {
+			records = append(records, *cur)
+		}
+	}
+
+	start := from.Truncate(tier.bucket).Unix()
+	end := now.Truncate(tier.bucket).Unix()
+	step := int64(tier.bucket / time.Second)
+	if (end-start)/step > historyMaxGapFillBuckets {
+		return nil, fmt.Errorf("history span too large: %s", span)
+	}
+	points := make([]historyPoint, 0, (end-start)/step+1)
+	i := 0
+	for t := start; t <= end; t += step {
+		for i < len(records) && records[i].T < t {
+			i++
+		}
+		if i < len(records) && records[i].T == t {
+			points = append(points, recordPoint(records[i]))
+			continue
+		}
+		points = append(points, historyPoint{Time: time.Unix(t, 0)})
+	}
+	return points, nil
+}
+
+func recordPoint(rec historyRecord) historyPoint {
+	return historyPoint{
+		Time:     time.Unix(rec.T, 0),
+		Hashrate: rec.Avg,
+		Accepted: rec.Acc,
+		Rejected: rec.Rej,
+		Invalid:  rec.Inv,
+	}
+}
+
+// Close flushes the open rollup buckets and closes all segment files.
+func (h *historyStore) Close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, tier := range historyTiers {
+		if cur := h.open[tier.name]; cur != nil {
+			h.appendLocked(tier, time.Unix(cur.T, 0), *cur)
+			delete(h.open, tier.name)
+		}
+	}
+	for path, f := range h.files {
+		_ = f.Close()
+		delete(h.files, path)
+	}
+}
+
+func summarizeHistory(points []historyPoint) (avg float64, accepted, rejected, invalid int64, ok bool) {
+	if len(points) == 0 {
+		return 0, 0, 0, 0, false
+	}
+	var sum float64
+	for _, p := range points {
+		sum += p.Hashrate
+		accepted += p.Accepted
+		rejected += p.Rejected
+		invalid += p.Invalid
+	}
+	return sum / float64(len(points)), accepted, rejected, invalid, true
+}
+
+// historyRangeLabels are the Dashboard chart ranges; the first one is the
+// live view.
+var historyRangeLabels = []string{"10 min", "1 h", "24 h", "7 d", "30 d"}
+
+func historyRangeSpan(label string) time.Duration {
+	switch label {
+	case "1 h":
+		return time.Hour
+	case "24 h":
+		return 24 * time.Hour
+	case "7 d":
+		return 7 * 24 * time.Hour
+	case "30 d":
+		return 30 * 24 * time.Hour
+	default:
+		return 10 * time.Minute
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestHistory opens a history store under a temporary config directory.
+func openTestHistory(t *testing.T, cfg *Config) *historyStore {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	h, err := openHistoryStore(func() *Config { return cfg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+	return h
+}
+
+func readTestTier(t *testing.T, h *historyStore, name string, from, to time.Time) []historyRecord {
+	t.Helper()
+	for _, tier := range historyTiers {
+		if tier.name == name {
+			records, err := h.readTier(tier, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return records
+		}
+	}
+	t.Fatalf("no tier %q", name)
+	return nil
+}
+
+func TestHistoryRollupBucketBoundary(t *testing.T) {
+	h := openTestHistory(t, &Config{})
+	base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	h.record(base.Add(50*time.Second), 100, 1, 0, 0)
+	h.record(base.Add(55*time.Second), 200, 3, 1, 0)
+	// Nothing is written until the minute bucket closes.
+	if got := readTestTier(t, h, historyTierMinute, base, base.Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("open bucket written early: %+v", got)
+	}
+	// xmrig restarted: the counters start again from zero.
+	h.record(base.Add(65*time.Second), 300, 2, 0, 0)
+
+	want := historyRecord{T: base.Unix(), Avg: 150, Min: 100, Max: 200, N: 2, Acc: 3, Rej: 1}
+	got := readTestTier(t, h, historyTierMinute, base, base.Add(time.Hour))
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("minute tier = %+v, want [%+v]", got, want)
+	}
+	if raw := readTestTier(t, h, historyTierRaw, base, base.Add(time.Hour)); len(raw) != 3 || raw[2].Acc != 2 {
+		t.Errorf("raw tier = %+v, want 3 samples ending with 2 accepted", raw)
+	}
+	open := h.open[historyTierMinute]
+	if open == nil || open.T != base.Add(time.Minute).Unix() || open.Avg != 300 || open.Acc != 2 {
+		t.Errorf("open minute bucket = %+v", open)
+	}
+	if hour := h.open[historyTierHour]; hour == nil || hour.N != 3 || hour.Avg != 200 || hour.Acc != 5 {
+		t.Errorf("open hour bucket = %+v", hour)
+	}
+}
+
+// A bucket flushed on shutdown and continued after a restart is read back
+// as one bucket.
+func TestHistoryReopenMerge(t *testing.T) {
+	cfg := &Config{}
+	h := openTestHistory(t, cfg)
+	base := time.Now().Truncate(time.Minute).Add(-10 * time.Minute)
+	h.record(base.Add(10*time.Second), 100, 1, 0, 0)
+	h.record(base.Add(20*time.Second), 200, 2, 0, 0)
+	h.Close()
+
+	h, err := openHistoryStore(func() *Config { return cfg })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if len(h.recent) != 2 {
+		t.Errorf("reopened store has %d recent samples, want 2", len(h.recent))
+	}
+	h.record(base.Add(30*time.Second), 300, 1, 0, 0)
+	h.record(base.Add(40*time.Second), 400, 1, 0, 0)
+	h.Close()
+
+	got := readTestTier(t, h, historyTierMinute, base, base.Add(time.Minute))
+	want := historyRecord{T: base.Unix(), Avg: 250, Min: 100, Max: 400, N: 4, Acc: 3}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("minute tier = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestHistoryQueryTiers(t *testing.T) {
+	h := openTestHistory(t, &Config{})
+	now := time.Date(2025, 3, 10, 12, 0, 30, 0, time.UTC)
+	early := now.Add(-5 * time.Hour)
+	h.record(early, 100, 0, 0, 0)
+	h.record(now.Add(-10*time.Second), 300, 4, 0, 0)
+
+	tests := []struct {
+		span   time.Duration
+		bucket time.Duration
+		points int
+		// values maps a bucket start to its hashrate; the other buckets are
+		// gap-filled with zero.
+		values map[time.Time]float64
+	}{
+		{10 * time.Minute, 0, 1, nil},
+		{24 * time.Hour, time.Minute, 24*60 + 1, map[time.Time]float64{
+			early.Truncate(time.Minute): 100,
+			now.Truncate(time.Minute):   300,
+		}},
+		{7 * 24 * time.Hour, time.Hour, 7*24 + 1, map[time.Time]float64{
+			early.Truncate(time.Hour): 100,
+			now.Truncate(time.Hour):   300,
+		}},
+		{90 * 24 * time.Hour, 24 * time.Hour, 91, map[time.Time]float64{
+			now.Truncate(24 * time.Hour): 200,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.span.String(), func(t *testing.T) {
+			points, err := h.query(now, tt.span)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(points) != tt.points {
+				t.Fatalf("%d points, want %d", len(points), tt.points)
+			}
+			if tt.bucket == 0 {
+				if points[0].Hashrate != 300 || points[0].Accepted != 4 {
+					t.Errorf("raw point = %+v", points[0])
+				}
+				return
+			}
+			for i, p := range points {
+				if i > 0 && p.Time.Sub(points[i-1].Time) != tt.bucket {
+					t.Fatalf("point %d at %s does not follow %s", i, p.Time, points[i-1].Time)
+				}
+				if want := tt.values[p.Time.UTC()]; p.Hashrate != want {
+					t.Errorf("point at %s = %v, want %v", p.Time.UTC(), p.Hashrate, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryPrune(t *testing.T) {
+	cfg := &Config{}
+	cfg.HistoryRawRetentionHours = 24
+	cfg.HistoryMinuteRetentionDays = 2
+	h := openTestHistory(t, cfg)
+	// The store pruned at the real time on open; these samples are older.
+	h.lastPrune = time.Time{}
+	day := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	h.record(day, 100, 0, 0, 0)
+	h.record(day.Add(time.Hour), 100, 0, 0, 0)
+	h.record(day.Add(2*24*time.Hour), 100, 0, 0, 0)
+
+	exists := func(tier, name string) bool {
+		_, err := os.Stat(filepath.Join(h.dir, tier, name+historySegmentExt))
+		return !errors.Is(err, os.ErrNotExist)
+	}
+	tests := []struct {
+		tier, name string
+		want       bool
+	}{
+		{historyTierRaw, "20250101", false},
+		{historyTierRaw, "20250103", true},
+		{historyTierMinute, "20250101", true},
+		{historyTierHour, "202501", true},
+		{historyTierDay, "2025", true},
+	}
+	for _, tt := range tests {
+		if got := exists(tt.tier, tt.name); got != tt.want {
+			t.Errorf("%s/%s exists = %v, want %v", tt.tier, tt.name, got, tt.want)
+		}
+	}
+
+	// A shorter retention applies from the next prune.
+	cfg.HistoryMinuteRetentionDays = 1
+	h.record(day.Add(3*24*time.Hour), 100, 0, 0, 0)
+	if exists(historyTierMinute, "20250101") {
+		t.Error("minute segment kept past the new retention")
+	}
+}
+
+func TestHistoryRetentionDefaults(t *testing.T) {
+	var cfg Config
+	for _, tt := range []struct {
+		tier historyTier
+		want time.Duration
+	}{
+		{historyTiers[0], defaultHistoryRawRetentionHours * time.Hour},
+		{historyTiers[1], defaultHistoryMinuteRetentionDays * 24 * time.Hour},
+		{historyTiers[2], defaultHistoryHourRetentionDays * 24 * time.Hour},
+		{historyTiers[3], defaultHistoryDayRetentionDays * 24 * time.Hour},
+	} {
+		if got := historyRetention(&cfg, tt.tier); got != tt.want {
+			t.Errorf("%s retention = %s, want %s", tt.tier.name, got, tt.want)
+		}
+	}
+}
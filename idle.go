@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultIdleAfterMin = 5
+
+	idleCheckInterval = 5 * time.Second
+	// The miner is paused after loadOverSamples consecutive samples over the
+	// load limit and resumed once the load has stayed below it for
+	// loadResumeAfter.
+	loadOverSamples = 2
+	loadResumeAfter = 30 * time.Second
+)
+
+// idleDetector reports how long the user has not touched the keyboard or
+// mouse. The governor takes it as an interface so tests can substitute a
+// fake.
+type idleDetector interface {
+	IdleTime() (time.Duration, error)
+}
+
+// loadSampler reports the CPU share, in percent of all logical CPUs, used by
+// processes other than pid since the previous call. pid 0 counts every
+// process.
+type loadSampler interface {
+	OtherLoad(pid int) (float64, error)
+}
+
+// systemIdleDetector asks the desktop session for the idle time; see
+// idle_linux.go.
+type systemIdleDetector struct{}
+
+// procLoadSampler derives the load of other processes from the system and
+// miner CPU times in /proc.
+type procLoadSampler struct {
+	root string
+
+	primed    bool
+	prevPID   int
+	prevTotal uint64
+	prevBusy  uint64
+	prevProc  uint64
+}
+
+func newProcLoadSampler() *procLoadSampler {
+	return &procLoadSampler{root: "/proc"}
+}
+
+// idleGovernor limits the miner while the user is active and pauses it while
+// other processes keep the CPU busy. It places thread caps and pause holds
+// on the Controller, which combines them with the schedule's.
+type idleGovernor struct {
+	ctrl     *Controller
+	detector idleDetector
+	load     loadSampler
+	cancel   context.CancelFunc
+
+	userActive   bool
+	detectFailed bool
+	loadFailed   bool
+	loadPaused   bool
+	overCount    int
+	underSince   time.Time
+}
+
+func startIdleGovernor(ctrl *Controller, detector idleDetector, load loadSampler) *idleGovernor {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &idleGovernor{ctrl: ctrl, detector: detector, load: load, cancel: cancel}
+	go g.run(ctx)
+	return g
+}
+
+func (g *idleGovernor) Close() {
+	if g == nil {
+		return
+	}
+	g.cancel()
+}
+
+func (g *idleGovernor) run(ctx context.Context) {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		g.check(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check applies the current idle and load settings once.
+func (g *idleGovernor) check(ctx context.Context, now time.Time) {
+	cfg := g.ctrl.Snapshot()
+	g.checkUser(ctx, cfg)
+	g.checkLoad(cfg, now)
+}
+
+func (g *idleGovernor) checkUser(ctx context.Context, cfg *Config) {
+	active := false
+	if cfg.IdleEnabled {
+		idle, err := g.detector.IdleTime()
+		if err != nil {
+			if !g.detectFailed {
+				g.ctrl.appendMinerLog(fmt.Sprintf("[idle] Idle detection unavailable: %v. Mining is not limited.\n", err))
+			}
+			g.detectFailed = true
+		} else {
+			g.detectFailed = false
+			active = idle < time.Duration(cfg.IdleAfterMin)*time.Minute
+		}
+	}
+
+	threads, pause := 0, false
+	if active {
+		if cfg.IdleActiveAction == throttleActionPause {
+			pause = true
+		} else {
+			threads = cfg.IdleActiveThreads
+		}
+	}
+	if active != g.userActive {
+		switch {
+		case active && pause:
+			g.ctrl.appendMinerLog("[idle] User active. Pausing miner.\n")
+		case active:
+			g.ctrl.appendMinerLog(fmt.Sprintf("[idle] User active. Limiting miner to %s.\n", threadsText(threads)))
+		default:
+			g.ctrl.appendMinerLog("[idle] User idle. Restoring full configuration.\n")
+		}
+		g.userActive = active
+	}
+
+	g.ctrl.setPauseHold(limitSourceIdle, pause)
+	g.ctrl.setThreadLimit(limitSourceIdle, threads)
+	g.ctrl.restartIfLimitChanged(ctx, "[idle]")
+}
+
+func (g *idleGovernor) checkLoad(cfg *Config, now time.Time) {
+	if cfg.IdleLoadLimit <= 0 {
+		g.releaseLoad("")
+		return
+	}
+	load, err := g.load.OtherLoad(g.ctrl.minerPID())
+	if err != nil {
+		if !g.loadFailed {
+			g.ctrl.appendMinerLog(fmt.Sprintf("[idle] CPU load unavailable: %v\n", err))
+		}
+		g.loadFailed = true
+		g.releaseLoad("")
+		return
+	}
+	g.loadFailed = false
+
+	if load > float64(cfg.IdleLoadLimit) {
+		g.underSince = time.Time{}
+		g.overCount++
+		if !g.loadPaused && g.overCount >= loadOverSamples {
+			g.loadPaused = true
+			g.ctrl.appendMinerLog(fmt.Sprintf("[idle] Other processes use %.0f%% CPU (limit %d%%). Pausing miner.\n", load, cfg.IdleLoadLimit))
+			g.ctrl.setPauseHold(limitSourceLoad, true)
+		}
+		return
+	}
+	g.overCount = 0
+	if !g.loadPaused {
+		return
+	}
+	if g.underSince.IsZero() {
+		g.underSince = now
+	}
+	if now.Sub(g.underSince) >= loadResumeAfter {
+		g.releaseLoad(fmt.Sprintf("[idle] CPU load from other processes down to %.0f%%. Resuming miner.\n", load))
+	}
+}
+
+// releaseLoad lifts the load pause hold, logging msg when one was placed.
+func (g *idleGovernor) releaseLoad(msg string) {
+	g.overCount = 0
+	g.underSince = time.Time{}
+	if !g.loadPaused {
+		return
+	}
+	g.loadPaused = false
+	if msg != "" {
+		g.ctrl.appendMinerLog(msg)
+	}
+	g.ctrl.setPauseHold(limitSourceLoad, false)
+}
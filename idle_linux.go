@@ -0,0 +1,177 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const dbusCallTimeout = 2 * time.Second
+
+// IdleTime asks the session's idle monitor first (GNOME's Mutter monitor,
+// then the freedesktop screensaver used by KDE and other X11 desktops), and
+// falls back to logind's IdleHint on the system bus, which also works from a
+// service outside the desktop session.
+func (systemIdleDetector) IdleTime() (time.Duration, error) {
+	if conn, err := dbus.SessionBus(); err == nil {
+		if idle, err := mutterIdleTime(conn); err == nil {
+			return idle, nil
+		}
+		if idle, err := screensaverIdleTime(conn); err == nil {
+			return idle, nil
+		}
+	}
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return 0, fmt.Errorf("no D-Bus idle monitor: %w", err)
+	}
+	return logindIdleTime(conn)
+}
+
+func mutterIdleTime(conn *dbus.Conn) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbusCallTimeout)
+	defer cancel()
+	var ms uint64
+	obj := conn.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core")
+	if err := obj.CallWithContext(ctx, "org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&ms); err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func screensaverIdleTime(conn *dbus.Conn) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbusCallTimeout)
+	defer cancel()
+	var ms uint32
+	obj := conn.Object("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver")
+	if err := obj.CallWithContext(ctx, "org.freedesktop.ScreenSaver.GetSessionIdleTime", 0).Store(&ms); err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// logindIdleTime reads the IdleHint that logind aggregates over all
+// sessions. Desktops set it once their own idle timeout has passed, so the
+// result is zero until then.
+func logindIdleTime(conn *dbus.Conn) (time.Duration, error) {
+	obj := conn.Object("org.freedesktop.login1", "/org/freedesktop/login1")
+	get := func(name string) (dbus.Variant, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), dbusCallTimeout)
+		defer cancel()
+		var v dbus.Variant
+		err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0, "org.freedesktop.login1.Manager", name).Store(&v)
+		return v, err
+	}
+	hint, err := get("IdleHint")
+	if err != nil {
+		return 0, fmt.Errorf("logind: %w", err)
+	}
+	idle, ok := hint.Value().(bool)
+	if !ok {
+		return 0, errors.New("logind: unexpected IdleHint type")
+	}
+	if !idle {
+		return 0, nil
+	}
+	since, err := get("IdleSinceHint")
+	if err != nil {
+		return 0, fmt.Errorf("logind: %w", err)
+	}
+	usec, ok := since.Value().(uint64)
+	if !ok || usec == 0 {
+		return 0, errors.New("logind: unexpected IdleSinceHint")
+	}
+	return time.Since(time.UnixMicro(int64(usec))), nil
+}
+
+func (s *procLoadSampler) OtherLoad(pid int) (float64, error) {
+	total, busy, err := readProcStatCPU(filepath.Join(s.root, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	var proc uint64
+	if pid > 0 {
+		// The miner may have exited since; its time then simply drops out.
+		proc, _ = readProcCPUTime(filepath.Join(s.root, strconv.Itoa(pid), "stat"))
+	}
+	prevTotal, prevBusy, prevProc := s.prevTotal, s.prevBusy, s.prevProc
+	if pid != s.prevPID {
+		prevProc = 0
+	}
+	primed := s.primed
+	s.primed = true
+	s.prevPID = pid
+	s.prevTotal, s.prevBusy, s.prevProc = total, busy, proc
+	if !primed || total <= prevTotal {
+		return 0, nil
+	}
+
+	var procDelta uint64
+	if proc > prevProc {
+		procDelta = proc - prevProc
+	}
+	other := float64(busy-prevBusy) - float64(procDelta)
+	load := other / float64(total-prevTotal) * 100
+	return max(0, min(100, load)), nil
+}
+
+// readProcStatCPU returns the total and busy jiffies of all CPUs from the
+// aggregate "cpu" line of /proc/stat.
+func readProcStatCPU(path string) (total, busy uint64, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	line, _, _ := strings.Cut(string(b), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected %s format", path)
+	}
+	// user nice system idle iowait irq softirq steal; guest time is already
+	// part of user.
+	var idle uint64
+	for i, f := range fields[1:min(len(fields), 9)] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unexpected %s format", path)
+		}
+		total += v
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+	return total, total - idle, nil
+}
+
+// readProcCPUTime returns utime+stime of a process, in jiffies.
+func readProcCPUTime(path string) (uint64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	// The command name may contain spaces; fields resume after its ')'.
+	text := string(b)
+	end := strings.LastIndexByte(text, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("unexpected %s format", path)
+	}
+	fields := strings.Fields(text[end+1:])
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected %s format", path)
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("unexpected %s format", path)
+	}
+	return utime + stime, nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+func (systemIdleDetector) IdleTime() (time.Duration, error) {
+	return 0, errors.New("not supported on this platform")
+}
+
+func (s *procLoadSampler) OtherLoad(pid int) (float64, error) {
+	return 0, errors.New("not supported on this platform")
+}
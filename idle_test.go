@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fakeIdleDetector struct {
+	idle time.Duration
+	err  error
+}
+
+func (f *fakeIdleDetector) IdleTime() (time.Duration, error) { return f.idle, f.err }
+
+type fakeLoadSampler struct {
+	load float64
+	err  error
+}
+
+func (f *fakeLoadSampler) OtherLoad(int) (float64, error) { return f.load, f.err }
+
+func TestIdleGovernorUser(t *testing.T) {
+	type step struct {
+		idle        time.Duration
+		err         error
+		wantPause   bool
+		wantThreads int
+	}
+	tests := []struct {
+		name    string
+		action  string
+		enabled bool
+		steps   []step
+	}{
+		{
+			name:    "pause while active",
+			action:  throttleActionPause,
+			enabled: true,
+			steps: []step{
+				{idle: 10 * time.Second, wantPause: true},
+				{idle: 4*time.Minute + 59*time.Second, wantPause: true},
+				{idle: 5 * time.Minute},
+				{idle: time.Second, wantPause: true},
+			},
+		},
+		{
+			name:    "limit threads while active",
+			action:  throttleActionThreads,
+			enabled: true,
+			steps: []step{
+				{idle: time.Minute, wantThreads: 2},
+				{idle: 6 * time.Minute},
+			},
+		},
+		{
+			name:    "detection failure does not limit",
+			action:  throttleActionPause,
+			enabled: true,
+			steps: []step{
+				{idle: time.Second, wantPause: true},
+				{err: errors.New("no session"), wantPause: false},
+				{idle: time.Second, wantPause: true},
+			},
+		},
+		{
+			name:   "disabled",
+			action: throttleActionPause,
+			steps:  []step{{idle: time.Second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.IdleEnabled = tt.enabled
+			cfg.IdleAfterMin = 5
+			cfg.IdleActiveAction = tt.action
+			cfg.IdleActiveThreads = 2
+			detector := &fakeIdleDetector{}
+			c := newController(cfg, "", nil)
+			g := &idleGovernor{ctrl: c, detector: detector, load: &fakeLoadSampler{}}
+			for i, s := range tt.steps {
+				detector.idle, detector.err = s.idle, s.err
+				g.checkUser(context.Background(), cfg)
+				if got := c.pauseHeld(); got != s.wantPause {
+					t.Errorf("step %d: paused = %v, want %v", i, got, s.wantPause)
+				}
+				if got := c.threadLimit(); got != s.wantThreads {
+					t.Errorf("step %d: thread limit = %d, want %d", i, got, s.wantThreads)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleGovernorLoad(t *testing.T) {
+	type step struct {
+		after time.Duration // since the previous step
+		load  float64
+		err   error
+		limit int
+		want  bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "pause after consecutive samples over the limit",
+			steps: []step{
+				{load: 80, limit: 50},
+				{after: 5 * time.Second, load: 80, limit: 50, want: true},
+			},
+		},
+		{
+			name: "a single spike does not pause",
+			steps: []step{
+				{load: 80, limit: 50},
+				{after: 5 * time.Second, load: 10, limit: 50},
+				{after: 5 * time.Second, load: 80, limit: 50},
+			},
+		},
+		{
+			name: "resume once the load stayed low",
+			steps: []step{
+				{load: 80, limit: 50},
+				{after: 5 * time.Second, load: 80, limit: 50, want: true},
+				{after: 5 * time.Second, load: 40, limit: 50, want: true},
+				{after: 29 * time.Second, load: 40, limit: 50, want: true},
+				{after: time.Second, load: 40, limit: 50},
+			},
+		},
+		{
+			name: "load back over the limit restarts the wait",
+			steps: []step{
+				{load: 80, limit: 50},
+				{after: 5 * time.Second, load: 80, limit: 50, want: true},
+				{after: 5 * time.Second, load: 40, limit: 50, want: true},
+				{after: 20 * time.Second, load: 60, limit: 50, want: true},
+				{after: 5 * time.Second, load: 40, limit: 50, want: true},
+				{after: 20 * time.Second, load: 40, limit: 50, want: true},
+				{after: 10 * time.Second, load: 40, limit: 50},
+			},
+		},
+		{
+			name: "sampler failure releases the pause",
+			steps: []step{
+				{load: 80, limit: 50},
+				{after: 5 * time.Second, load: 80, limit: 50, want: true},
+				{after: 5 * time.Second, err: errors.New("no /proc"), limit: 50},
+			},
+		},
+		{
+			name: "turning the limit off releases the pause",
+			steps: []step{
+				{load: 80, limit: 50},
+				{after: 5 * time.Second, load: 80, limit: 50, want: true},
+				{after: 5 * time.Second, load: 80, limit: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			sampler := &fakeLoadSampler{}
+			c := newController(cfg, "", nil)
+			g := &idleGovernor{ctrl: c, detector: &fakeIdleDetector{}, load: sampler}
+			now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				sampler.load, sampler.err = s.load, s.err
+				cfg.IdleLoadLimit = s.limit
+				g.checkLoad(cfg, now)
+				var want []string
+				if s.want {
+					want = []string{limitSourceLoad}
+				}
+				if got := c.PauseReasons(); !slices.Equal(got, want) {
+					t.Errorf("step %d: pause reasons = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// The governor reads its settings while API requests replace them (run with
+// -race).
+func TestIdleGovernorDuringPatch(t *testing.T) {
+	s := testAPIServer(testAPIConfig(t))
+	g := &idleGovernor{ctrl: s.ctrl, detector: &fakeIdleDetector{idle: time.Second}, load: &fakeLoadSampler{load: 90}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		now := time.Now()
+		for ctx.Err() == nil {
+			now = now.Add(idleCheckInterval)
+			g.check(ctx, now)
+		}
+	}()
+	for i := range 20 {
+		body := fmt.Sprintf(`{"idleEnabled":%v,"idleActiveAction":%q,"idleLoadLimit":%d}`, i%2 == 0, throttleActionThreads, 50+i)
+		if rec := patchTestConfig(t, s, body); rec.Code != http.StatusOK {
+			t.Fatalf("PATCH: status = %d (%s)", rec.Code, rec.Body)
+		}
+	}
+	cancel()
+	<-done
+	if got := s.ctrl.Snapshot().IdleLoadLimit; got != 69 {
+		t.Errorf("idleLoadLimit = %d, want 69", got)
+	}
+}
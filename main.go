@@ -18,6 +18,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -59,46 +60,90 @@ const (
 )
 
 type Config struct {
-	Mode          string `json:"mode"`
-	StratumHost   string `json:"stratumHost"`
-	StratumPort   int    `json:"stratumPort"`
-	RPCURL        string `json:"rpcUrl"`
-	WalletAddress string `json:"walletAddress"`
-	WorkerName    string `json:"workerName"`
-
-	CPUThreads      int   `json:"cpuThreads"`
-	CPUAffinity     []int `json:"cpuAffinity"`
-	UseHugePages    bool  `json:"useHugePages"`
-	EnableMSR       bool  `json:"enableMsr"`
-	AutoGrantMSR    bool  `json:"autoGrantMsr"`
-	DonateLevel     int   `json:"donateLevel"`
-	DisplayInterval int   `json:"displayInterval"`
+	// SchemaVersion is the config layout version; older files are migrated
+	// on load (see configMigrations).
+	SchemaVersion int `json:"schemaVersion"`
+
+	// ProfileSettings are the settings of ActiveProfile.
+	ProfileSettings
+
+	// Profiles are named miner and node configurations; the embedded
+	// ProfileSettings are the ones of ActiveProfile.
+	ActiveProfile string          `json:"activeProfile"`
+	Profiles      []MiningProfile `json:"profiles"`
+
+	// AddressBook holds named addresses the wallet and mining address
+	// fields can pick from.
+	AddressBook []AddressBookEntry `json:"addressBook"`
+
+	DisplayInterval int `json:"displayInterval"`
 
 	Backend         string `json:"backend"`
 	SelectedDevices []int  `json:"selectedDevices"`
 	ReportHashrate  bool   `json:"reportHashrate"`
 	HWMon           bool   `json:"hwMon"`
+	SysfsRoot       string `json:"sysfsRoot,omitempty"`
 
-	NodeEnabled    bool   `json:"nodeEnabled"`
-	NodeMode       string `json:"nodeMode"`
-	NodeDataDir    string `json:"nodeDataDir"`
-	NodeRPCPort    int    `json:"nodeRpcPort"`
-	NodeP2PPort    int    `json:"nodeP2pPort"`
-	NodeBootnodes  string `json:"nodeBootnodes"`
-	NodeVerbosity  int    `json:"nodeVerbosity"`
-	NodeEtherbase  string `json:"nodeEtherbase"`
-	NodeCleanStart bool   `json:"nodeCleanStart"`
+	NodeCleanStart bool `json:"nodeCleanStart"`
 
 	WatchdogEnabled         bool `json:"watchdogEnabled"`
 	WatchdogNoJobTimeoutSec int  `json:"watchdogNoJobTimeoutSec"`
 	WatchdogRestartDelaySec int  `json:"watchdogRestartDelaySec"`
 	WatchdogRetryWindowMin  int  `json:"watchdogRetryWindowMin"`
+
+	// Schedule lists the weekly windows the miner runs in when
+	// ScheduleEnabled is set.
+	ScheduleEnabled bool             `json:"scheduleEnabled"`
+	Schedule        []ScheduleWindow `json:"schedule"`
+
+	// While the user is active (input within IdleAfterMin minutes) the
+	// miner is limited to IdleActiveThreads or paused, per IdleActiveAction.
+	// IdleLoadLimit pauses it while other processes use more than that
+	// percentage of the CPU (0 = off).
+	IdleEnabled       bool   `json:"idleEnabled"`
+	IdleAfterMin      int    `json:"idleAfterMin"`
+	IdleActiveAction  string `json:"idleActiveAction"`
+	IdleActiveThreads int    `json:"idleActiveThreads"`
+	IdleLoadLimit     int    `json:"idleLoadLimit"`
+
+	// Over ThermalLimitC (hottest hwmon sensor) the miner threads are
+	// reduced or the miner is paused, per ThermalAction, until the
+	// temperature has stayed at or below ThermalResumeC.
+	ThermalEnabled bool   `json:"thermalEnabled"`
+	ThermalLimitC  int    `json:"thermalLimitC"`
+	ThermalResumeC int    `json:"thermalResumeC"`
+	ThermalAction  string `json:"thermalAction"`
+
+	// BlockReward is the OLIVO paid per block, used by the Dashboard
+	// estimator for expected earnings (0 = not set).
+	BlockReward float64 `json:"blockReward"`
+	// WalletRPCURL is the node RPC the Dashboard wallet reads balances from
+	// (empty = the embedded node or the RPC endpoint of the RPC modes).
+	WalletRPCURL string `json:"walletRpcUrl"`
+
+	HistoryEnabled             bool `json:"historyEnabled"`
+	HistoryRawRetentionHours   int  `json:"historyRawRetentionHours"`
+	HistoryMinuteRetentionDays int  `json:"historyMinuteRetentionDays"`
+	HistoryHourRetentionDays   int  `json:"historyHourRetentionDays"`
+	HistoryDayRetentionDays    int  `json:"historyDayRetentionDays"`
+
+	MetricsEnabled     bool   `json:"metricsEnabled"`
+	MetricsBindAddress string `json:"metricsBindAddress"`
+	MetricsPort        int    `json:"metricsPort"`
+
+	APIEnabled     bool   `json:"apiEnabled"`
+	APIBindAddress string `json:"apiBindAddress"`
+	APIPort        int    `json:"apiPort"`
+	APIToken       string `json:"apiToken,omitempty"`
 }
 
 type Device struct {
-	Index int
-	PCI   string
-	Name  string
+	Index  int
+	PCI    string
+	Name   string
+	Core   int
+	Socket int
+	Node   int
 }
 
 type Stat struct {
@@ -117,9 +162,73 @@ type Stat struct {
 	Fans          []int
 	Pool          string
 	Difficulty    float64
+	Paused        bool
+	// CPUIndexed reports that the per-thread slices are indexed by logical
+	// CPU. Otherwise they are in xmrig thread order.
+	CPUIndexed bool
 }
 
 func main() {
+	args, configFile, importFile, err := extractConfigFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if configFile != "" {
+		path, err := expandUserPath(configFile)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--config: %v\n", err)
+			os.Exit(2)
+		}
+		configPathOverride = path
+	}
+	if importFile != "" {
+		// Importing on its own is a provisioning step; with run or
+		// --headless the imported config is started right away.
+		if err := importConfigBundleFile(importFile); err != nil {
+			fmt.Fprintf(os.Stderr, "--import: %v\n", err)
+			os.Exit(1)
+		}
+		if !isHeadlessInvocation(args) {
+			os.Exit(0)
+		}
+	}
+	if isHeadlessInvocation(args) {
+		os.Exit(runHeadless(args))
+	}
+	runGUI()
+}
+
+// extractConfigFlags takes --config PATH and --import BUNDLE (or their
+// =PATH forms) out of args, for both the GUI and headless mode.
+func extractConfigFlags(args []string) (rest []string, configFile, importFile string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "config" && name != "import") {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, "", "", fmt.Errorf("flag needs an argument: %s", arg)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "config" {
+			configFile = value
+		} else {
+			importFile = value
+		}
+	}
+	return rest, configFile, importFile, nil
+}
+
+func runGUI() {
 	a := app.NewWithID("org.olivetum.miner")
 	a.Settings().SetTheme(olivetumDarkTheme{})
 	w := a.NewWindow(appName)
@@ -127,9 +236,24 @@ func main() {
 	w.SetFullScreen(false)
 	w.Resize(fyne.NewSize(1120, 760))
 
-	cfg := loadConfig()
+	cfg, cfgErr := loadConfig()
+	cfgInvalidPath := ""
+	if cfgErr != nil {
+		// Keep the broken file out of the way of the next save.
+		if path, err := configPath(); err == nil {
+			cfgInvalidPath, _ = setAsideInvalidConfig(path)
+		}
+	}
 
 	xmrigPath, xmrigErr := findXMRig()
+	ctrl := newController(cfg, xmrigPath, xmrigErr)
+
+	var history *historyStore
+	var historyErr error
+	if cfg.HistoryEnabled {
+		history, historyErr = openHistoryStore(ctrl.Snapshot)
+	}
+	ledger, ledgerErr := openBlockLedger(ctrl.Snapshot)
 
 	modeLabels := []string{
 		"Solo Pool (Stratum)",
@@ -176,6 +300,25 @@ func main() {
 	hugePagesCheck := widget.NewCheck("Use huge pages", nil)
 	hugePagesCheck.SetChecked(cfg.UseHugePages)
 
+	randomXModeSelect := widget.NewSelect([]string{randomXModeAuto, randomXModeFast, randomXModeLight}, nil)
+	randomXModeSelect.SetSelected(cfg.RandomXMode)
+
+	oneGBPagesCheck := widget.NewCheck("Use 1 GB pages for the RandomX dataset (Linux)", nil)
+	oneGBPagesCheck.SetChecked(cfg.RandomX1GBPages)
+
+	cpuPriorityLabels := []string{"Default", "0 (idle)", "1", "2 (normal)", "3", "4", "5 (highest)"}
+	cpuPrioritySelect := widget.NewSelect(cpuPriorityLabels, nil)
+	cpuPrioritySelect.SetSelectedIndex(cfg.CPUPriority - cpuPriorityDefault)
+	selectedCPUPriority := func() int {
+		if i := cpuPrioritySelect.SelectedIndex(); i >= 0 {
+			return i + cpuPriorityDefault
+		}
+		return cpuPriorityDefault
+	}
+
+	cpuYieldCheck := widget.NewCheck("Yield to other programs", nil)
+	cpuYieldCheck.SetChecked(cfg.CPUYield)
+
 	donateEntry := widget.NewEntry()
 	donateEntry.SetPlaceHolder("0")
 	if cfg.DonateLevel >= 0 {
@@ -190,8 +333,9 @@ func main() {
 	portEntry.SetText(strconv.Itoa(cfg.StratumPort))
 	portEntry.SetPlaceHolder(strconv.Itoa(defaultStratumPort))
 
+	addressBook := func() []AddressBookEntry { return cfg.AddressBook }
 	walletEntry := widget.NewEntry()
-	walletEntry.SetText(cfg.WalletAddress)
+	walletEntry.SetText(displayAddress(cfg.WalletAddress))
 	walletEntry.SetPlaceHolder("0x...")
 
 	workerEntry := widget.NewEntry()
@@ -202,6 +346,15 @@ func main() {
 	rpcEntry.SetText(cfg.RPCURL)
 	rpcEntry.SetPlaceHolder(defaultRPCURL)
 
+	backupPools := append([]PoolConfig(nil), cfg.Pools...)
+
+	poolFailbackCheck := widget.NewCheck("Return to the primary pool when it is healthy again", nil)
+	poolFailbackCheck.SetChecked(cfg.PoolFailbackEnabled)
+
+	poolFailbackEntry := widget.NewEntry()
+	poolFailbackEntry.SetText(strconv.Itoa(cfg.PoolFailbackMin))
+	poolFailbackEntry.SetPlaceHolder(strconv.Itoa(defaultPoolFailbackMin))
+
 	nodeEnabledCheck := widget.NewCheck("Run a node", nil)
 	nodeEnabledCheck.SetChecked(cfg.NodeEnabled)
 
@@ -231,7 +384,7 @@ func main() {
 	}
 
 	nodeEtherbaseEntry := widget.NewEntry()
-	nodeEtherbaseEntry.SetText(cfg.NodeEtherbase)
+	nodeEtherbaseEntry.SetText(displayAddress(cfg.NodeEtherbase))
 	nodeEtherbaseEntry.SetPlaceHolder("0x...")
 
 	nodeDataDirEntry := widget.NewEntry()
@@ -256,6 +409,14 @@ func main() {
 	nodeBootnodesEntry.SetText(cfg.NodeBootnodes)
 	nodeBootnodesEntry.SetPlaceHolder(defaultNodeBootnodes)
 
+	nodeStaticPeersEntry := widget.NewMultiLineEntry()
+	nodeStaticPeersEntry.SetText(strings.Join(cfg.NodeStaticPeers, "\n"))
+	nodeStaticPeersEntry.SetPlaceHolder("enode://…@host:port, one per line")
+
+	nodeTrustedPeersEntry := widget.NewMultiLineEntry()
+	nodeTrustedPeersEntry.SetText(strings.Join(cfg.NodeTrustedPeers, "\n"))
+	nodeTrustedPeersEntry.SetPlaceHolder("enode://…@host:port, one per line")
+
 	nodeVerbosityEntry := widget.NewEntry()
 	if cfg.NodeVerbosity > 0 {
 		nodeVerbosityEntry.SetText(strconv.Itoa(cfg.NodeVerbosity))
@@ -265,6 +426,59 @@ func main() {
 	nodeCleanStartCheck := widget.NewCheck("Start with clean database (next start)", nil)
 	nodeCleanStartCheck.SetChecked(cfg.NodeCleanStart)
 
+	scheduleEnabledCheck := widget.NewCheck("Mine only during the scheduled windows", nil)
+	scheduleEnabledCheck.SetChecked(cfg.ScheduleEnabled)
+	scheduleWindows := append([]ScheduleWindow(nil), cfg.Schedule...)
+
+	idleEnabledCheck := widget.NewCheck("Limit mining while I use this computer", nil)
+	idleEnabledCheck.SetChecked(cfg.IdleEnabled)
+
+	idleAfterEntry := widget.NewEntry()
+	idleAfterEntry.SetText(strconv.Itoa(cfg.IdleAfterMin))
+	idleAfterEntry.SetPlaceHolder(strconv.Itoa(defaultIdleAfterMin))
+
+	idleActionSelect := widget.NewSelect([]string{"Pause", "Reduce threads (restarts xmrig)"}, nil)
+	selectedIdleAction := func() string {
+		if idleActionSelect.SelectedIndex() == 1 {
+			return throttleActionThreads
+		}
+		return throttleActionPause
+	}
+
+	idleThreadsEntry := widget.NewEntry()
+	idleThreadsEntry.SetText(strconv.Itoa(cfg.IdleActiveThreads))
+	idleThreadsEntry.SetPlaceHolder("1")
+
+	idleLoadEntry := widget.NewEntry()
+	if cfg.IdleLoadLimit > 0 {
+		idleLoadEntry.SetText(strconv.Itoa(cfg.IdleLoadLimit))
+	}
+	idleLoadEntry.SetPlaceHolder("Off")
+
+	thermalEnabledCheck := widget.NewCheck("Protect the CPU from high temperatures", nil)
+	thermalEnabledCheck.SetChecked(cfg.ThermalEnabled)
+
+	thermalLimitEntry := widget.NewEntry()
+	thermalLimitEntry.SetText(strconv.Itoa(cfg.ThermalLimitC))
+	thermalLimitEntry.SetPlaceHolder(strconv.Itoa(defaultThermalLimitC))
+
+	thermalResumeEntry := widget.NewEntry()
+	thermalResumeEntry.SetText(strconv.Itoa(cfg.ThermalResumeC))
+	thermalResumeEntry.SetPlaceHolder(strconv.Itoa(defaultThermalResumeC))
+
+	thermalActionSelect := widget.NewSelect([]string{"Pause", "Reduce threads (restarts xmrig)"}, nil)
+	if cfg.ThermalAction == throttleActionThreads {
+		thermalActionSelect.SetSelectedIndex(1)
+	} else {
+		thermalActionSelect.SetSelectedIndex(0)
+	}
+	selectedThermalAction := func() string {
+		if thermalActionSelect.SelectedIndex() == 1 {
+			return throttleActionThreads
+		}
+		return throttleActionPause
+	}
+
 	watchdogEnabledCheck := widget.NewCheck("Enable watchdog (restart miner if jobs stop)", nil)
 	watchdogEnabledCheck.SetChecked(cfg.WatchdogEnabled)
 
@@ -280,6 +494,64 @@ func main() {
 	watchdogRetryWindowEntry.SetText(strconv.Itoa(cfg.WatchdogRetryWindowMin))
 	watchdogRetryWindowEntry.SetPlaceHolder("10")
 
+	historyEnabledCheck := widget.NewCheck("Keep hashrate and share history", nil)
+	historyEnabledCheck.SetChecked(cfg.HistoryEnabled)
+
+	historyRawEntry := widget.NewEntry()
+	historyRawEntry.SetText(strconv.Itoa(cfg.HistoryRawRetentionHours))
+	historyRawEntry.SetPlaceHolder(strconv.Itoa(defaultHistoryRawRetentionHours))
+
+	historyMinuteEntry := widget.NewEntry()
+	historyMinuteEntry.SetText(strconv.Itoa(cfg.HistoryMinuteRetentionDays))
+	historyMinuteEntry.SetPlaceHolder(strconv.Itoa(defaultHistoryMinuteRetentionDays))
+
+	historyHourEntry := widget.NewEntry()
+	historyHourEntry.SetText(strconv.Itoa(cfg.HistoryHourRetentionDays))
+	historyHourEntry.SetPlaceHolder(strconv.Itoa(defaultHistoryHourRetentionDays))
+
+	historyDayEntry := widget.NewEntry()
+	historyDayEntry.SetText(strconv.Itoa(cfg.HistoryDayRetentionDays))
+	historyDayEntry.SetPlaceHolder(strconv.Itoa(defaultHistoryDayRetentionDays))
+
+	metricsEnabledCheck := widget.NewCheck("Serve Prometheus metrics on /metrics", nil)
+	metricsEnabledCheck.SetChecked(cfg.MetricsEnabled)
+
+	metricsBindEntry := widget.NewEntry()
+	metricsBindEntry.SetText(cfg.MetricsBindAddress)
+	metricsBindEntry.SetPlaceHolder(defaultMetricsBindAddress)
+
+	metricsPortEntry := widget.NewEntry()
+	metricsPortEntry.SetText(strconv.Itoa(cfg.MetricsPort))
+	metricsPortEntry.SetPlaceHolder(strconv.Itoa(defaultMetricsPort))
+
+	blockRewardEntry := widget.NewEntry()
+	if cfg.BlockReward > 0 {
+		blockRewardEntry.SetText(strconv.FormatFloat(cfg.BlockReward, 'f', -1, 64))
+	}
+	blockRewardEntry.SetPlaceHolder("OLIVO per block")
+
+	walletRPCEntry := widget.NewEntry()
+	walletRPCEntry.SetText(cfg.WalletRPCURL)
+	walletRPCEntry.SetPlaceHolder("Embedded node or mining RPC")
+
+	whatIfEntry := widget.NewEntry()
+	whatIfEntry.SetPlaceHolder("e.g. 25 kH/s")
+
+	apiEnabledCheck := widget.NewCheck("Enable control API", nil)
+	apiEnabledCheck.SetChecked(cfg.APIEnabled)
+
+	apiBindEntry := widget.NewEntry()
+	apiBindEntry.SetText(cfg.APIBindAddress)
+	apiBindEntry.SetPlaceHolder(defaultAPIBindAddress)
+
+	apiPortEntry := widget.NewEntry()
+	apiPortEntry.SetText(strconv.Itoa(cfg.APIPort))
+	apiPortEntry.SetPlaceHolder(strconv.Itoa(defaultAPIPort))
+
+	apiTokenEntry := widget.NewPasswordEntry()
+	apiTokenEntry.SetText(cfg.APIToken)
+	apiTokenEntry.SetPlaceHolder("generated on Apply")
+
 	displayIntervalEntry := widget.NewEntry()
 	if cfg.DisplayInterval > 0 {
 		displayIntervalEntry.SetText(strconv.Itoa(cfg.DisplayInterval))
@@ -361,6 +633,48 @@ func main() {
 	avgHashrateValue.Wrapping = fyne.TextWrapOff
 	avgHashrateValue.Importance = widget.MediumImportance
 
+	historyRange := historyRangeLabels[0]
+	refreshHashrateHistory := func() {
+		if history == nil {
+			return
+		}
+		rangeLabel := historyRange
+		go func() {
+			points, err := history.Query(historyRangeSpan(rangeLabel))
+			if err != nil {
+				return
+			}
+			values := make([]float64, len(points))
+			for i, p := range points {
+				values[i] = p.Hashrate
+			}
+			avg, accepted, _, _, ok := summarizeHistory(points)
+			fyne.Do(func() {
+				if historyRange != rangeLabel {
+					return
+				}
+				hashrateHistory.SetPoints(values)
+				if !ok {
+					avgHashrateValue.SetText("Avg —")
+					return
+				}
+				text := fmt.Sprintf("Avg %s", formatHashrate(avg))
+				if rangeLabel != historyRangeLabels[0] {
+					text += fmt.Sprintf(" | %d shares", accepted)
+				}
+				avgHashrateValue.SetText(text)
+			})
+		}()
+	}
+	historyRangeSelect := widget.NewSelect(historyRangeLabels, func(label string) {
+		historyRange = label
+		refreshHashrateHistory()
+	})
+	historyRangeSelect.SetSelected(historyRange)
+	if history == nil {
+		historyRangeSelect.Disable()
+	}
+
 	blendColor := func(a, b color.NRGBA, t float32) color.NRGBA {
 		if t < 0 {
 			t = 0
@@ -421,25 +735,8 @@ func main() {
 	)
 	deviceLabelByIndex := make(map[int]string)
 
-	minerLogBuf := newRingLogs(5000)
-	nodeLogBuf := newRingLogs(5000)
-
-	var (
-		minerDeviceMapMu sync.RWMutex
-		minerDeviceMap   []int
-	)
-
-	setMinerDeviceMap := func(selected []int) {
-		minerDeviceMapMu.Lock()
-		minerDeviceMap = append([]int(nil), selected...)
-		minerDeviceMapMu.Unlock()
-	}
-
-	getMinerDeviceMap := func() []int {
-		minerDeviceMapMu.RLock()
-		defer minerDeviceMapMu.RUnlock()
-		return append([]int(nil), minerDeviceMap...)
-	}
+	minerLogBuf := ctrl.minerLog
+	nodeLogBuf := ctrl.nodeLog
 
 	minerFollowTailCheck := widget.NewCheck("Follow tail", nil)
 	minerFollowTailCheck.SetChecked(true)
@@ -622,6 +919,7 @@ func main() {
 		Hashrate float64
 		Temp     int
 		Fan      int
+		FanRPM   int
 		Power    float64
 	}
 	var (
@@ -680,6 +978,8 @@ func main() {
 				text.TextStyle = fyne.TextStyle{Monospace: true}
 				if data.Fan > 0 {
 					text.SetText(fmt.Sprintf("%d%%", data.Fan))
+				} else if data.FanRPM > 0 {
+					text.SetText(fmt.Sprintf("%d rpm", data.FanRPM))
 				} else {
 					text.SetText("—")
 				}
@@ -758,11 +1058,13 @@ func main() {
 			if i < len(s.Fans) && s.Fans[i] > 0 {
 				fan = s.Fans[i]
 			}
+			fanRPM := 0
 			power := -1.0
 			if i < len(s.PerGPU_Power) && s.PerGPU_Power[i] >= 0 {
 				power = s.PerGPU_Power[i]
 			}
 			if fallback, ok := fallbackSensors[i]; ok {
+				fanRPM = fallback.FanRPM
 				if temp == 0 && fallback.Temp > 0 {
 					temp = fallback.Temp
 				}
@@ -779,6 +1081,7 @@ func main() {
 				Hashrate: hashrate,
 				Temp:     temp,
 				Fan:      fan,
+				FanRPM:   fanRPM,
 				Power:    power,
 			})
 		}
@@ -805,39 +1108,27 @@ func main() {
 		reset bool
 	}
 
-	var (
-		minerStartedAt            atomic.Int64
-		lastJobAt                 atomic.Int64
-		currentJobBlock           atomic.Int64
-		lastFoundBlock            atomic.Int64
-		jobDifficulty             atomic.Value
-		nodeChainIssueDialogShown atomic.Bool
-		nodeChainIssueCount       atomic.Int64
-		nodeChainIssueFirstAt     atomic.Int64
-	)
-	jobDifficulty.Store("")
+	var nodeChainIssueDialogShown atomic.Bool
 
 	minerLogEvents := make(chan logEvent, 256)
 	nodeLogEvents := make(chan logEvent, 256)
 
-	resetMinerLog := func() {
+	onMinerLogReset := func() {
 		logSensorMu.Lock()
 		logSensors = make(map[int]deviceSensors)
 		logSensorMu.Unlock()
 		minerLogSnapshotMu.Lock()
 		minerLogSnapshot = nil
 		minerLogSnapshotMu.Unlock()
-		minerLogBuf.Clear()
 		select {
 		case minerLogEvents <- logEvent{reset: true}:
 		default:
 		}
 	}
-	resetNodeLog := func() {
+	onNodeLogReset := func() {
 		nodeLogSnapshotMu.Lock()
 		nodeLogSnapshot = nil
 		nodeLogSnapshotMu.Unlock()
-		nodeLogBuf.Clear()
 		select {
 		case nodeLogEvents <- logEvent{reset: true}:
 		default:
@@ -846,115 +1137,38 @@ func main() {
 
 	var resetNodeDataAndResync func(startAfter bool, requireConfirm bool)
 
-	appendMinerLog := func(text string) {
-		text = sanitizeLogLine(text)
-		lineCount := 0
-		handleLine := func(line string) {
-			if m := xmrigJobLine.FindStringSubmatch(line); len(m) == 3 {
-				if diff := strings.TrimSpace(m[1]); diff != "" {
-					jobDifficulty.Store(diff)
-				}
-				if block, err := strconv.ParseInt(m[2], 10, 64); err == nil && block > 0 {
-					currentJobBlock.Store(block)
-				}
-				lastJobAt.Store(time.Now().UnixNano())
-			}
-			minerLogBuf.Append(line)
-			lineCount++
-		}
-		if strings.IndexByte(text, '\n') == -1 {
-			handleLine(text)
-		} else {
-			for _, line := range strings.Split(text, "\n") {
-				handleLine(line)
-			}
-		}
-		if lineCount > 0 {
-			minerLogVersion.Add(int64(lineCount))
-			select {
-			case minerLogEvents <- logEvent{}:
-			default:
-			}
+	appendMinerLog := ctrl.appendMinerLog
+
+	onMinerLogLine := func() {
+		minerLogVersion.Add(1)
+		select {
+		case minerLogEvents <- logEvent{}:
+		default:
 		}
 	}
 
-	appendNodeLog := func(text string) {
-		text = sanitizeLogLine(text)
-		lineCount := 0
-		handleLine := func(line string) {
-			if m := nodeMinedPotentialBlockLine.FindStringSubmatch(line); len(m) == 2 {
-				n := strings.ReplaceAll(m[1], ",", "")
-				if block, err := strconv.ParseInt(n, 10, 64); err == nil && block > 0 {
-					lastFoundBlock.Store(block)
-					fyne.Do(func() { lastFoundBlockValue.SetText(fmt.Sprintf("%d", block)) })
-				}
-			} else if m := nodeSealedNewBlockLine.FindStringSubmatch(line); len(m) == 2 {
-				n := strings.ReplaceAll(m[1], ",", "")
-				if block, err := strconv.ParseInt(n, 10, 64); err == nil && block > 0 {
-					lastFoundBlock.Store(block)
-					fyne.Do(func() { lastFoundBlockValue.SetText(fmt.Sprintf("%d", block)) })
-				}
-			}
-			lower := strings.ToLower(line)
-			isLocalDBIssue := (strings.Contains(lower, "failed to read") && strings.Contains(lower, "last block")) ||
-				strings.Contains(lower, "missing trie node") ||
-				(strings.Contains(lower, "failed to restore") && strings.Contains(lower, "runtime")) ||
-				(strings.Contains(lower, "database") && strings.Contains(lower, "corrupt")) ||
-				strings.Contains(lower, "chaindata is corrupt") ||
-				strings.Contains(lower, "corruption") ||
-				strings.Contains(lower, "fatal")
-			if isLocalDBIssue && resetNodeDataAndResync != nil {
-				isFatal := (strings.Contains(lower, "failed to read") && strings.Contains(lower, "last block")) ||
-					(strings.Contains(lower, "database") && strings.Contains(lower, "corrupt")) ||
-					strings.Contains(lower, "chaindata is corrupt") ||
-					strings.Contains(lower, "corruption") ||
-					strings.Contains(lower, "fatal")
-
-				now := time.Now().UnixNano()
-				const issueWindow = 45 * time.Second
-				const issueThreshold = int64(3)
-
-				firstAt := nodeChainIssueFirstAt.Load()
-				if firstAt == 0 || now-firstAt > int64(issueWindow) {
-					nodeChainIssueFirstAt.Store(now)
-					nodeChainIssueCount.Store(1)
-				} else {
-					nodeChainIssueCount.Add(1)
-				}
-
-				shouldPrompt := isFatal || nodeChainIssueCount.Load() >= issueThreshold
-				if shouldPrompt && nodeChainIssueDialogShown.CompareAndSwap(false, true) {
-					fyne.Do(func() {
-						msg := widget.NewLabel("A potential local database issue was detected.\n\nIf syncing continues normally, you can ignore this.\nIf the issue repeats after restart or the node cannot sync, a resync may help.")
-						msg.Wrapping = fyne.TextWrapWord
-						d := dialog.NewCustomConfirm(appName, "Reset node data & resync", "Dismiss", msg, func(ok bool) {
-							if ok {
-								resetNodeDataAndResync(true, false)
-								return
-							}
-							nodeChainIssueDialogShown.Store(false)
-						}, w)
-						d.Show()
-					})
-				}
-			}
-			nodeLogBuf.Append(line)
-			lineCount++
+	onNodeLogLine := func() {
+		nodeLogVersion.Add(1)
+		select {
+		case nodeLogEvents <- logEvent{}:
+		default:
 		}
-		if strings.IndexByte(text, '\n') == -1 {
-			handleLine(text)
-		} else {
-			for _, line := range strings.Split(text, "\n") {
-				handleLine(line)
-			}
+	}
+
+	onNodeIssue := func() {
+		if resetNodeDataAndResync == nil || !nodeChainIssueDialogShown.CompareAndSwap(false, true) {
+			return
 		}
-		if lineCount > 0 {
-			nodeLogVersion.Add(int64(lineCount))
-			select {
-			case nodeLogEvents <- logEvent{}:
-			default:
+		msg := widget.NewLabel("A potential local database issue was detected.\n\nIf syncing continues normally, you can ignore this.\nIf the issue repeats after restart or the node cannot sync, a resync may help.")
+		msg.Wrapping = fyne.TextWrapWord
+		d := dialog.NewCustomConfirm(appName, "Reset node data & resync", "Dismiss", msg, func(ok bool) {
+			if ok {
+				resetNodeDataAndResync(true, false)
+				return
 			}
-		}
+			nodeChainIssueDialogShown.Store(false)
+		}, w)
+		d.Show()
 	}
 
 	go func() {
@@ -1043,7 +1257,7 @@ func main() {
 
 	quickPoolRow := container.NewGridWithColumns(2, hostEntry, portEntry)
 	modeRow := formRow("Mode", modeSelect)
-	walletRow := formRow("Wallet", walletEntry)
+	walletRow := formRow("Wallet", addressField(walletEntry, addressBook))
 	workerRow := formRow("Worker", workerEntry)
 	poolRow := formRow("Pool", quickPoolRow)
 	rpcRow := formRow("RPC URL", rpcEntry)
@@ -1159,36 +1373,55 @@ func main() {
 	}
 	refreshBtn.OnTapped = refreshDevices
 
-	var (
-		procMu             sync.Mutex
-		minerCmd           *exec.Cmd
-		minerCtx           context.Context
-		minerCancel        context.CancelFunc
-		apiPort            int
-		pollCancel         context.CancelFunc
-		waitingForStats    atomic.Bool
-		lastAccepted       atomic.Int64
-		watchdogCancel     context.CancelFunc
-		watchdogRestarting atomic.Bool
-
-		nodeCmd     *exec.Cmd
-		nodeCtx     context.Context
-		nodeCancel  context.CancelFunc
-		nodeRunMode string
-	)
-
 	var startBtn *widget.Button
 	var stopBtn *widget.Button
+	var pauseBtn *widget.Button
 	var nodeStartBtn *widget.Button
 	var nodeStopBtn *widget.Button
 
-	setRunningUI := func(running bool) {
-		if running {
-			if waitingForStats.Load() {
+	// liveApplyPanel sits above Setup while the miner runs; it pushes saved
+	// changes to xmrig and lists the ones that need a restart.
+	liveApplyText := widget.NewLabel("")
+	liveApplyText.Wrapping = fyne.TextWrapWord
+	var restartNowBtn *widget.Button
+	var liveApplyPanel fyne.CanvasObject
+	refreshPendingRestart := func() {
+		if liveApplyPanel == nil {
+			return
+		}
+		if !ctrl.MinerRunning() {
+			liveApplyPanel.Hide()
+			return
+		}
+		if pending := ctrl.PendingRestart(); len(pending) > 0 {
+			liveApplyText.SetText("Pending restart: " + strings.Join(pending, ", "))
+			restartNowBtn.Show()
+		} else {
+			liveApplyText.SetText("Apply sends pool, thread, donate level and watchdog changes to the running miner.")
+			restartNowBtn.Hide()
+		}
+		liveApplyPanel.Show()
+	}
+
+	setRunningUI := func(state minerState) {
+		defer refreshPendingRestart()
+		if state != minerStateStopped {
+			if state == minerStateStarting {
 				setStatusText("Starting")
 				setConnectionBadge("Conn: Connecting", connConnectingColor)
 			} else {
-				setStatusText("Running")
+				if ctrl.Throttled() {
+					setStatusText("Throttled")
+				} else if ctrl.MinerPaused() {
+					reasons := ctrl.PauseReasons()
+					if len(reasons) == 1 && reasons[0] != limitSourceUser {
+						setStatusText("Paused (" + reasons[0] + ")")
+					} else {
+						setStatusText("Paused")
+					}
+				} else {
+					setStatusText("Running")
+				}
 				setConnectionBadge("Conn: Live", connLiveColor)
 			}
 			setStatusDot(theme.Color(theme.ColorNamePrimary))
@@ -1198,13 +1431,17 @@ func main() {
 			if stopBtn != nil {
 				stopBtn.Enable()
 			}
+			if pauseBtn != nil {
+				if ctrl.MinerPaused() {
+					pauseBtn.SetText("Resume")
+					pauseBtn.SetIcon(theme.MediaPlayIcon())
+				} else {
+					pauseBtn.SetText("Pause")
+					pauseBtn.SetIcon(theme.MediaPauseIcon())
+				}
+				pauseBtn.Enable()
+			}
 		} else {
-			waitingForStats.Store(false)
-			lastAccepted.Store(0)
-			lastJobAt.Store(0)
-			currentJobBlock.Store(0)
-			lastFoundBlock.Store(0)
-			jobDifficulty.Store("")
 			setStatusText("Stopped")
 			setStatusDot(theme.Color(theme.ColorNameDisabled))
 			setConnectionBadge("Conn: Offline", connOfflineColor)
@@ -1217,8 +1454,12 @@ func main() {
 			currentBlockValue.SetText("—")
 			currentDifficultyValue.SetText("—")
 			lastFoundBlockValue.SetText("—")
-			hashrateHistory.Reset()
-			avgHashrateValue.SetText("Avg —")
+			if history == nil {
+				hashrateHistory.Reset()
+				avgHashrateValue.SetText("Avg —")
+			} else {
+				refreshHashrateHistory()
+			}
 			lastStatMu.Lock()
 			lastStat = nil
 			lastStatMu.Unlock()
@@ -1233,82 +1474,69 @@ func main() {
 			if stopBtn != nil {
 				stopBtn.Disable()
 			}
+			if pauseBtn != nil {
+				pauseBtn.SetText("Pause")
+				pauseBtn.SetIcon(theme.MediaPauseIcon())
+				pauseBtn.Disable()
+			}
 		}
 	}
 
 	saveFromUI := func() error {
-		mode := selectedMode()
+		next := *cfg
+		next.Mode = selectedMode()
 		var err error
 
-		host := strings.TrimSpace(hostEntry.Text)
-		if host == "" {
-			host = defaultStratumHost
+		next.StratumHost = strings.TrimSpace(hostEntry.Text)
+		if next.StratumHost == "" {
+			next.StratumHost = defaultStratumHost
 		}
 
-		var port int
-		portText := strings.TrimSpace(portEntry.Text)
-		if portText == "" {
-			port = defaultStratumPort
-		} else {
-			port, err = strconv.Atoi(portText)
-			if mode == modeStratum && (err != nil || port < 1 || port > 65535) {
-				return errors.New("invalid stratum port")
-			}
-			if err != nil || port < 1 || port > 65535 {
-				port = cfg.StratumPort
-			}
+		if portText := strings.TrimSpace(portEntry.Text); portText == "" {
+			next.StratumPort = defaultStratumPort
+		} else if port, err := strconv.Atoi(portText); err == nil && port >= 1 && port <= 65535 {
+			next.StratumPort = port
+		} else if next.Mode == modeStratum {
+			return errors.New("invalid stratum port")
 		}
 
 		rpcURLText := strings.TrimSpace(rpcEntry.Text)
-		rpcURL := cfg.RPCURL
-		if mode != modeStratum {
-			rpcURL, err = normalizeRPCURL(rpcURLText)
-			if err != nil {
+		if next.Mode != modeStratum {
+			if next.RPCURL, err = normalizeRPCURL(rpcURLText); err != nil {
 				return err
 			}
 		} else if rpcURLText != "" {
 			if normalized, err := normalizeRPCURL(rpcURLText); err == nil {
-				rpcURL = normalized
+				next.RPCURL = normalized
 			}
 		}
-		if rpcURL == "" {
-			rpcURL = defaultRPCURL
+		if next.RPCURL == "" {
+			next.RPCURL = defaultRPCURL
 		}
 
-		wallet := strings.TrimSpace(walletEntry.Text)
-		if mode != modeRPCLocal {
-			if !isHexAddress(wallet) {
-				return errors.New("invalid wallet address (expected 0x + 40 hex chars)")
-			}
-		}
+		next.WalletAddress = strings.TrimSpace(walletEntry.Text)
+		next.WorkerName = strings.TrimSpace(workerEntry.Text)
 
-		worker := strings.TrimSpace(workerEntry.Text)
-		if mode == modeStratum {
-			if worker != "" && !regexp.MustCompile(`^[0-9A-Za-z_-]{1,16}$`).MatchString(worker) {
-				return errors.New("invalid worker name (allowed: 0-9 A-Z a-z _ -; max 16)")
-			}
-		}
+		next.Pools = append([]PoolConfig(nil), backupPools...)
+		next.PoolFailbackEnabled = poolFailbackCheck.Checked
 
-		cpuThreads := 0
+		next.CPUThreads = 0
 		if txt := strings.TrimSpace(threadsEntry.Text); txt != "" {
-			cpuThreads, err = strconv.Atoi(txt)
-			if err != nil || cpuThreads < 0 || cpuThreads > 4096 {
+			if next.CPUThreads, err = strconv.Atoi(txt); err != nil {
 				return errors.New("invalid CPU threads value (0..4096)")
 			}
 		}
 
-		displayIntv := 10
-		if strings.TrimSpace(displayIntervalEntry.Text) != "" {
-			displayIntv, err = strconv.Atoi(strings.TrimSpace(displayIntervalEntry.Text))
-			if err != nil || displayIntv < 1 || displayIntv > 1800 {
+		next.DisplayInterval = 10
+		if txt := strings.TrimSpace(displayIntervalEntry.Text); txt != "" {
+			if next.DisplayInterval, err = strconv.Atoi(txt); err != nil {
 				return errors.New("invalid display interval (1..1800)")
 			}
 		}
 
-		donateLevel := 0
+		next.DonateLevel = 0
 		if txt := strings.TrimSpace(donateEntry.Text); txt != "" {
-			donateLevel, err = strconv.Atoi(txt)
-			if err != nil || donateLevel < 0 || donateLevel > 100 {
+			if next.DonateLevel, err = strconv.Atoi(txt); err != nil {
 				return errors.New("invalid donate level (0..100)")
 			}
 		}
@@ -1321,99 +1549,137 @@ func main() {
 			}
 		}
 		devMu.Unlock()
-
-		cfg.Mode = mode
-		cfg.StratumHost = host
-		cfg.StratumPort = port
-		cfg.RPCURL = rpcURL
-		if isHexAddress(wallet) {
-			cfg.WalletAddress = strings.ToLower(wallet)
-		} else {
-			cfg.WalletAddress = wallet
-		}
-		cfg.WorkerName = worker
-		cfg.CPUThreads = cpuThreads
-		cfg.CPUAffinity = selected
-		cfg.SelectedDevices = append([]int(nil), selected...)
-		cfg.UseHugePages = hugePagesCheck.Checked
-		cfg.EnableMSR = msrCheck.Checked
-		cfg.AutoGrantMSR = autoMSRCheck.Checked
-		cfg.DonateLevel = donateLevel
-		cfg.DisplayInterval = displayIntv
-
-		cfg.NodeEnabled = nodeEnabledCheck.Checked
-		cfg.NodeMode = selectedNodeMode()
-
-		cfg.NodeDataDir = strings.TrimSpace(nodeDataDirEntry.Text)
-
-		nodeRPCPort := defaultNodeRPCPort
-		if strings.TrimSpace(nodeRPCPortEntry.Text) != "" {
-			nodeRPCPort, err = strconv.Atoi(strings.TrimSpace(nodeRPCPortEntry.Text))
-			if err != nil || nodeRPCPort < 1 || nodeRPCPort > 65535 {
+		next.CPUAffinity = selected
+		next.SelectedDevices = append([]int(nil), selected...)
+		next.UseHugePages = hugePagesCheck.Checked
+		next.RandomXMode = randomXModeSelect.Selected
+		next.RandomX1GBPages = oneGBPagesCheck.Checked
+		next.CPUPriority = selectedCPUPriority()
+		next.CPUYield = cpuYieldCheck.Checked
+		next.EnableMSR = msrCheck.Checked
+		next.AutoGrantMSR = autoMSRCheck.Checked
+
+		next.NodeEnabled = nodeEnabledCheck.Checked
+		next.NodeMode = selectedNodeMode()
+		next.NodeDataDir = strings.TrimSpace(nodeDataDirEntry.Text)
+
+		next.NodeRPCPort = defaultNodeRPCPort
+		if txt := strings.TrimSpace(nodeRPCPortEntry.Text); txt != "" {
+			if next.NodeRPCPort, err = strconv.Atoi(txt); err != nil {
 				return errors.New("invalid node RPC port")
 			}
 		}
-		cfg.NodeRPCPort = nodeRPCPort
 
-		nodeP2PPort := defaultNodeP2PPort
-		if strings.TrimSpace(nodeP2PPortEntry.Text) != "" {
-			nodeP2PPort, err = strconv.Atoi(strings.TrimSpace(nodeP2PPortEntry.Text))
-			if err != nil || nodeP2PPort < 1 || nodeP2PPort > 65535 {
+		next.NodeP2PPort = defaultNodeP2PPort
+		if txt := strings.TrimSpace(nodeP2PPortEntry.Text); txt != "" {
+			if next.NodeP2PPort, err = strconv.Atoi(txt); err != nil {
 				return errors.New("invalid node P2P port")
 			}
 		}
-		cfg.NodeP2PPort = nodeP2PPort
 
-		nodeBootnodes := strings.TrimSpace(nodeBootnodesEntry.Text)
-		if nodeBootnodes == "" {
-			nodeBootnodes = defaultNodeBootnodes
+		next.NodeBootnodes = strings.TrimSpace(nodeBootnodesEntry.Text)
+		if next.NodeBootnodes == "" {
+			next.NodeBootnodes = defaultNodeBootnodes
 		}
-		cfg.NodeBootnodes = nodeBootnodes
+		next.NodeStaticPeers = parsePeerList(nodeStaticPeersEntry.Text)
+		next.NodeTrustedPeers = parsePeerList(nodeTrustedPeersEntry.Text)
 
-		nodeVerbosity := defaultNodeVerbosity
-		if strings.TrimSpace(nodeVerbosityEntry.Text) != "" {
-			nodeVerbosity, err = strconv.Atoi(strings.TrimSpace(nodeVerbosityEntry.Text))
-			if err != nil || nodeVerbosity < 0 || nodeVerbosity > 5 {
+		next.NodeVerbosity = defaultNodeVerbosity
+		if txt := strings.TrimSpace(nodeVerbosityEntry.Text); txt != "" {
+			if next.NodeVerbosity, err = strconv.Atoi(txt); err != nil {
 				return errors.New("invalid node verbosity (0..5)")
 			}
 		}
-		cfg.NodeVerbosity = nodeVerbosity
-		nodeEtherbase := strings.TrimSpace(nodeEtherbaseEntry.Text)
-		if nodeEtherbase != "" && !isHexAddress(nodeEtherbase) {
-			if cfg.NodeEnabled {
-				return errors.New("invalid node mining address (expected 0x + 40 hex chars)")
-			}
-			cfg.NodeEtherbase = ""
-		} else if isHexAddress(nodeEtherbase) {
-			cfg.NodeEtherbase = strings.ToLower(nodeEtherbase)
-		} else {
-			cfg.NodeEtherbase = ""
+
+		next.NodeEtherbase = strings.TrimSpace(nodeEtherbaseEntry.Text)
+		if !next.NodeEnabled && !isHexAddress(next.NodeEtherbase) {
+			next.NodeEtherbase = ""
 		}
-		cfg.NodeCleanStart = nodeCleanStartCheck.Checked
+		next.NodeCleanStart = nodeCleanStartCheck.Checked
 
-		cfg.WatchdogEnabled = watchdogEnabledCheck.Checked
-		if text := strings.TrimSpace(watchdogNoJobEntry.Text); text != "" {
-			if v, err := strconv.Atoi(text); err == nil && v >= 5 && v <= 3600 {
-				cfg.WatchdogNoJobTimeoutSec = v
-			} else if cfg.WatchdogEnabled {
-				return errors.New("invalid watchdog no-job timeout (5..3600 seconds)")
+		// Disabled sections keep their previous values when the entry does
+		// not parse, so a stray character cannot block saving.
+		parseOptional := func(entry *widget.Entry, enabled bool, dst *int, msg string) error {
+			text := strings.TrimSpace(entry.Text)
+			if text == "" {
+				return nil
 			}
-		}
-		if text := strings.TrimSpace(watchdogRestartDelayEntry.Text); text != "" {
-			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 600 {
-				cfg.WatchdogRestartDelaySec = v
-			} else if cfg.WatchdogEnabled {
-				return errors.New("invalid watchdog restart delay (1..600 seconds)")
+			v, err := strconv.Atoi(text)
+			if err != nil {
+				if enabled {
+					return errors.New(msg)
+				}
+				return nil
 			}
+			*dst = v
+			return nil
 		}
-		if text := strings.TrimSpace(watchdogRetryWindowEntry.Text); text != "" {
-			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 1440 {
-				cfg.WatchdogRetryWindowMin = v
-			} else if cfg.WatchdogEnabled {
-				return errors.New("invalid watchdog retry window (1..1440 minutes)")
+
+		if err := parseOptional(poolFailbackEntry, next.PoolFailbackEnabled, &next.PoolFailbackMin, "invalid failback time (1..1440 minutes)"); err != nil {
+			return err
+		}
+
+		next.ScheduleEnabled = scheduleEnabledCheck.Checked
+		next.Schedule = append([]ScheduleWindow(nil), scheduleWindows...)
+
+		next.IdleEnabled = idleEnabledCheck.Checked
+		next.IdleActiveAction = selectedIdleAction()
+		if err := parseOptional(idleAfterEntry, next.IdleEnabled, &next.IdleAfterMin, "invalid idle time (1..1440 minutes)"); err != nil {
+			return err
+		}
+		if err := parseOptional(idleThreadsEntry, next.IdleEnabled && next.IdleActiveAction == throttleActionThreads, &next.IdleActiveThreads, "invalid threads while active (1..4096)"); err != nil {
+			return err
+		}
+		next.IdleLoadLimit = 0
+		if text := strings.TrimSpace(idleLoadEntry.Text); text != "" {
+			v, err := strconv.Atoi(text)
+			if err != nil {
+				return errors.New("invalid CPU load limit (0..100 percent)")
 			}
+			next.IdleLoadLimit = v
+		}
+
+		next.ThermalEnabled = thermalEnabledCheck.Checked
+		next.ThermalAction = selectedThermalAction()
+		if err := parseOptional(thermalLimitEntry, next.ThermalEnabled, &next.ThermalLimitC, "invalid temperature limit (40..110 °C)"); err != nil {
+			return err
+		}
+		if err := parseOptional(thermalResumeEntry, next.ThermalEnabled, &next.ThermalResumeC, "invalid resume temperature (30 °C up to below the limit)"); err != nil {
+			return err
+		}
+
+		next.WatchdogEnabled = watchdogEnabledCheck.Checked
+		if err := parseOptional(watchdogNoJobEntry, next.WatchdogEnabled, &next.WatchdogNoJobTimeoutSec, "invalid watchdog no-job timeout (5..3600 seconds)"); err != nil {
+			return err
+		}
+		if err := parseOptional(watchdogRestartDelayEntry, next.WatchdogEnabled, &next.WatchdogRestartDelaySec, "invalid watchdog restart delay (1..600 seconds)"); err != nil {
+			return err
+		}
+		if err := parseOptional(watchdogRetryWindowEntry, next.WatchdogEnabled, &next.WatchdogRetryWindowMin, "invalid watchdog retry window (1..1440 minutes)"); err != nil {
+			return err
+		}
+
+		next.HistoryEnabled = historyEnabledCheck.Checked
+		if err := parseOptional(historyRawEntry, next.HistoryEnabled, &next.HistoryRawRetentionHours, "invalid raw history retention (1..168 hours)"); err != nil {
+			return err
+		}
+		if err := parseOptional(historyMinuteEntry, next.HistoryEnabled, &next.HistoryMinuteRetentionDays, "invalid 1-minute history retention (1..90 days)"); err != nil {
+			return err
+		}
+		if err := parseOptional(historyHourEntry, next.HistoryEnabled, &next.HistoryHourRetentionDays, "invalid 1-hour history retention (1..1825 days)"); err != nil {
+			return err
+		}
+		if err := parseOptional(historyDayEntry, next.HistoryEnabled, &next.HistoryDayRetentionDays, "invalid daily history retention (1..3650 days)"); err != nil {
+			return err
+		}
+		next.WalletRPCURL = strings.TrimSpace(walletRPCEntry.Text)
+
+		normalizeConfig(&next)
+		if err := validateConfig(&next); err != nil {
+			return err
 		}
-		return saveConfig(cfg)
+		*cfg = next
+		return ctrl.Replace(cfg)
 	}
 
 	saveDraftFromUI := func() {
@@ -1441,9 +1707,20 @@ func main() {
 
 		cfg.WalletAddress = strings.TrimSpace(walletEntry.Text)
 		cfg.WorkerName = strings.TrimSpace(workerEntry.Text)
+		cfg.Pools = append([]PoolConfig(nil), backupPools...)
+		cfg.PoolFailbackEnabled = poolFailbackCheck.Checked
+		if minutes, err := strconv.Atoi(strings.TrimSpace(poolFailbackEntry.Text)); err == nil && minutes >= 1 && minutes <= 1440 {
+			cfg.PoolFailbackMin = minutes
+		}
 		cfg.UseHugePages = hugePagesCheck.Checked
 		cfg.EnableMSR = msrCheck.Checked
 		cfg.AutoGrantMSR = autoMSRCheck.Checked
+		if randomXModeSelect.Selected != "" {
+			cfg.RandomXMode = randomXModeSelect.Selected
+		}
+		cfg.RandomX1GBPages = oneGBPagesCheck.Checked
+		cfg.CPUPriority = selectedCPUPriority()
+		cfg.CPUYield = cpuYieldCheck.Checked
 
 		if diText := strings.TrimSpace(displayIntervalEntry.Text); diText != "" {
 			if di, err := strconv.Atoi(diText); err == nil && di >= 1 && di <= 1800 {
@@ -1501,6 +1778,12 @@ func main() {
 		} else if cfg.NodeBootnodes == "" {
 			cfg.NodeBootnodes = defaultNodeBootnodes
 		}
+		if peers := parsePeerList(nodeStaticPeersEntry.Text); validatePeerList("static", peers) == nil {
+			cfg.NodeStaticPeers = peers
+		}
+		if peers := parsePeerList(nodeTrustedPeersEntry.Text); validatePeerList("trusted", peers) == nil {
+			cfg.NodeTrustedPeers = peers
+		}
 
 		if vText := strings.TrimSpace(nodeVerbosityEntry.Text); vText != "" {
 			if v, err := strconv.Atoi(vText); err == nil && v >= 0 && v <= 5 {
@@ -1510,12 +1793,43 @@ func main() {
 			cfg.NodeVerbosity = defaultNodeVerbosity
 		}
 
-		if wallet := strings.TrimSpace(nodeEtherbaseEntry.Text); isHexAddress(wallet) {
+		if wallet := strings.TrimSpace(nodeEtherbaseEntry.Text); isHexAddress(wallet) && addressChecksumOK(wallet) {
 			cfg.NodeEtherbase = strings.ToLower(wallet)
 		} else if wallet == "" {
 			cfg.NodeEtherbase = ""
 		}
 
+		cfg.ScheduleEnabled = scheduleEnabledCheck.Checked && len(scheduleWindows) > 0
+		cfg.Schedule = append([]ScheduleWindow(nil), scheduleWindows...)
+		cfg.IdleEnabled = idleEnabledCheck.Checked
+		cfg.IdleActiveAction = selectedIdleAction()
+		if text := strings.TrimSpace(idleAfterEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 1440 {
+				cfg.IdleAfterMin = v
+			}
+		}
+		if text := strings.TrimSpace(idleThreadsEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 4096 {
+				cfg.IdleActiveThreads = v
+			}
+		}
+		if text := strings.TrimSpace(idleLoadEntry.Text); text == "" {
+			cfg.IdleLoadLimit = 0
+		} else if v, err := strconv.Atoi(text); err == nil && v >= 0 && v <= 100 {
+			cfg.IdleLoadLimit = v
+		}
+		cfg.ThermalEnabled = thermalEnabledCheck.Checked
+		cfg.ThermalAction = selectedThermalAction()
+		if text := strings.TrimSpace(thermalLimitEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 40 && v <= 110 {
+				cfg.ThermalLimitC = v
+			}
+		}
+		if text := strings.TrimSpace(thermalResumeEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 30 && v < cfg.ThermalLimitC {
+				cfg.ThermalResumeC = v
+			}
+		}
 		cfg.WatchdogEnabled = watchdogEnabledCheck.Checked
 		if text := strings.TrimSpace(watchdogNoJobEntry.Text); text != "" {
 			if v, err := strconv.Atoi(text); err == nil && v >= 5 && v <= 3600 {
@@ -1533,19 +1847,34 @@ func main() {
 			}
 		}
 
-		_ = saveConfig(cfg)
-	}
+		cfg.HistoryEnabled = historyEnabledCheck.Checked
+		if text := strings.TrimSpace(historyRawEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 168 {
+				cfg.HistoryRawRetentionHours = v
+			}
+		}
+		if text := strings.TrimSpace(historyMinuteEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 90 {
+				cfg.HistoryMinuteRetentionDays = v
+			}
+		}
+		if text := strings.TrimSpace(historyHourEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 1825 {
+				cfg.HistoryHourRetentionDays = v
+			}
+		}
+		if text := strings.TrimSpace(historyDayEntry.Text); text != "" {
+			if v, err := strconv.Atoi(text); err == nil && v >= 1 && v <= 3650 {
+				cfg.HistoryDayRetentionDays = v
+			}
+		}
+		if text := strings.TrimSpace(walletRPCEntry.Text); text == "" {
+			cfg.WalletRPCURL = ""
+		} else if normalized, err := normalizeRPCURL(text); err == nil {
+			cfg.WalletRPCURL = normalized
+		}
 
-	type nodeStartSettings struct {
-		Enabled    bool
-		CleanStart bool
-		Mode       string
-		DataDir    string
-		RPCPort    int
-		P2PPort    int
-		Bootnodes  string
-		Verbosity  int
-		Wallet     string
+		_ = ctrl.Replace(cfg)
 	}
 
 	snapshotNodeConfigFromUI := func(requireMiningService bool) (nodeStartSettings, error) {
@@ -1582,6 +1911,15 @@ func main() {
 		}
 		settings.Bootnodes = nodeBootnodes
 
+		settings.StaticPeers = parsePeerList(nodeStaticPeersEntry.Text)
+		if err := validatePeerList("static", settings.StaticPeers); err != nil {
+			return settings, err
+		}
+		settings.TrustedPeers = parsePeerList(nodeTrustedPeersEntry.Text)
+		if err := validatePeerList("trusted", settings.TrustedPeers); err != nil {
+			return settings, err
+		}
+
 		nodeVerbosity := defaultNodeVerbosity
 		if strings.TrimSpace(nodeVerbosityEntry.Text) != "" {
 			nodeVerbosity, err = strconv.Atoi(strings.TrimSpace(nodeVerbosityEntry.Text))
@@ -1595,6 +1933,9 @@ func main() {
 		if wallet == "" {
 			wallet = strings.TrimSpace(walletEntry.Text)
 		}
+		if err := validateAddressChecksum("mining address", wallet); err != nil {
+			return settings, err
+		}
 		if settings.Enabled && (settings.Mode == nodeModeMine || requireMiningService) {
 			if !isHexAddress(wallet) {
 				return settings, errors.New("mining address is required for node mining (expected 0x + 40 hex chars)")
@@ -1610,6 +1951,8 @@ func main() {
 		cfg.NodeRPCPort = settings.RPCPort
 		cfg.NodeP2PPort = settings.P2PPort
 		cfg.NodeBootnodes = settings.Bootnodes
+		cfg.NodeStaticPeers = settings.StaticPeers
+		cfg.NodeTrustedPeers = settings.TrustedPeers
 		cfg.NodeVerbosity = settings.Verbosity
 		cfg.NodeCleanStart = settings.CleanStart
 		if etherbase := strings.TrimSpace(nodeEtherbaseEntry.Text); isHexAddress(etherbase) {
@@ -1617,7 +1960,7 @@ func main() {
 		} else {
 			cfg.NodeEtherbase = ""
 		}
-		return settings, saveConfig(cfg)
+		return settings, ctrl.Replace(cfg)
 	}
 
 	setNodeButtons := func(running bool) {
@@ -1637,165 +1980,6 @@ func main() {
 		}
 	}
 
-	startNodeWithSettings := func(settings nodeStartSettings, requireMiningService bool) error {
-		procMu.Lock()
-		if nodeCmd != nil && nodeCmd.Process != nil {
-			procMu.Unlock()
-			return nil
-		}
-		procMu.Unlock()
-
-		gethPath, err := findGeth()
-		if err != nil {
-			return fmt.Errorf("geth not found: %w", err)
-		}
-		genesisPath, err := ensureGenesisFile()
-		if err != nil {
-			return fmt.Errorf("failed to prepare genesis file: %w", err)
-		}
-
-		dataDir := strings.TrimSpace(settings.DataDir)
-		if dataDir == "" {
-			dataDir = defaultNodeDataDir()
-		}
-		dataDir, err = expandUserPath(dataDir)
-		if err != nil {
-			return err
-		}
-		if dataDir == "" {
-			return errors.New("node data directory is required")
-		}
-		if err := os.MkdirAll(dataDir, 0o755); err != nil {
-			return err
-		}
-
-		if settings.CleanStart {
-			appendNodeLog("\n[node] Cleaning local chain data...\n")
-			if err := wipeNodeData(dataDir); err != nil {
-				return err
-			}
-			settings.CleanStart = false
-			cfg.NodeCleanStart = false
-			_ = saveConfig(cfg)
-			fyne.Do(func() { nodeCleanStartCheck.SetChecked(false) })
-		}
-
-		if !isGethInitialized(dataDir) {
-			appendNodeLog("\n[node] Initializing chain data...\n")
-			out, err := runGethInit(gethPath, dataDir, genesisPath)
-			if strings.TrimSpace(out) != "" {
-				appendNodeLog(out + "\n")
-			}
-			if err != nil {
-				return err
-			}
-		}
-
-		effectiveMode := settings.Mode
-		if requireMiningService {
-			effectiveMode = nodeModeMine
-		}
-
-		args := []string{
-			"--datadir", dataDir,
-			"--http", "--http.addr", "127.0.0.1", "--http.port", strconv.Itoa(settings.RPCPort),
-			"--http.api", "eth,net,web3,miner,olivetumhash,olivetum",
-			"--port", strconv.Itoa(settings.P2PPort),
-			"--syncmode", "snap",
-			"--gcmode", "full",
-			"--bootnodes", strings.TrimSpace(settings.Bootnodes),
-			"--verbosity", strconv.Itoa(settings.Verbosity),
-		}
-		autoStartMiningServiceAfterSync := false
-		if effectiveMode == nodeModeMine {
-			if !isHexAddress(settings.Wallet) {
-				return errors.New("wallet address is required for node mining")
-			}
-			// Do not start mining immediately: in core-geth this disables snap sync.
-			// We'll enable the mining service after the initial sync completes.
-			autoStartMiningServiceAfterSync = true
-			args = append(args,
-				"--miner.recommit=10s",
-				"--miner.etherbase", settings.Wallet,
-			)
-		}
-
-		nodeCtx, nodeCancel = context.WithCancel(context.Background())
-		cmd := exec.CommandContext(nodeCtx, gethPath, args...)
-		configureChildProcess(cmd)
-		cmd.Env = append(os.Environ(), "LC_ALL=C")
-
-		stdout, _ := cmd.StdoutPipe()
-		stderr, _ := cmd.StderrPipe()
-
-		appendNodeLog(fmt.Sprintf("\nStarting node: %s %s\n\n", gethPath, strings.Join(args, " ")))
-		if err := cmd.Start(); err != nil {
-			nodeCancel()
-			nodeCtx = nil
-			nodeCancel = nil
-			fyne.Do(func() {
-				setNodeBadge("Node: Off", connOfflineColor)
-				setNodeButtons(false)
-			})
-			return err
-		}
-
-		procMu.Lock()
-		nodeCmd = cmd
-		nodeRunMode = effectiveMode
-		procMu.Unlock()
-
-		go streamLines(stdout, appendNodeLog)
-		go streamLines(stderr, appendNodeLog)
-
-		if autoStartMiningServiceAfterSync {
-			go autoStartMiningService(nodeCtx, settings.RPCPort, appendNodeLog)
-		}
-
-		go func(ctx context.Context, port int) {
-			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			defer cancel()
-			ticker := time.NewTicker(500 * time.Millisecond)
-			defer ticker.Stop()
-			for {
-				conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 750*time.Millisecond)
-				if err == nil {
-					_ = conn.Close()
-					fyne.Do(func() { setNodeBadge("Node: Running", connLiveColor) })
-					return
-				}
-				select {
-				case <-ctx.Done():
-					return
-				case <-ticker.C:
-				}
-			}
-		}(nodeCtx, settings.RPCPort)
-
-		go func() {
-			err := cmd.Wait()
-			procMu.Lock()
-			nodeCmd = nil
-			nodeRunMode = ""
-			if nodeCancel != nil {
-				nodeCancel()
-				nodeCancel = nil
-			}
-			procMu.Unlock()
-
-			fyne.Do(func() {
-				setNodeBadge("Node: Off", connOfflineColor)
-				setNodeButtons(false)
-			})
-			if err != nil && !errors.Is(err, context.Canceled) {
-				appendNodeLog(fmt.Sprintf("\n[node exit] %v\n", err))
-			} else {
-				appendNodeLog("\n[node exit] node stopped\n")
-			}
-		}()
-		return nil
-	}
-
 	startNodeAsync := func(requireMiningService bool) error {
 		settings, err := snapshotNodeConfigFromUI(requireMiningService)
 		if err != nil {
@@ -1805,10 +1989,8 @@ func main() {
 			return errors.New("node is disabled")
 		}
 
-		procMu.Lock()
-		alreadyRunning := nodeCmd != nil && nodeCmd.Process != nil
-		runningMode := nodeRunMode
-		procMu.Unlock()
+		alreadyRunning := ctrl.NodeRunning()
+		runningMode := ctrl.NodeRunMode()
 		if alreadyRunning {
 			if requireMiningService && runningMode != nodeModeMine {
 				return errors.New("node is running without mining service enabled; stop the node and start it again with mining enabled")
@@ -1819,10 +2001,8 @@ func main() {
 		setNodeBadge("Node: Starting", connConnectingColor)
 		setNodeButtons(true)
 		go func(settings nodeStartSettings) {
-			if err := startNodeWithSettings(settings, requireMiningService); err != nil {
+			if err := ctrl.StartNode(settings, requireMiningService); err != nil {
 				fyne.Do(func() {
-					setNodeBadge("Node: Off", connOfflineColor)
-					setNodeButtons(false)
 					dialog.ShowError(err, w)
 				})
 			}
@@ -1830,29 +2010,7 @@ func main() {
 		return nil
 	}
 
-	stopNode := func() {
-		procMu.Lock()
-		defer procMu.Unlock()
-		if nodeCmd == nil || nodeCmd.Process == nil {
-			return
-		}
-		appendNodeLog("\nStopping node...\n")
-		cmd := nodeCmd
-		proc := nodeCmd.Process
-		if err := sendProcessInterrupt(proc); err != nil {
-			appendNodeLog(fmt.Sprintf("[node] interrupt failed: %v\n", err))
-		}
-		go func(cmd *exec.Cmd, p *os.Process) {
-			time.Sleep(60 * time.Second)
-			procMu.Lock()
-			still := nodeCmd == cmd
-			procMu.Unlock()
-			if still {
-				appendNodeLog("[node] Force-killing node (timeout)\n")
-				_ = p.Kill()
-			}
-		}(cmd, proc)
-	}
+	stopNode := ctrl.StopNode
 
 	redactPath := func(p string) string {
 		p = strings.TrimSpace(p)
@@ -1901,43 +2059,11 @@ func main() {
 
 		doReset := func() {
 			go func(dataDir string) {
-				fyne.Do(func() {
-					setNodeBadge("Node: Resetting", connConnectingColor)
-					setNodeButtons(true)
-				})
-
-				stopNode()
-				deadline := time.Now().Add(90 * time.Second)
-				for {
-					procMu.Lock()
-					running := nodeCmd != nil && nodeCmd.Process != nil
-					procMu.Unlock()
-					if !running {
-						break
-					}
-					if time.Now().After(deadline) {
-						fyne.Do(func() {
-							setNodeBadge("Node: Off", connOfflineColor)
-							setNodeButtons(false)
-							dialog.ShowError(errors.New("node did not stop in time"), w)
-						})
-						return
-					}
-					time.Sleep(250 * time.Millisecond)
-				}
-
-				appendNodeLog("\n[node] Removing local chain data...\n")
-				if err := wipeNodeData(dataDir); err != nil {
-					fyne.Do(func() {
-						setNodeBadge("Node: Off", connOfflineColor)
-						setNodeButtons(false)
-						dialog.ShowError(err, w)
-					})
+				if err := ctrl.ResetNodeData(dataDir); err != nil {
+					fyne.Do(func() { dialog.ShowError(err, w) })
 					return
 				}
 				nodeChainIssueDialogShown.Store(false)
-				nodeChainIssueCount.Store(0)
-				nodeChainIssueFirstAt.Store(0)
 
 				if startAfter {
 					fyne.Do(func() {
@@ -1947,11 +2073,6 @@ func main() {
 							dialog.ShowError(err, w)
 						}
 					})
-				} else {
-					fyne.Do(func() {
-						setNodeBadge("Node: Off", connOfflineColor)
-						setNodeButtons(false)
-					})
 				}
 			}(dataDir)
 		}
@@ -1971,564 +2092,179 @@ func main() {
 		d.Show()
 	}
 
-	type minerStartOrigin int
-
-	const (
-		minerStartOriginUser minerStartOrigin = iota
-		minerStartOriginWatchdog
-	)
-
-	type minerStopOrigin int
-
-	const (
-		minerStopOriginUser minerStopOrigin = iota
-		minerStopOriginWatchdog
-	)
-
-	type watchdogSettings struct {
-		NoJobTimeout time.Duration
-		RestartDelay time.Duration
-		RetryWindow  time.Duration
-	}
-
-	stopWatchdogSession := func() {
-		procMu.Lock()
-		cancel := watchdogCancel
-		watchdogCancel = nil
-		watchdogRestarting.Store(false)
-		procMu.Unlock()
-		if cancel != nil {
-			cancel()
-		}
-	}
-
-	var startMinerWithOrigin func(origin minerStartOrigin) error
-	var stopMinerWithOrigin func(origin minerStopOrigin)
-
-	waitForMinerExit := func(ctx context.Context, timeout time.Duration) bool {
-		deadline := time.Now().Add(timeout)
-		for {
-			procMu.Lock()
-			running := minerCmd != nil && minerCmd.Process != nil
-			procMu.Unlock()
-			if !running {
-				return true
-			}
-			if time.Now().After(deadline) {
-				return false
-			}
-			select {
-			case <-ctx.Done():
-				return false
-			case <-time.After(200 * time.Millisecond):
-			}
-		}
-	}
-
-	startWatchdogSession := func(settings watchdogSettings) {
-		procMu.Lock()
-		if watchdogCancel != nil {
-			procMu.Unlock()
-			return
-		}
-		ctx, cancel := context.WithCancel(context.Background())
-		watchdogCancel = cancel
-		procMu.Unlock()
-
-		appendMinerLog(fmt.Sprintf("[watchdog] Enabled (no-job %s, retry %s)\n",
-			settings.NoJobTimeout, settings.RetryWindow))
-
-		go func() {
-			ticker := time.NewTicker(2 * time.Second)
-			defer ticker.Stop()
-
-			var (
-				outageStart  time.Time
-				lastSeenJob  int64
-				restartCount int
-			)
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-ticker.C:
-				}
-
-				jobAt := lastJobAt.Load()
-				if jobAt != 0 && jobAt != lastSeenJob {
-					lastSeenJob = jobAt
-					outageStart = time.Time{}
-					restartCount = 0
-					continue
-				}
-
-				refAt := jobAt
-				if refAt == 0 {
-					refAt = minerStartedAt.Load()
-				}
-				if refAt == 0 {
-					refAt = time.Now().UnixNano()
-				}
-				elapsed := time.Since(time.Unix(0, refAt))
-				if elapsed <= settings.NoJobTimeout {
-					continue
-				}
-
-				if outageStart.IsZero() {
-					outageStart = time.Now()
-				}
-				if settings.RetryWindow > 0 && time.Since(outageStart) > settings.RetryWindow {
-					appendMinerLog(fmt.Sprintf("[watchdog] No jobs for %s (retry window reached). Stopping miner.\n", elapsed))
-					stopMinerWithOrigin(minerStopOriginUser)
-					return
-				}
-
-				if !watchdogRestarting.CompareAndSwap(false, true) {
-					continue
-				}
-				restartCount++
-				appendMinerLog(fmt.Sprintf("[watchdog] No jobs for %s. Restarting miner (attempt %d).\n", elapsed, restartCount))
-
-				stopMinerWithOrigin(minerStopOriginWatchdog)
-				_ = waitForMinerExit(ctx, 25*time.Second)
-
-				select {
-				case <-ctx.Done():
-					watchdogRestarting.Store(false)
-					return
-				case <-time.After(settings.RestartDelay):
-				}
-
-				minerStartedAt.Store(time.Now().UnixNano())
-				lastJobAt.Store(0)
-				currentJobBlock.Store(0)
-
-				if ctx.Err() != nil {
-					watchdogRestarting.Store(false)
-					return
+	events, _ := ctrl.Subscribe(1024)
+	go func() {
+		for ev := range events {
+			ev := ev
+			switch ev.Kind {
+			case eventMinerLog:
+				onMinerLogLine()
+			case eventMinerLogReset:
+				onMinerLogReset()
+			case eventNodeLog:
+				onNodeLogLine()
+			case eventNodeLogReset:
+				onNodeLogReset()
+			case eventNodeIssue:
+				fyne.Do(onNodeIssue)
+			case eventMinerState:
+				if ev.MinerState == minerStateStopped {
+					history.MarkStopped()
 				}
-
-				startErrCh := make(chan error, 1)
 				fyne.Do(func() {
-					if ctx.Err() != nil {
-						startErrCh <- ctx.Err()
-						return
+					setRunningUI(ev.MinerState)
+					if ev.MinerState == minerStateStarting {
+						threadsInUseValue.SetText(fmt.Sprintf("%d", configuredThreads(cfg)))
+					}
+				})
+			case eventNodeState:
+				fyne.Do(func() {
+					switch ev.NodeState {
+					case nodeStateStarting:
+						setNodeBadge("Node: Starting", connConnectingColor)
+						setNodeButtons(true)
+					case nodeStateRunning:
+						setNodeBadge("Node: Running", connLiveColor)
+					case nodeStateResetting:
+						setNodeBadge("Node: Resetting", connConnectingColor)
+						setNodeButtons(true)
+					default:
+						setNodeBadge("Node: Off", connOfflineColor)
+						setNodeButtons(false)
+					}
+					// StartNode clears the flag in the shared config once
+					// the chain data is wiped.
+					if cfg.NodeCleanStart && !ctrl.Snapshot().NodeCleanStart {
+						cfg.NodeCleanStart = false
+					}
+					if !cfg.NodeCleanStart && nodeCleanStartCheck.Checked {
+						nodeCleanStartCheck.SetChecked(false)
 					}
-					startErrCh <- startMinerWithOrigin(minerStartOriginWatchdog)
 				})
-				if err := <-startErrCh; err != nil {
-					appendMinerLog(fmt.Sprintf("[watchdog] Restart failed: %v\n", err))
+			case eventFoundBlock:
+				if err := ledger.Record(ev); err != nil {
+					appendMinerLog(fmt.Sprintf("[blocks] %v\n", err))
 				}
-				watchdogRestarting.Store(false)
+				fyne.Do(func() { lastFoundBlockValue.SetText(fmt.Sprintf("%d", ev.Block)) })
+			case eventStats:
+				st := ev.Stats
+				statCopy := st.Stat
+				statCopy.PerGPU_KHs = append([]int64(nil), st.PerGPU_KHs...)
+				statCopy.PerGPU_Power = append([]float64(nil), st.PerGPU_Power...)
+				statCopy.Temps = append([]int(nil), st.Temps...)
+				statCopy.Fans = append([]int(nil), st.Fans...)
+				lastStatMu.Lock()
+				lastStat = &statCopy
+				lastStatMu.Unlock()
+				history.Record(st)
+				fyne.Do(func() {
+					hashrateValue.Text = formatHashrate(st.Hashrate)
+					hashrateValue.Refresh()
+					if st.Threads > 0 {
+						threadsInUseValue.SetText(fmt.Sprintf("%d", st.Threads))
+					} else {
+						threadsInUseValue.SetText("—")
+					}
+					if history != nil {
+						if historyRange == historyRangeLabels[0] {
+							refreshHashrateHistory()
+						}
+					} else {
+						hashrateHistory.Add(st.Hashrate)
+						if avg, ok := hashrateHistory.Average(); ok {
+							avgHashrateValue.SetText(fmt.Sprintf("Avg %s", formatHashrate(avg)))
+						} else {
+							avgHashrateValue.SetText("Avg —")
+						}
+					}
+					sharesValue.SetText(fmt.Sprintf("Accepted %d | Rejected %d | Invalid %d", st.Accepted, st.Rejected, st.Invalid))
+					if st.NewAccept {
+						highlightShares()
+					}
+					poolValue.SetText(poolStatusText(st))
+					uptimeValue.SetText(fmt.Sprintf("%d min", st.UptimeMin))
+					if st.JobBlock > 0 {
+						currentBlockValue.SetText(fmt.Sprintf("%d", st.JobBlock))
+					} else {
+						currentBlockValue.SetText("—")
+					}
+					if strings.TrimSpace(st.Difficulty) != "" {
+						currentDifficultyValue.SetText(st.Difficulty)
+					} else {
+						currentDifficultyValue.SetText("—")
+					}
+					if st.LastFound > 0 {
+						lastFoundBlockValue.SetText(fmt.Sprintf("%d", st.LastFound))
+					} else {
+						lastFoundBlockValue.SetText("—")
+					}
+					updateStatsTable(statCopy)
+				})
 			}
-		}()
-	}
-
-	errMinerAlreadyRunning := errors.New("miner already running")
+		}
+	}()
 
-	stopMinerWithOrigin = func(origin minerStopOrigin) {
-		if origin == minerStopOriginUser {
-			stopWatchdogSession()
+	startMinerUser := func() {
+		if err := saveFromUI(); err != nil {
+			dialog.ShowError(err, w)
+			return
 		}
-		procMu.Lock()
-		defer procMu.Unlock()
-		if minerCmd == nil || minerCmd.Process == nil {
+		err := ctrl.StartMiner(minerStartOriginUser)
+		if err == nil {
 			return
 		}
-		appendMinerLog("\nStopping miner...\n")
-		cmd := minerCmd
-		proc := minerCmd.Process
-		if err := sendProcessInterrupt(proc); err != nil {
-			appendMinerLog(fmt.Sprintf("[miner] interrupt failed: %v\n", err))
+		if errors.Is(err, errMinerAlreadyRunning) {
+			dialog.ShowInformation(appName, "Miner already running", w)
+			return
 		}
-		go func(cmd *exec.Cmd, p *os.Process) {
-			time.Sleep(10 * time.Second)
-			procMu.Lock()
-			still := minerCmd == cmd
-			procMu.Unlock()
-			if still {
-				appendMinerLog("[miner] Force-killing miner (timeout)\n")
-				_ = p.Kill()
-			}
-		}(cmd, proc)
+		dialog.ShowError(err, w)
 	}
 
-	startMinerWithOrigin = func(origin minerStartOrigin) error {
-		if xmrigErr != nil {
-			return fmt.Errorf("xmrig not found: %w", xmrigErr)
-		}
-		if origin == minerStartOriginUser {
-			if err := saveFromUI(); err != nil {
-				return err
-			}
-		}
+	stopMinerUser := func() {
+		ctrl.StopMiner(minerStopOriginUser)
+	}
 
-		procMu.Lock()
-		if minerCmd != nil && minerCmd.Process != nil {
-			procMu.Unlock()
-			return errMinerAlreadyRunning
+	togglePauseUser := func() {
+		if !ctrl.MinerPaused() && !slices.Contains(ctrl.PauseReasons(), limitSourceUser) {
+			if err := ctrl.PauseMiner(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			return
 		}
-
-		port, err := pickFreePort()
-		if err != nil {
-			procMu.Unlock()
-			return err
+		if err := ctrl.ResumeMiner(); err != nil {
+			dialog.ShowError(err, w)
+			return
 		}
-		apiPort = port
-
-		poolURL, err := buildPoolURL(cfg)
-		if err != nil {
-			procMu.Unlock()
-			return err
+		if reasons := ctrl.PauseReasons(); len(reasons) > 0 {
+			dialog.ShowInformation(appName, "Mining stays paused by: "+strings.Join(reasons, ", ")+".\nIt resumes when that condition clears.", w)
 		}
+	}
 
-		if cfg.Mode == modeRPCLocal {
-			nodeRunning := nodeCmd != nil && nodeCmd.Process != nil
-			runningMode := nodeRunMode
-			if cfg.NodeEnabled {
-				if !nodeRunning {
-					procMu.Unlock()
-					return errors.New("node is enabled but not running; start it in Setup → Node")
-				}
-				if runningMode != nodeModeMine {
-					procMu.Unlock()
-					return errors.New("node is running without mining service enabled; restart the node with mining service enabled")
-				}
-			}
-
-			u, err := url.Parse(poolURL)
-			if err != nil || u.Host == "" {
-				procMu.Unlock()
-				return errors.New("invalid RPC URL")
-			}
-			host := u.Host
-			if !strings.Contains(host, ":") {
-				if strings.Contains(strings.ToLower(u.Scheme), "https") {
-					host += ":443"
-				} else {
-					host += ":80"
-				}
-			}
-			conn, err := net.DialTimeout("tcp", host, 750*time.Millisecond)
-			if err != nil {
-				procMu.Unlock()
-				return fmt.Errorf("RPC is not reachable at %s", host)
-			}
-			_ = conn.Close()
+	restartMinerUser := func() {
+		if err := ctrl.RestartMiner(); err != nil {
+			dialog.ShowError(err, w)
 		}
+	}
 
-		resetMinerLog()
-
-		args := []string{
-			"--no-color",
-			"-o", poolURL,
-			"--coin", "OLIVO",
-			"--http-host", "127.0.0.1",
-			"--http-port", strconv.Itoa(apiPort),
-			"--donate-level", strconv.Itoa(cfg.DonateLevel),
-		}
-		if cfg.Mode == modeStratum {
-			user := cfg.WalletAddress
-			if cfg.WorkerName != "" {
-				user = user + "." + cfg.WorkerName
-			}
-			args = append(args, "-u", user, "-p", "x")
-		} else if cfg.Mode == modeRPCGateway {
-			args = append(args, "-u", cfg.WalletAddress)
-		}
-		if cfg.Mode != modeStratum {
-			args = append(args, "--daemon")
+	applyToMinerUser := func() {
+		if err := saveFromUI(); err != nil {
+			dialog.ShowError(err, w)
+			return
 		}
-		if cfg.DisplayInterval > 0 {
-			args = append(args, "--print-time", strconv.Itoa(cfg.DisplayInterval))
+		pending, err := ctrl.ApplyConfig()
+		refreshPendingRestart()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
 		}
-		if cfg.CPUThreads > 0 {
-			args = append(args, "-t", strconv.Itoa(cfg.CPUThreads))
+		if len(pending) == 0 {
+			return
 		}
-		if len(cfg.CPUAffinity) > 0 {
-			mask, ok := affinityMask(cfg.CPUAffinity)
+		dialog.ShowConfirm("Restart required", strings.Join(pending, ", ")+" only take effect after xmrig restarts.\nRestart the miner now?", func(ok bool) {
 			if ok {
-				args = append(args, "--cpu-affinity", mask, "-t", strconv.Itoa(len(cfg.CPUAffinity)))
-			} else {
-				appendMinerLog("[cpu] Affinity contains CPU index >= 64, skipping affinity mask.\n")
-				args = append(args, "-t", strconv.Itoa(len(cfg.CPUAffinity)))
-			}
-		}
-		if !cfg.UseHugePages {
-			args = append(args, "--no-huge-pages")
-		}
-		if !cfg.EnableMSR {
-			args = append(args, "--randomx-wrmsr=-1")
-		}
-
-		runXMRigPath := xmrigPath
-		if runtime.GOOS == "linux" {
-			p, err := prepareXMRigBinary(xmrigPath)
-			if err != nil {
-				procMu.Unlock()
-				return err
-			}
-			runXMRigPath = p
-			if cfg.EnableMSR && cfg.AutoGrantMSR {
-				if err := ensureLinuxMSRAccess(runXMRigPath); err != nil {
-					appendMinerLog(fmt.Sprintf("[msr] Auto grant failed: %v\n", err))
-				}
-			}
-			if cfg.EnableMSR {
-				if ok, err := hasLinuxMSRCaps(runXMRigPath); err == nil {
-					if ok {
-						appendMinerLog("[msr] CAP_SYS_RAWIO and CAP_DAC_OVERRIDE detected on xmrig binary.\n")
-					} else {
-						appendMinerLog("[msr] Required Linux capabilities missing (CAP_SYS_RAWIO + CAP_DAC_OVERRIDE); MSR tweak may fail.\n")
-					}
-				}
-			}
-		}
-
-		setMinerDeviceMap(cfg.CPUAffinity)
-
-		minerStartedAt.Store(time.Now().UnixNano())
-		lastJobAt.Store(0)
-		currentJobBlock.Store(0)
-		lastFoundBlock.Store(0)
-		jobDifficulty.Store("")
-
-		minerCtx, minerCancel = context.WithCancel(context.Background())
-		cmd := exec.CommandContext(minerCtx, runXMRigPath, args...)
-		configureChildProcess(cmd)
-		cmd.Env = append(os.Environ(), "LC_ALL=C")
-
-		stdout, _ := cmd.StdoutPipe()
-		stderr, _ := cmd.StderrPipe()
-
-		appendMinerLog(fmt.Sprintf("Starting: %s %s\n\n", runXMRigPath, strings.Join(args, " ")))
-
-		if err := cmd.Start(); err != nil {
-			minerCancel()
-			minerCtx = nil
-			minerCancel = nil
-			procMu.Unlock()
-			return err
-		}
-		minerCmd = cmd
-		waitingForStats.Store(true)
-		lastAccepted.Store(0)
-
-		pollCtx, pollCancelFn := context.WithCancel(context.Background())
-		pollCancel = pollCancelFn
-		procMu.Unlock()
-
-		setRunningUI(true)
-		if len(cfg.CPUAffinity) > 0 {
-			threadsInUseValue.SetText(fmt.Sprintf("%d", len(cfg.CPUAffinity)))
-		} else if cfg.CPUThreads > 0 {
-			threadsInUseValue.SetText(fmt.Sprintf("%d", cfg.CPUThreads))
-		} else {
-			threadsInUseValue.SetText(fmt.Sprintf("%d", runtime.NumCPU()))
-		}
-
-		if origin == minerStartOriginUser && cfg.WatchdogEnabled {
-			startWatchdogSession(watchdogSettings{
-				NoJobTimeout: time.Duration(cfg.WatchdogNoJobTimeoutSec) * time.Second,
-				RestartDelay: time.Duration(cfg.WatchdogRestartDelaySec) * time.Second,
-				RetryWindow:  time.Duration(cfg.WatchdogRetryWindowMin) * time.Minute,
-			})
-		}
-
-		go streamLines(stdout, appendMinerLog)
-		go streamLines(stderr, appendMinerLog)
-
-		go pollStats(pollCtx, "127.0.0.1", apiPort, true, func(s Stat) {
-			if deviceMap := getMinerDeviceMap(); len(deviceMap) > 0 {
-				maxSelected := -1
-				identity := true
-				for i, idx := range deviceMap {
-					if idx > maxSelected {
-						maxSelected = idx
-					}
-					if idx != i {
-						identity = false
-					}
-				}
-				maxLen := len(s.PerGPU_KHs)
-				if len(s.Temps) > maxLen {
-					maxLen = len(s.Temps)
-				}
-				if len(s.Fans) > maxLen {
-					maxLen = len(s.Fans)
-				}
-				if len(s.PerGPU_Power) > maxLen {
-					maxLen = len(s.PerGPU_Power)
-				}
-
-				needRemap := false
-				if maxSelected >= 0 && maxLen > 0 {
-					needRemap = maxLen < maxSelected+1 || (!identity && maxLen <= len(deviceMap))
-				}
-				if needRemap {
-					outLen := maxSelected + 1
-					hashes := make([]int64, outLen)
-					temps := make([]int, outLen)
-					fans := make([]int, outLen)
-					power := make([]float64, outLen)
-					for i := range power {
-						power[i] = -1
-					}
-					for localIdx, deviceIdx := range deviceMap {
-						if deviceIdx < 0 || deviceIdx >= outLen {
-							continue
-						}
-						if localIdx >= 0 && localIdx < len(s.PerGPU_KHs) {
-							hashes[deviceIdx] = s.PerGPU_KHs[localIdx]
-						}
-						if localIdx >= 0 && localIdx < len(s.Temps) {
-							temps[deviceIdx] = s.Temps[localIdx]
-						}
-						if localIdx >= 0 && localIdx < len(s.Fans) {
-							fans[deviceIdx] = s.Fans[localIdx]
-						}
-						if localIdx >= 0 && localIdx < len(s.PerGPU_Power) {
-							power[deviceIdx] = s.PerGPU_Power[localIdx]
-						}
-					}
-					s.PerGPU_KHs = hashes
-					s.Temps = temps
-					s.Fans = fans
-					s.PerGPU_Power = power
-				}
-			}
-			firstStat := waitingForStats.Swap(false)
-			prevAccepted := lastAccepted.Swap(s.Accepted)
-			hasNewAccept := s.Accepted > prevAccepted
-			if s.Difficulty > 0 {
-				jobDifficulty.Store(formatDifficulty(s.Difficulty))
-			}
-			updateLastFoundFromAccept := cfg.Mode != modeRPCLocal
-			if hasNewAccept && updateLastFoundFromAccept {
-				if block := currentJobBlock.Load(); block > 0 {
-					lastFoundBlock.Store(block)
-				}
-			}
-			statCopy := s
-			statCopy.PerGPU_KHs = append([]int64(nil), s.PerGPU_KHs...)
-			statCopy.PerGPU_Power = append([]float64(nil), s.PerGPU_Power...)
-			statCopy.Temps = append([]int(nil), s.Temps...)
-			statCopy.Fans = append([]int(nil), s.Fans...)
-			lastStatMu.Lock()
-			lastStat = &statCopy
-			lastStatMu.Unlock()
-			totalHashrate := s.TotalHashrate
-			if totalHashrate <= 0 {
-				totalHashrate = float64(s.TotalKHs)
-			}
-			if totalHashrate <= 0 {
-				for _, v := range s.PerGPU_KHs {
-					if v > 0 {
-						totalHashrate += float64(v)
-					}
-				}
-			}
-			threadCount := s.ActiveThreads
-			if threadCount <= 0 {
-				if len(cfg.CPUAffinity) > 0 {
-					threadCount = len(cfg.CPUAffinity)
-				} else if cfg.CPUThreads > 0 {
-					threadCount = cfg.CPUThreads
-				} else if len(s.PerGPU_KHs) > 0 {
-					threadCount = len(s.PerGPU_KHs)
-				}
-			}
-			fyne.Do(func() {
-				if firstStat {
-					setStatusText("Running")
-					setConnectionBadge("Conn: Live", connLiveColor)
-				}
-				hashrateValue.Text = formatHashrate(totalHashrate)
-				hashrateValue.Refresh()
-				hashrateHistory.Add(totalHashrate)
-				if threadCount > 0 {
-					threadsInUseValue.SetText(fmt.Sprintf("%d", threadCount))
-				} else {
-					threadsInUseValue.SetText("—")
-				}
-				if avg, ok := hashrateHistory.Average(); ok {
-					avgHashrateValue.SetText(fmt.Sprintf("Avg %s", formatHashrate(avg)))
-				} else {
-					avgHashrateValue.SetText("Avg —")
-				}
-				sharesValue.SetText(fmt.Sprintf("Accepted %d | Rejected %d | Invalid %d", s.Accepted, s.Rejected, s.Invalid))
-				if hasNewAccept {
-					highlightShares()
-				}
-				poolValue.SetText(s.Pool)
-				uptimeValue.SetText(fmt.Sprintf("%d min", s.UptimeMin))
-				if block := currentJobBlock.Load(); block > 0 {
-					currentBlockValue.SetText(fmt.Sprintf("%d", block))
-				} else {
-					currentBlockValue.SetText("—")
-				}
-				if diff, ok := jobDifficulty.Load().(string); ok && strings.TrimSpace(diff) != "" {
-					currentDifficultyValue.SetText(diff)
-				} else {
-					currentDifficultyValue.SetText("—")
-				}
-				if block := lastFoundBlock.Load(); block > 0 {
-					lastFoundBlockValue.SetText(fmt.Sprintf("%d", block))
-				} else {
-					lastFoundBlockValue.SetText("—")
-				}
-				updateStatsTable(statCopy)
-			})
-		}, func(err error) {
-			if waitingForStats.Load() {
-				msg := strings.ToLower(err.Error())
-				if strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host") {
-					return
-				}
-			}
-			appendMinerLog(fmt.Sprintf("[api] %v\n", err))
-		})
-
-		go func() {
-			err := cmd.Wait()
-			procMu.Lock()
-			minerCmd = nil
-			setMinerDeviceMap(nil)
-			if pollCancel != nil {
-				pollCancel()
-				pollCancel = nil
-			}
-			if minerCancel != nil {
-				minerCancel()
-				minerCancel = nil
-			}
-			procMu.Unlock()
-
-			fyne.Do(func() { setRunningUI(false) })
-			if err != nil && !errors.Is(err, context.Canceled) {
-				appendMinerLog(fmt.Sprintf("\n[exit] %v\n", err))
-			} else {
-				appendMinerLog("\n[exit] miner stopped\n")
+				restartMinerUser()
 			}
-		}()
-		return nil
-	}
-
-	startMinerUser := func() {
-		err := startMinerWithOrigin(minerStartOriginUser)
-		if err == nil {
-			return
-		}
-		if errors.Is(err, errMinerAlreadyRunning) {
-			dialog.ShowInformation(appName, "Miner already running", w)
-			return
-		}
-		dialog.ShowError(err, w)
-	}
-
-	stopMinerUser := func() {
-		stopMinerWithOrigin(minerStopOriginUser)
+		}, w)
 	}
 
 	nodeStartBtn = widget.NewButtonWithIcon("Start node", theme.MediaPlayIcon(), func() {
@@ -2544,6 +2280,8 @@ func main() {
 	startBtn.Importance = widget.HighImportance
 	stopBtn = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), stopMinerUser)
 	stopBtn.Importance = widget.DangerImportance
+	pauseBtn = widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), togglePauseUser)
+	pauseBtn.Disable()
 
 	if xmrigErr != nil {
 		startBtn.Disable()
@@ -2567,6 +2305,154 @@ func main() {
 	)
 	connectionPanel := panel("Connection", connectionBody)
 
+	poolsBox := container.NewVBox()
+	var rebuildPoolRows func()
+	showPoolEditor := func(index int) {
+		p := PoolConfig{Mode: modeStratum, Port: defaultStratumPort}
+		if index >= 0 && index < len(backupPools) {
+			p = backupPools[index]
+		}
+		editMode := widget.NewSelect(modeLabels, nil)
+		if label, ok := modeLabelForKey[p.Mode]; ok {
+			editMode.SetSelected(label)
+		} else {
+			editMode.SetSelected(modeLabels[0])
+		}
+		editHost := widget.NewEntry()
+		editHost.SetText(p.Host)
+		editHost.SetPlaceHolder("backup.example.org")
+		editPort := widget.NewEntry()
+		if p.Port > 0 {
+			editPort.SetText(strconv.Itoa(p.Port))
+		}
+		editPort.SetPlaceHolder(strconv.Itoa(defaultStratumPort))
+		editRPC := widget.NewEntry()
+		editRPC.SetText(p.RPCURL)
+		editRPC.SetPlaceHolder(defaultRPCURL)
+		editWallet := widget.NewEntry()
+		editWallet.SetText(displayAddress(p.WalletAddress))
+		editWallet.SetPlaceHolder("0x...")
+		editWorker := widget.NewEntry()
+		editWorker.SetText(p.WorkerName)
+		editWorker.SetPlaceHolder("optional (e.g. rig1)")
+		editEnabled := widget.NewCheck("Enabled", nil)
+		editEnabled.SetChecked(!p.Disabled)
+
+		items := []*widget.FormItem{
+			widget.NewFormItem("Mode", editMode),
+			widget.NewFormItem("Host", editHost),
+			widget.NewFormItem("Port", editPort),
+			widget.NewFormItem("RPC URL", editRPC),
+			widget.NewFormItem("Wallet", addressField(editWallet, addressBook)),
+			widget.NewFormItem("Worker", editWorker),
+			widget.NewFormItem("", editEnabled),
+		}
+		title := "Add backup pool"
+		if index >= 0 {
+			title = "Edit backup pool"
+		}
+		d := dialog.NewForm(title, "Save", "Cancel", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			next := PoolConfig{
+				Mode:          modeKeyForLabel[editMode.Selected],
+				Host:          editHost.Text,
+				RPCURL:        editRPC.Text,
+				WalletAddress: editWallet.Text,
+				WorkerName:    editWorker.Text,
+				Disabled:      !editEnabled.Checked,
+			}
+			if port, err := strconv.Atoi(strings.TrimSpace(editPort.Text)); err == nil {
+				next.Port = port
+			}
+			normalizePool(&next)
+			if err := validatePool(next); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if index >= 0 && index < len(backupPools) {
+				backupPools[index] = next
+			} else {
+				backupPools = append(backupPools, next)
+			}
+			rebuildPoolRows()
+		}, w)
+		d.Resize(fyne.NewSize(520, d.MinSize().Height))
+		d.Show()
+	}
+	rebuildPoolRows = func() {
+		rows := make([]fyne.CanvasObject, 0, len(backupPools))
+		if len(backupPools) == 0 {
+			empty := widget.NewLabel("No backup pools; xmrig only uses the connection above.")
+			empty.Wrapping = fyne.TextWrapWord
+			empty.TextStyle = fyne.TextStyle{Italic: true}
+			rows = append(rows, empty)
+		}
+		for i, p := range backupPools {
+			label := widget.NewLabel(fmt.Sprintf("%d. %s", i+1, describePool(p)))
+			label.Truncation = fyne.TextTruncateEllipsis
+			upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				backupPools[i-1], backupPools[i] = backupPools[i], backupPools[i-1]
+				rebuildPoolRows()
+			})
+			if i == 0 {
+				upBtn.Disable()
+			}
+			downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+				backupPools[i+1], backupPools[i] = backupPools[i], backupPools[i+1]
+				rebuildPoolRows()
+			})
+			if i == len(backupPools)-1 {
+				downBtn.Disable()
+			}
+			editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				showPoolEditor(i)
+			})
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				backupPools = append(backupPools[:i:i], backupPools[i+1:]...)
+				rebuildPoolRows()
+			})
+			rows = append(rows, container.NewBorder(nil, nil, nil, container.NewHBox(upBtn, downBtn, editBtn, removeBtn), label))
+		}
+		poolsBox.Objects = rows
+		poolsBox.Refresh()
+	}
+	rebuildPoolRows()
+
+	addPoolBtn := widget.NewButtonWithIcon("Add backup pool", theme.ContentAddIcon(), func() {
+		if len(backupPools) >= maxBackupPools {
+			dialog.ShowError(fmt.Errorf("too many backup pools (max %d)", maxBackupPools), w)
+			return
+		}
+		showPoolEditor(-1)
+	})
+	poolsHint := widget.NewLabel("xmrig moves to the next pool when the active one stops responding. Changes apply on next miner start.")
+	poolsHint.Wrapping = fyne.TextWrapWord
+	poolsHint.TextStyle = fyne.TextStyle{Italic: true}
+	poolFailbackFields := container.NewGridWithColumns(2,
+		fieldLabel("Healthy for (min)"), poolFailbackEntry,
+	)
+	if !poolFailbackCheck.Checked {
+		poolFailbackFields.Hide()
+	}
+	poolFailbackCheck.OnChanged = func(enabled bool) {
+		if enabled {
+			poolFailbackFields.Show()
+		} else {
+			poolFailbackFields.Hide()
+		}
+	}
+	poolsBody := container.NewVBox(
+		poolsBox,
+		container.NewHBox(layout.NewSpacer(), addPoolBtn),
+		poolsHint,
+		widget.NewSeparator(),
+		poolFailbackCheck,
+		poolFailbackFields,
+	)
+	poolsPanel := panel("Backup pools", poolsBody)
+
 	nodeDataDirBrowseBtn := widget.NewButtonWithIcon("Browse", theme.FolderOpenIcon(), func() {
 		dialog.ShowFolderOpen(func(listable fyne.ListableURI, err error) {
 			if err != nil {
@@ -2590,6 +2476,8 @@ func main() {
 	nodeAdvancedBody := container.NewVBox(
 		nodePortsGrid,
 		formRow("Bootnodes", nodeBootnodesEntry),
+		formRow("Static peers", nodeStaticPeersEntry),
+		formRow("Trusted peers", nodeTrustedPeersEntry),
 	)
 	nodeAdvanced := widget.NewAccordion(widget.NewAccordionItem("Advanced", nodeAdvancedBody))
 	nodeAdvanced.CloseAll()
@@ -2661,7 +2549,7 @@ func main() {
 	nodeHint.Wrapping = fyne.TextWrapWord
 	nodeHint.TextStyle = fyne.TextStyle{Italic: true}
 
-	nodeEtherbaseRow := formRow("Mining address", nodeEtherbaseEntry)
+	nodeEtherbaseRow := formRow("Mining address", addressField(nodeEtherbaseEntry, addressBook))
 	nodeEtherbaseHint := widget.NewLabel("Used as --miner.etherbase when the mining service is enabled. Leave empty to reuse Wallet from Connection.")
 	nodeEtherbaseHint.Wrapping = fyne.TextWrapWord
 	nodeEtherbaseHint.TextStyle = fyne.TextStyle{Italic: true}
@@ -2714,10 +2602,7 @@ func main() {
 		} else {
 			nodeSettingsBox.Hide()
 		}
-		procMu.Lock()
-		running := nodeCmd != nil && nodeCmd.Process != nil
-		procMu.Unlock()
-		setNodeButtons(running)
+		setNodeButtons(ctrl.NodeRunning())
 		applyModeUI()
 	}
 
@@ -2727,39 +2612,955 @@ func main() {
 	)
 	nodePanel := panel("Node", nodeBody)
 
+	accountsCfg := func() *Config {
+		c := *cfg
+		c.NodeDataDir = strings.TrimSpace(nodeDataDirEntry.Text)
+		return &c
+	}
+	accountsBox := container.NewVBox()
+	accountsStatus := widget.NewLabel("")
+	accountsStatus.Wrapping = fyne.TextWrapWord
+	accountsStatus.TextStyle = fyne.TextStyle{Italic: true}
+	refreshAccounts := func() {
+		dir, err := nodeKeystoreDir(accountsCfg())
+		var accounts []keystoreAccount
+		if err == nil {
+			accounts, err = listKeystoreAccounts(dir)
+		}
+		rows := make([]fyne.CanvasObject, 0, len(accounts))
+		switch {
+		case err != nil:
+			empty := widget.NewLabel(fmt.Sprintf("Keystore unavailable: %v", err))
+			empty.Wrapping = fyne.TextWrapWord
+			rows = append(rows, empty)
+		case len(accounts) == 0:
+			empty := widget.NewLabel("No accounts in " + redactPath(dir) + ".")
+			empty.TextStyle = fyne.TextStyle{Italic: true}
+			empty.Wrapping = fyne.TextWrapWord
+			rows = append(rows, empty)
+		}
+		for _, acc := range accounts {
+			label := widget.NewLabelWithStyle(displayAddress(acc.Address), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			label.Truncation = fyne.TextTruncateEllipsis
+			nodeBtn := widget.NewButton("Node address", func() {
+				nodeEtherbaseEntry.SetText(displayAddress(acc.Address))
+				accountsStatus.SetText(fmt.Sprintf("%s set as node mining address; save to keep it.", acc.Address))
+			})
+			walletBtn := widget.NewButton("Wallet", func() {
+				walletEntry.SetText(displayAddress(acc.Address))
+				accountsStatus.SetText(fmt.Sprintf("%s set as wallet address; save to keep it.", acc.Address))
+			})
+			exportBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+				save := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+					if err != nil {
+						dialog.ShowError(err, w)
+						return
+					}
+					if wc == nil {
+						return
+					}
+					err = exportKeystoreFile(acc.File, wc)
+					if closeErr := wc.Close(); err == nil {
+						err = closeErr
+					}
+					if err != nil {
+						dialog.ShowError(fmt.Errorf("failed to export key file: %w", err), w)
+						return
+					}
+					accountsStatus.SetText(fmt.Sprintf("Exported %s. The backup is encrypted with the account password.", acc.Address))
+				}, w)
+				save.SetFileName(filepath.Base(acc.File))
+				save.Show()
+			})
+			rows = append(rows, container.NewBorder(nil, nil, nil, container.NewHBox(nodeBtn, walletBtn, exportBtn), label))
+		}
+		accountsBox.Objects = rows
+		accountsBox.Refresh()
+	}
+	runAccountAction := func(action string, run func(*Config) (string, error)) {
+		accountsStatus.SetText(action + "…")
+		c := accountsCfg()
+		go func() {
+			addr, err := run(c)
+			fyne.Do(func() {
+				refreshAccounts()
+				if err != nil {
+					accountsStatus.SetText("")
+					dialog.ShowError(err, w)
+					return
+				}
+				accountsStatus.SetText(fmt.Sprintf("Added %s.", addr))
+			})
+		}()
+	}
+	showAccountForm := func(title string, withKey bool) {
+		keyEntry := widget.NewPasswordEntry()
+		keyEntry.SetPlaceHolder("64 hex chars")
+		passwordEntry := widget.NewPasswordEntry()
+		confirmEntry := widget.NewPasswordEntry()
+		var items []*widget.FormItem
+		if withKey {
+			items = append(items, widget.NewFormItem("Private key", keyEntry))
+		}
+		items = append(items,
+			widget.NewFormItem("Password", passwordEntry),
+			widget.NewFormItem("Confirm password", confirmEntry),
+		)
+		confirm := "Create"
+		if withKey {
+			confirm = "Import"
+		}
+		d := dialog.NewForm(title, confirm, "Cancel", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := validateAccountPassword(passwordEntry.Text, confirmEntry.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			password := passwordEntry.Text
+			if withKey {
+				key := keyEntry.Text
+				runAccountAction("Importing key", func(c *Config) (string, error) { return importNodeAccount(c, key, password) })
+			} else {
+				runAccountAction("Creating account", func(c *Config) (string, error) { return createNodeAccount(c, password) })
+			}
+		}, w)
+		d.Resize(fyne.NewSize(520, d.MinSize().Height))
+		d.Show()
+	}
+	accountNewBtn := widget.NewButtonWithIcon("New account", theme.ContentAddIcon(), func() {
+		showAccountForm("New account", false)
+	})
+	accountImportBtn := widget.NewButtonWithIcon("Import key", theme.UploadIcon(), func() {
+		showAccountForm("Import private key", true)
+	})
+	accountsRefreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), refreshAccounts)
+	accountsHint := widget.NewLabel("Key files in the keystore folder of the node data directory, encrypted with their password. A resync keeps them. Keep the password and an exported backup safe: without them the funds are lost.")
+	accountsHint.Wrapping = fyne.TextWrapWord
+	accountsHint.TextStyle = fyne.TextStyle{Italic: true}
+	refreshAccounts()
+	accountsPanel := panel("Accounts", container.NewVBox(
+		accountsBox,
+		container.NewHBox(layout.NewSpacer(), accountsRefreshBtn, accountImportBtn, accountNewBtn),
+		accountsStatus,
+		accountsHint,
+	))
+
+	addressBookBox := container.NewVBox()
+	var rebuildAddressBookRows func()
+	saveAddressBook := func(book []AddressBookEntry) error {
+		next := *cfg
+		next.AddressBook = book
+		normalizeAddressBook(next.AddressBook)
+		if err := validateAddressBook(next.AddressBook); err != nil {
+			return err
+		}
+		cfg.AddressBook = next.AddressBook
+		if err := ctrl.Replace(cfg); err != nil {
+			return err
+		}
+		rebuildAddressBookRows()
+		return nil
+	}
+	showAddressBookEditor := func(index int) {
+		var entry AddressBookEntry
+		if index >= 0 && index < len(cfg.AddressBook) {
+			entry = cfg.AddressBook[index]
+		}
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(entry.Name)
+		nameEntry.SetPlaceHolder("e.g. Cold wallet")
+		addrEntry := widget.NewEntry()
+		addrEntry.SetText(entry.Address)
+		addrEntry.SetPlaceHolder("0x...")
+		title := "Add address"
+		if index >= 0 {
+			title = "Edit address"
+		}
+		d := dialog.NewForm(title, "Save", "Cancel", []*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("Address", addressField(addrEntry, addressBook)),
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			book := append([]AddressBookEntry(nil), cfg.AddressBook...)
+			entry := AddressBookEntry{Name: nameEntry.Text, Address: addrEntry.Text}
+			if index >= 0 && index < len(book) {
+				book[index] = entry
+			} else {
+				book = append(book, entry)
+			}
+			if err := saveAddressBook(book); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}, w)
+		d.Resize(fyne.NewSize(520, d.MinSize().Height))
+		d.Show()
+	}
+	rebuildAddressBookRows = func() {
+		rows := make([]fyne.CanvasObject, 0, len(cfg.AddressBook))
+		if len(cfg.AddressBook) == 0 {
+			empty := widget.NewLabel("No saved addresses.")
+			empty.TextStyle = fyne.TextStyle{Italic: true}
+			rows = append(rows, empty)
+		}
+		for i, e := range cfg.AddressBook {
+			name := widget.NewLabelWithStyle(e.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			addr := widget.NewLabelWithStyle(e.Address, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			addr.Truncation = fyne.TextTruncateEllipsis
+			editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				showAddressBookEditor(i)
+			})
+			deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				book := append([]AddressBookEntry(nil), cfg.AddressBook[:i]...)
+				book = append(book, cfg.AddressBook[i+1:]...)
+				if err := saveAddressBook(book); err != nil {
+					dialog.ShowError(err, w)
+				}
+			})
+			rows = append(rows, container.NewBorder(nil, nil, name, container.NewHBox(editBtn, deleteBtn), addr))
+		}
+		addressBookBox.Objects = rows
+		addressBookBox.Refresh()
+	}
+	rebuildAddressBookRows()
+	addressBookAddBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		showAddressBookEditor(-1)
+	})
+	addressBookHint := widget.NewLabel("Named addresses for the wallet, node mining address and pool wallet fields; pick them with the button next to each field. Mixed-case addresses are checked against their EIP-55 checksum to catch typos. Changes here are saved right away.")
+	addressBookHint.Wrapping = fyne.TextWrapWord
+	addressBookHint.TextStyle = fyne.TextStyle{Italic: true}
+	addressBookPanel := panel("Address book", container.NewVBox(
+		addressBookBox,
+		container.NewHBox(layout.NewSpacer(), addressBookAddBtn),
+		addressBookHint,
+	))
+
 	watchdogBody := container.NewVBox(
 		watchdogEnabledCheck,
 		watchdogFields,
 	)
 	watchdogPanel := panel("Watchdog", watchdogBody)
 
-	hardwareGrid := container.NewGridWithColumns(2,
-		fieldLabel("CPU threads"), threadsEntry,
-		fieldLabel("Display interval (s)"), displayIntervalEntry,
-		fieldLabel("Donate level"), donateEntry,
-		widget.NewLabel(""), hugePagesCheck,
-		widget.NewLabel(""), msrCheck,
-		widget.NewLabel(""), autoMSRCheck,
+	scheduleBox := container.NewVBox()
+	var rebuildScheduleRows func()
+	dayLabels := []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	showScheduleEditor := func(index int) {
+		win := ScheduleWindow{Start: "22:00", End: "06:00"}
+		if index >= 0 && index < len(scheduleWindows) {
+			win = scheduleWindows[index]
+		}
+		daysGroup := widget.NewCheckGroup(dayLabels, nil)
+		daysGroup.Horizontal = true
+		var checkedDays []string
+		for _, label := range dayLabels {
+			if len(win.Days) == 0 || slices.Contains(win.Days, strings.ToLower(label)) {
+				checkedDays = append(checkedDays, label)
+			}
+		}
+		daysGroup.SetSelected(checkedDays)
+		startEntry := widget.NewEntry()
+		startEntry.SetText(win.Start)
+		startEntry.SetPlaceHolder("22:00")
+		endEntry := widget.NewEntry()
+		endEntry.SetText(win.End)
+		endEntry.SetPlaceHolder("06:00")
+		threadsEntry := widget.NewEntry()
+		if win.Threads > 0 {
+			threadsEntry.SetText(strconv.Itoa(win.Threads))
+		}
+		threadsEntry.SetPlaceHolder("Hardware setting")
+
+		items := []*widget.FormItem{
+			widget.NewFormItem("Days", daysGroup),
+			widget.NewFormItem("Start", startEntry),
+			widget.NewFormItem("End", endEntry),
+			widget.NewFormItem("Threads", threadsEntry),
+		}
+		title := "Add window"
+		if index >= 0 {
+			title = "Edit window"
+		}
+		d := dialog.NewForm(title, "Save", "Cancel", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			next := ScheduleWindow{Start: startEntry.Text, End: endEntry.Text}
+			if len(daysGroup.Selected) == 0 {
+				dialog.ShowError(errors.New("select at least one day"), w)
+				return
+			}
+			if len(daysGroup.Selected) < len(dayLabels) {
+				for _, label := range dayLabels {
+					if slices.Contains(daysGroup.Selected, label) {
+						next.Days = append(next.Days, strings.ToLower(label))
+					}
+				}
+			}
+			if text := strings.TrimSpace(threadsEntry.Text); text != "" {
+				n, err := strconv.Atoi(text)
+				if err != nil {
+					dialog.ShowError(errors.New("invalid window threads (0..4096)"), w)
+					return
+				}
+				next.Threads = n
+			}
+			normalizeScheduleWindow(&next)
+			if err := validateScheduleWindow(next); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if index >= 0 && index < len(scheduleWindows) {
+				scheduleWindows[index] = next
+			} else {
+				scheduleWindows = append(scheduleWindows, next)
+			}
+			rebuildScheduleRows()
+		}, w)
+		d.Resize(fyne.NewSize(520, d.MinSize().Height))
+		d.Show()
+	}
+	rebuildScheduleRows = func() {
+		rows := make([]fyne.CanvasObject, 0, len(scheduleWindows))
+		if len(scheduleWindows) == 0 {
+			empty := widget.NewLabel("No windows yet.")
+			empty.TextStyle = fyne.TextStyle{Italic: true}
+			rows = append(rows, empty)
+		}
+		for i, win := range scheduleWindows {
+			label := widget.NewLabel(describeScheduleWindow(win))
+			label.Truncation = fyne.TextTruncateEllipsis
+			editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				showScheduleEditor(i)
+			})
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				scheduleWindows = append(scheduleWindows[:i:i], scheduleWindows[i+1:]...)
+				rebuildScheduleRows()
+			})
+			rows = append(rows, container.NewBorder(nil, nil, nil, container.NewHBox(editBtn, removeBtn), label))
+		}
+		scheduleBox.Objects = rows
+		scheduleBox.Refresh()
+	}
+	rebuildScheduleRows()
+
+	addWindowBtn := widget.NewButtonWithIcon("Add window", theme.ContentAddIcon(), func() {
+		if len(scheduleWindows) >= maxScheduleWindows {
+			dialog.ShowError(fmt.Errorf("too many schedule windows (max %d)", maxScheduleWindows), w)
+			return
+		}
+		showScheduleEditor(-1)
+	})
+	scheduleHint := widget.NewLabel("Local time. A window ending before it starts runs past midnight. The miner is started and stopped at window boundaries; manual Start/Stop holds until the next one.")
+	scheduleHint.Wrapping = fyne.TextWrapWord
+	scheduleHint.TextStyle = fyne.TextStyle{Italic: true}
+	scheduleFields := container.NewVBox(scheduleBox, container.NewHBox(layout.NewSpacer(), addWindowBtn), scheduleHint)
+	if !scheduleEnabledCheck.Checked {
+		scheduleFields.Hide()
+	}
+	scheduleEnabledCheck.OnChanged = func(enabled bool) {
+		if enabled {
+			scheduleFields.Show()
+		} else {
+			scheduleFields.Hide()
+		}
+	}
+	schedulePanel := panel("Schedule", container.NewVBox(scheduleEnabledCheck, scheduleFields))
+
+	idleThreadsLabel := fieldLabel("Threads while active")
+	idleGrid := container.NewGridWithColumns(2,
+		fieldLabel("Idle after (min)"), idleAfterEntry,
+		fieldLabel("While active"), idleActionSelect,
+		idleThreadsLabel, idleThreadsEntry,
 	)
-	hardwareBody := container.NewVBox(
-		hardwareGrid,
-		cpuHint,
-		cpuResolvedHint,
+	idleActionSelect.OnChanged = func(string) {
+		if selectedIdleAction() == throttleActionPause {
+			idleThreadsLabel.Hide()
+			idleThreadsEntry.Hide()
+		} else {
+			idleThreadsLabel.Show()
+			idleThreadsEntry.Show()
+		}
+	}
+	if cfg.IdleActiveAction == throttleActionThreads {
+		idleActionSelect.SetSelectedIndex(1)
+	} else {
+		idleActionSelect.SetSelectedIndex(0)
+	}
+	idleHint := widget.NewLabel("Keyboard and mouse activity from the desktop session (GNOME, KDE/freedesktop screensaver, or logind). Full settings return once the computer has been idle this long.")
+	idleHint.Wrapping = fyne.TextWrapWord
+	idleHint.TextStyle = fyne.TextStyle{Italic: true}
+	idleFields := container.NewVBox(idleGrid, idleHint)
+	if !idleEnabledCheck.Checked {
+		idleFields.Hide()
+	}
+	idleEnabledCheck.OnChanged = func(enabled bool) {
+		if enabled {
+			idleFields.Show()
+		} else {
+			idleFields.Hide()
+		}
+	}
+	idleLoadHint := widget.NewLabel("Pause while other programs use more than this share of the CPU (all cores, excluding the miner); resume after 30 s below it. Empty turns it off.")
+	idleLoadHint.Wrapping = fyne.TextWrapWord
+	idleLoadHint.TextStyle = fyne.TextStyle{Italic: true}
+	idlePanel := panel("Idle", container.NewVBox(
+		idleEnabledCheck,
+		idleFields,
 		widget.NewSeparator(),
-		container.NewHBox(fieldLabel("CPUs"), layout.NewSpacer(), refreshBtn),
-		devicesScroll,
+		container.NewGridWithColumns(2, fieldLabel("CPU load limit (%)"), idleLoadEntry),
+		idleLoadHint,
+	))
+
+	thermalGrid := container.NewGridWithColumns(2,
+		fieldLabel("Limit (°C)"), thermalLimitEntry,
+		fieldLabel("Resume at (°C)"), thermalResumeEntry,
+		fieldLabel("Over the limit"), thermalActionSelect,
 	)
-	hardwarePanel := panel("Hardware", hardwareBody)
-
-	setupLeft := container.NewVBox(connectionPanel, nodePanel, watchdogPanel)
-	setupLeftScroll := container.NewVScroll(setupLeft)
-	setupSplit := container.NewHSplit(setupLeftScroll, hardwarePanel)
-	setupSplit.Offset = 0.52
-	setupTab := container.NewPadded(setupSplit)
+	thermalHint := widget.NewLabel("Uses the hottest CPU package or core sensor (hwmon). Reduce threads halves them every minute while still too hot and pauses at one thread. Full settings return after a minute at or below the resume temperature.")
+	thermalHint.Wrapping = fyne.TextWrapWord
+	thermalHint.TextStyle = fyne.TextStyle{Italic: true}
+	thermalFields := container.NewVBox(thermalGrid, thermalHint)
+	if !thermalEnabledCheck.Checked {
+		thermalFields.Hide()
+	}
+	thermalEnabledCheck.OnChanged = func(enabled bool) {
+		if enabled {
+			thermalFields.Show()
+		} else {
+			thermalFields.Hide()
+		}
+	}
+	thermalPanel := panel("Thermal", container.NewVBox(thermalEnabledCheck, thermalFields))
 
-	hashrate10mTitle := widget.NewLabelWithStyle("Hashrate (10 min)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-	hashrate10mTitle.Wrapping = fyne.TextWrapOff
-	hashrate10mHeader := container.NewHBox(widget.NewIcon(theme.HistoryIcon()), hashrate10mTitle, layout.NewSpacer(), avgHashrateValue)
+	historyGrid := container.NewGridWithColumns(2,
+		fieldLabel("Raw samples (h)"), historyRawEntry,
+		fieldLabel("1-minute data (days)"), historyMinuteEntry,
+		fieldLabel("1-hour data (days)"), historyHourEntry,
+		fieldLabel("Daily data (days)"), historyDayEntry,
+	)
+	historyHint := widget.NewLabel("Stored in the config folder. Turning history on or off applies on next launch.")
+	historyHint.Wrapping = fyne.TextWrapWord
+	historyHint.TextStyle = fyne.TextStyle{Italic: true}
+	historyFields := container.NewVBox(historyGrid, historyHint)
+	if !historyEnabledCheck.Checked {
+		historyFields.Hide()
+	}
+	historyEnabledCheck.OnChanged = func(enabled bool) {
+		if enabled {
+			historyFields.Show()
+		} else {
+			historyFields.Hide()
+		}
+	}
+	historyPanel := panel("History", container.NewVBox(historyEnabledCheck, historyFields))
+
+	walletGrid := container.NewGridWithColumns(2,
+		fieldLabel("Balance RPC URL"), walletRPCEntry,
+	)
+	walletHint := widget.NewLabel("The Dashboard wallet reads the balance of the wallet and node mining addresses from this node RPC. Leave empty to use the embedded node, or the RPC URL in the Local RPC and RPC gateway modes. Balances and rewards are kept with the history.")
+	walletHint.Wrapping = fyne.TextWrapWord
+	walletHint.TextStyle = fyne.TextStyle{Italic: true}
+	walletPanel := panel("Wallet", container.NewVBox(walletGrid, walletHint))
+
+	var metrics *metricsServer
+	metricsStatus := widget.NewLabel("")
+	metricsStatus.Wrapping = fyne.TextWrapWord
+	metricsStatus.TextStyle = fyne.TextStyle{Italic: true}
+	applyMetrics := func() {
+		metrics.Close()
+		metrics = nil
+		if !cfg.MetricsEnabled {
+			metricsStatus.SetText("Exporter is off.")
+			return
+		}
+		srv, err := startMetricsServer(ctrl, metricsListenAddress(cfg))
+		if err != nil {
+			metricsStatus.SetText(fmt.Sprintf("Exporter failed to start: %v", err))
+			return
+		}
+		metrics = srv
+		metricsStatus.SetText(fmt.Sprintf("Serving http://%s/metrics", srv.Addr()))
+	}
+	metricsApplyBtn := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		bind := strings.TrimSpace(metricsBindEntry.Text)
+		if bind == "" {
+			bind = defaultMetricsBindAddress
+		}
+		port := defaultMetricsPort
+		if text := strings.TrimSpace(metricsPortEntry.Text); text != "" {
+			v, err := strconv.Atoi(text)
+			if err != nil || v < 1 || v > 65535 {
+				dialog.ShowError(errors.New("invalid metrics port (1..65535)"), w)
+				return
+			}
+			port = v
+		}
+		cfg.MetricsEnabled = metricsEnabledCheck.Checked
+		cfg.MetricsBindAddress = bind
+		cfg.MetricsPort = port
+		if err := ctrl.Replace(cfg); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		applyMetrics()
+	})
+	metricsGrid := container.NewGridWithColumns(2,
+		fieldLabel("Bind address"), metricsBindEntry,
+		fieldLabel("Port"), metricsPortEntry,
+	)
+	metricsBody := container.NewVBox(
+		metricsEnabledCheck,
+		metricsGrid,
+		container.NewHBox(metricsStatus, layout.NewSpacer(), metricsApplyBtn),
+	)
+	metricsPanel := panel("Metrics", metricsBody)
+
+	profileSelect := widget.NewSelect(nil, nil)
+	refreshProfileSelect := func() {
+		// Set without SetSelected so OnChanged only fires for the user.
+		profileSelect.Options = profileNames(cfg)
+		profileSelect.Selected = cfg.ActiveProfile
+		profileSelect.Refresh()
+	}
+	refreshProfileSelect()
+
+	applyConfigToUI := func() {
+		refreshProfileSelect()
+		if label, ok := modeLabelForKey[cfg.Mode]; ok {
+			modeSelect.SetSelected(label)
+		}
+		hostEntry.SetText(cfg.StratumHost)
+		portEntry.SetText(strconv.Itoa(cfg.StratumPort))
+		rpcEntry.SetText(cfg.RPCURL)
+		walletEntry.SetText(displayAddress(cfg.WalletAddress))
+		workerEntry.SetText(cfg.WorkerName)
+		backupPools = append([]PoolConfig(nil), cfg.Pools...)
+		rebuildPoolRows()
+		poolFailbackCheck.SetChecked(cfg.PoolFailbackEnabled)
+		poolFailbackEntry.SetText(strconv.Itoa(cfg.PoolFailbackMin))
+		if cfg.CPUThreads > 0 {
+			threadsEntry.SetText(strconv.Itoa(cfg.CPUThreads))
+		} else {
+			threadsEntry.SetText("")
+		}
+		displayIntervalEntry.SetText(strconv.Itoa(cfg.DisplayInterval))
+		donateEntry.SetText(strconv.Itoa(cfg.DonateLevel))
+		hugePagesCheck.SetChecked(cfg.UseHugePages)
+		randomXModeSelect.SetSelected(cfg.RandomXMode)
+		oneGBPagesCheck.SetChecked(cfg.RandomX1GBPages)
+		cpuPrioritySelect.SetSelectedIndex(cfg.CPUPriority - cpuPriorityDefault)
+		cpuYieldCheck.SetChecked(cfg.CPUYield)
+		msrCheck.SetChecked(cfg.EnableMSR)
+		autoMSRCheck.SetChecked(cfg.AutoGrantMSR)
+		selected := make(map[int]bool, len(cfg.CPUAffinity))
+		for _, idx := range cfg.CPUAffinity {
+			selected[idx] = true
+		}
+		devMu.Lock()
+		for i, check := range deviceChecks {
+			if i < len(devices) {
+				check.SetChecked(selected[devices[i].Index])
+			}
+		}
+		devMu.Unlock()
+
+		nodeEnabledCheck.SetChecked(cfg.NodeEnabled)
+		if label, ok := nodeModeLabelForKey[cfg.NodeMode]; ok {
+			nodeModeSelect.SetSelected(label)
+		}
+		nodeEtherbaseEntry.SetText(displayAddress(cfg.NodeEtherbase))
+		nodeDataDirEntry.SetText(cfg.NodeDataDir)
+		nodeRPCPortEntry.SetText(strconv.Itoa(cfg.NodeRPCPort))
+		nodeP2PPortEntry.SetText(strconv.Itoa(cfg.NodeP2PPort))
+		nodeBootnodesEntry.SetText(cfg.NodeBootnodes)
+		nodeStaticPeersEntry.SetText(strings.Join(cfg.NodeStaticPeers, "\n"))
+		nodeTrustedPeersEntry.SetText(strings.Join(cfg.NodeTrustedPeers, "\n"))
+		nodeVerbosityEntry.SetText(strconv.Itoa(cfg.NodeVerbosity))
+		nodeCleanStartCheck.SetChecked(cfg.NodeCleanStart)
+
+		scheduleEnabledCheck.SetChecked(cfg.ScheduleEnabled)
+		scheduleWindows = append([]ScheduleWindow(nil), cfg.Schedule...)
+		rebuildScheduleRows()
+		rebuildAddressBookRows()
+
+		idleEnabledCheck.SetChecked(cfg.IdleEnabled)
+		idleAfterEntry.SetText(strconv.Itoa(cfg.IdleAfterMin))
+		if cfg.IdleActiveAction == throttleActionThreads {
+			idleActionSelect.SetSelectedIndex(1)
+		} else {
+			idleActionSelect.SetSelectedIndex(0)
+		}
+		idleThreadsEntry.SetText(strconv.Itoa(cfg.IdleActiveThreads))
+		idleLoadEntry.SetText("")
+		if cfg.IdleLoadLimit > 0 {
+			idleLoadEntry.SetText(strconv.Itoa(cfg.IdleLoadLimit))
+		}
+
+		thermalEnabledCheck.SetChecked(cfg.ThermalEnabled)
+		thermalLimitEntry.SetText(strconv.Itoa(cfg.ThermalLimitC))
+		thermalResumeEntry.SetText(strconv.Itoa(cfg.ThermalResumeC))
+		if cfg.ThermalAction == throttleActionThreads {
+			thermalActionSelect.SetSelectedIndex(1)
+		} else {
+			thermalActionSelect.SetSelectedIndex(0)
+		}
+		if cfg.BlockReward > 0 {
+			blockRewardEntry.SetText(strconv.FormatFloat(cfg.BlockReward, 'f', -1, 64))
+		} else {
+			blockRewardEntry.SetText("")
+		}
+
+		watchdogEnabledCheck.SetChecked(cfg.WatchdogEnabled)
+		watchdogNoJobEntry.SetText(strconv.Itoa(cfg.WatchdogNoJobTimeoutSec))
+		watchdogRestartDelayEntry.SetText(strconv.Itoa(cfg.WatchdogRestartDelaySec))
+		watchdogRetryWindowEntry.SetText(strconv.Itoa(cfg.WatchdogRetryWindowMin))
+
+		historyEnabledCheck.SetChecked(cfg.HistoryEnabled)
+		historyRawEntry.SetText(strconv.Itoa(cfg.HistoryRawRetentionHours))
+		historyMinuteEntry.SetText(strconv.Itoa(cfg.HistoryMinuteRetentionDays))
+		historyHourEntry.SetText(strconv.Itoa(cfg.HistoryHourRetentionDays))
+		historyDayEntry.SetText(strconv.Itoa(cfg.HistoryDayRetentionDays))
+		walletRPCEntry.SetText(cfg.WalletRPCURL)
+
+		metricsEnabledCheck.SetChecked(cfg.MetricsEnabled)
+		metricsBindEntry.SetText(cfg.MetricsBindAddress)
+		metricsPortEntry.SetText(strconv.Itoa(cfg.MetricsPort))
+	}
+
+	var api *apiServer
+	apiStatus := widget.NewLabel("")
+	apiStatus.Wrapping = fyne.TextWrapWord
+	apiStatus.TextStyle = fyne.TextStyle{Italic: true}
+	applyAPI := func() {
+		api.Close()
+		api = nil
+		if !cfg.APIEnabled {
+			apiStatus.SetText("Control API is off.")
+			return
+		}
+		// API changes run on the UI goroutine, so they cannot interleave
+		// with the Setup form replacing the config.
+		srv, err := startAPIServer(ctrl, cfg, func(change func(next *Config) error) error {
+			var err error
+			fyne.DoAndWait(func() {
+				if err = ctrl.Update(change); err == nil {
+					*cfg = *ctrl.Snapshot()
+					applyConfigToUI()
+				}
+			})
+			return err
+		})
+		if err != nil {
+			apiStatus.SetText(fmt.Sprintf("Control API failed to start: %v", err))
+			return
+		}
+		api = srv
+		apiStatus.SetText(fmt.Sprintf("Listening on http://%s/api/v1/", srv.Addr()))
+	}
+	apiApplyBtn := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() {
+		next := *cfg
+		next.APIEnabled = apiEnabledCheck.Checked
+		next.APIBindAddress = strings.TrimSpace(apiBindEntry.Text)
+		if next.APIBindAddress == "" {
+			next.APIBindAddress = defaultAPIBindAddress
+		}
+		next.APIPort = defaultAPIPort
+		if text := strings.TrimSpace(apiPortEntry.Text); text != "" {
+			v, err := strconv.Atoi(text)
+			if err != nil {
+				dialog.ShowError(errors.New("invalid API port (1..65535)"), w)
+				return
+			}
+			next.APIPort = v
+		}
+		next.APIToken = strings.TrimSpace(apiTokenEntry.Text)
+		if next.APIEnabled && next.APIToken == "" {
+			token, err := generateAPIToken()
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			next.APIToken = token
+			apiTokenEntry.SetText(token)
+		}
+		if next.APIEnabled && len(next.APIToken) < 16 {
+			dialog.ShowError(errors.New("API token must be at least 16 characters"), w)
+			return
+		}
+		if next.APIEnabled && (next.APIPort < 1 || next.APIPort > 65535) {
+			dialog.ShowError(errors.New("invalid API port (1..65535)"), w)
+			return
+		}
+		cfg.APIEnabled = next.APIEnabled
+		cfg.APIBindAddress = next.APIBindAddress
+		cfg.APIPort = next.APIPort
+		cfg.APIToken = next.APIToken
+		if err := ctrl.Replace(cfg); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		applyAPI()
+	})
+	apiCopyTokenBtn := widget.NewButtonWithIcon("Copy token", theme.ContentCopyIcon(), func() {
+		w.Clipboard().SetContent(apiTokenEntry.Text)
+	})
+	apiGrid := container.NewGridWithColumns(2,
+		fieldLabel("Bind address"), apiBindEntry,
+		fieldLabel("Port"), apiPortEntry,
+		fieldLabel("Token"), apiTokenEntry,
+	)
+	apiBody := container.NewVBox(
+		apiEnabledCheck,
+		apiGrid,
+		container.NewHBox(apiStatus, layout.NewSpacer(), apiCopyTokenBtn, apiApplyBtn),
+	)
+	apiPanel := panel("Control API", apiBody)
+
+	exportSecretsCheck := widget.NewCheck("Include the control API token", nil)
+	bundleStatus := widget.NewLabel("")
+	bundleStatus.Wrapping = fyne.TextWrapWord
+	bundleStatus.TextStyle = fyne.TextStyle{Italic: true}
+	exportConfigBtn := widget.NewButtonWithIcon("Export…", theme.DocumentSaveIcon(), func() {
+		if err := saveFromUI(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		withSecrets := exportSecretsCheck.Checked
+		save := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if wc == nil {
+				return
+			}
+			err = writeConfigBundle(wc, cfg, withSecrets)
+			if closeErr := wc.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to export config: %w", err), w)
+				return
+			}
+			bundleStatus.SetText(fmt.Sprintf("Exported to %s.", wc.URI().Name()))
+		}, w)
+		save.SetFileName("olivetum-miner-config.json")
+		save.Show()
+	})
+	importConfigBtn := widget.NewButtonWithIcon("Import…", theme.UploadIcon(), func() {
+		open := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if rc == nil {
+				return
+			}
+			b, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			next, err := parseConfigBundle(b)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", rc.URI().Name(), err), w)
+				return
+			}
+			name := rc.URI().Name()
+			dialog.ShowConfirm("Import config", fmt.Sprintf("Replace all settings with %s?\nThe current settings are kept as a backup.", name), func(ok bool) {
+				if !ok {
+					return
+				}
+				*cfg = *next
+				if err := ctrl.Replace(cfg); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				applyConfigToUI()
+				applyMetrics()
+				applyAPI()
+				_, err := ctrl.ApplyConfig()
+				refreshPendingRestart()
+				if err != nil {
+					dialog.ShowError(err, w)
+				}
+				bundleStatus.SetText(fmt.Sprintf("Imported %s.", name))
+				appendMinerLog(fmt.Sprintf("[config] Imported %s\n", name))
+			}, w)
+		}, w)
+		open.Show()
+	})
+	bundleHint := widget.NewLabel("Exports every setting and profile as a JSON bundle for other machines. On import, ${NAME} is replaced by the environment variable NAME (${NAME:-default} when unset; ${HOSTNAME} and ${HOSTNAME_SHORT} are built in), and the entry for this host name under \"hosts\" is applied over the config.")
+	bundleHint.Wrapping = fyne.TextWrapWord
+	bundleHint.TextStyle = fyne.TextStyle{Italic: true}
+	bundlePanel := panel("Import / export", container.NewVBox(
+		exportSecretsCheck,
+		container.NewHBox(bundleStatus, layout.NewSpacer(), importConfigBtn, exportConfigBtn),
+		bundleHint,
+	))
+
+	applyTuneCandidate := func(cand tuneCandidate) {
+		next := *cfg
+		next.CPUAffinity = append([]int(nil), cand.Affinity...)
+		next.CPUThreads = 0
+		if len(next.CPUAffinity) == 0 {
+			next.CPUThreads = cand.Threads
+		}
+		if err := validateConfig(&next); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		*cfg = next
+		if err := ctrl.Replace(cfg); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		applyConfigToUI()
+		appendMinerLog(fmt.Sprintf("[autotune] Applied %s\n", cand.Name))
+	}
+
+	autotuneBtn := widget.NewButtonWithIcon("Auto-tune", theme.MediaFastForwardIcon(), func() {
+		if ctrl.MinerRunning() {
+			dialog.ShowError(errors.New("stop mining before auto-tuning"), w)
+			return
+		}
+		devMu.Lock()
+		list := append([]Device(nil), devices...)
+		devMu.Unlock()
+		if len(list) == 0 {
+			dialog.ShowError(errors.New("no CPUs listed yet; use Refresh CPUs first"), w)
+			return
+		}
+		sysfsRoot := strings.TrimSpace(cfg.SysfsRoot)
+		if sysfsRoot == "" {
+			sysfsRoot = defaultSysfsRoot
+		}
+		candidates := autotuneCandidates(list, l3CacheBytes(sysfsRoot))
+
+		runEntry := widget.NewEntry()
+		runEntry.SetText(strconv.Itoa(defaultAutotuneRunSec))
+		results := container.NewGridWithColumns(2)
+		statuses := make([]*widget.Label, len(candidates))
+		for i, cand := range candidates {
+			results.Add(widget.NewLabel(cand.Name))
+			statuses[i] = widget.NewLabel("—")
+			statuses[i].Truncation = fyne.TextTruncateEllipsis
+			results.Add(statuses[i])
+		}
+		progress := widget.NewProgressBar()
+		progress.Max = float64(len(candidates))
+		hint := widget.NewLabel("Each layout runs xmrig's offline benchmark (--stress); nothing is sent to the pool. Keep other heavy programs closed while it runs.")
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+		runBtn := widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), nil)
+		runBtn.Importance = widget.HighImportance
+		content := container.NewVBox(
+			hint,
+			container.NewGridWithColumns(2, fieldLabel("Seconds per layout"), runEntry),
+			widget.NewSeparator(),
+			results,
+			progress,
+			container.NewHBox(layout.NewSpacer(), runBtn),
+		)
+
+		var cancelRun context.CancelFunc
+		d := dialog.NewCustom("Auto-tune", "Close", content, w)
+		d.SetOnClosed(func() {
+			if cancelRun != nil {
+				cancelRun()
+			}
+		})
+		runBtn.OnTapped = func() {
+			secs, err := strconv.Atoi(strings.TrimSpace(runEntry.Text))
+			if err != nil || secs < 10 || secs > 600 {
+				dialog.ShowError(errors.New("invalid run time (10..600 seconds)"), w)
+				return
+			}
+			runBtn.Disable()
+			runEntry.Disable()
+			statuses[0].SetText("running…")
+			ctx, cancel := context.WithCancel(context.Background())
+			cancelRun = cancel
+			go func() {
+				defer cancel()
+				res, err := ctrl.RunAutotune(ctx, candidates, time.Duration(secs)*time.Second, func(i int, r tuneResult) {
+					fyne.Do(func() {
+						if r.Err != nil {
+							statuses[i].SetText("failed: " + r.Err.Error())
+						} else {
+							statuses[i].SetText(formatHashrate(r.Hashrate))
+						}
+						progress.SetValue(float64(i + 1))
+						if i+1 < len(statuses) {
+							statuses[i+1].SetText("running…")
+						}
+					})
+				})
+				if ctx.Err() != nil {
+					return
+				}
+				best, ok := bestTuneResult(res)
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(err, w)
+						return
+					}
+					if !ok {
+						dialog.ShowInformation("Auto-tune", "No layout reported a hashrate; see Miner Logs.", w)
+						return
+					}
+					msg := fmt.Sprintf("Best layout: %s at %s.\nApply it to the Hardware settings?", best.Candidate.Name, formatHashrate(best.Hashrate))
+					dialog.ShowConfirm("Auto-tune", msg, func(apply bool) {
+						if apply {
+							applyTuneCandidate(best.Candidate)
+						}
+					}, w)
+				})
+			}()
+		}
+		d.Resize(fyne.NewSize(560, d.MinSize().Height))
+		d.Show()
+	})
+
+	hardwareGrid := container.NewGridWithColumns(2,
+		fieldLabel("CPU threads"), threadsEntry,
+		fieldLabel("Display interval (s)"), displayIntervalEntry,
+		fieldLabel("Donate level"), donateEntry,
+		fieldLabel("RandomX mode"), randomXModeSelect,
+		fieldLabel("Thread priority"), cpuPrioritySelect,
+		widget.NewLabel(""), hugePagesCheck,
+		widget.NewLabel(""), oneGBPagesCheck,
+		widget.NewLabel(""), cpuYieldCheck,
+		widget.NewLabel(""), msrCheck,
+		widget.NewLabel(""), autoMSRCheck,
+	)
+	hardwareBody := container.NewVBox(
+		hardwareGrid,
+		cpuHint,
+		cpuResolvedHint,
+		widget.NewSeparator(),
+		container.NewHBox(fieldLabel("CPUs"), layout.NewSpacer(), autotuneBtn, refreshBtn),
+		devicesScroll,
+	)
+	hardwarePanel := panel("Hardware", hardwareBody)
+
+	setupLeft := container.NewVBox(connectionPanel, poolsPanel, nodePanel, accountsPanel, addressBookPanel, schedulePanel, idlePanel, thermalPanel, watchdogPanel, historyPanel, walletPanel, metricsPanel, apiPanel, bundlePanel)
+	setupLeftScroll := container.NewVScroll(setupLeft)
+	setupSplit := container.NewHSplit(setupLeftScroll, hardwarePanel)
+	setupSplit.Offset = 0.52
+	restartNowBtn = widget.NewButtonWithIcon("Restart miner", theme.ViewRefreshIcon(), restartMinerUser)
+	liveApplyBtn := widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), applyToMinerUser)
+	liveApplyPanel = panel("Running miner", container.NewBorder(nil, nil, nil, container.NewHBox(restartNowBtn, liveApplyBtn), liveApplyText))
+	refreshPendingRestart()
+	setupTab := container.NewPadded(container.NewBorder(liveApplyPanel, nil, nil, nil, setupSplit))
+
+	hashrateTitle := widget.NewLabelWithStyle("Hashrate", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	hashrateTitle.Wrapping = fyne.TextWrapOff
+	hashrateHeader := container.NewHBox(widget.NewIcon(theme.HistoryIcon()), hashrateTitle, historyRangeSelect, layout.NewSpacer(), avgHashrateValue)
 
 	overviewGrid := container.NewGridWithColumns(4,
 		metricTileWithIcon("Threads", theme.ComputerIcon(), threadsInUseValue),
@@ -2771,25 +3572,728 @@ func main() {
 		metricTileWithIcon("Current mining block", iconPickaxeWhite, currentBlockValue),
 		metricTileWithIcon("Last found", theme.SearchIcon(), lastFoundBlockValue),
 	)
+	scheduleValue := widget.NewLabel("—")
+	scheduleTile := metricTileWithIcon("Schedule", theme.HistoryIcon(), scheduleValue)
+	scheduleTile.Hide()
 	overviewBody := container.NewVBox(
 		fieldLabel("Total hashrate"),
 		hashrateValue,
 		overviewGrid,
 		jobRow,
+		scheduleTile,
 	)
 	overviewPanel := panel("Overview", overviewBody)
-	hashratePanel := panelWithHeader(hashrate10mHeader, hashrateHistory.Object())
+	hashratePanel := panelWithHeader(hashrateHeader, hashrateHistory.Object())
 	statsScroll := container.NewVScroll(statsTable)
 	statsScroll.SetMinSize(fyne.NewSize(0, 220))
 	statsBody := container.NewVBox(statsHeaderRow, widget.NewSeparator(), statsScroll)
 	statsPanel := panel("Per-CPU", statsBody)
-	dashboardStack := container.NewVBox(overviewPanel, hashratePanel, statsPanel)
+
+	estimateBasisLabels := []string{"Chart average", "Last 24 hours", "Last 7 days"}
+	estimateBasisSelect := widget.NewSelect(estimateBasisLabels, nil)
+	estimateBasisSelect.SetSelectedIndex(0)
+	estimateDifficultyValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	estimateBlockTimeValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	estimateChance24hValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	estimateChance7dValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	estimatePerDayValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	estimateStatus := widget.NewLabel("")
+	estimateStatus.Wrapping = fyne.TextWrapWord
+	estimateStatus.TextStyle = fyne.TextStyle{Italic: true}
+	showEstimate := func(e miningEstimate, ok bool) {
+		if !ok {
+			for _, l := range []*widget.Label{estimateDifficultyValue, estimateBlockTimeValue, estimateChance24hValue, estimateChance7dValue, estimatePerDayValue} {
+				l.SetText("—")
+			}
+			return
+		}
+		estimateDifficultyValue.SetText(formatDifficulty(e.Difficulty))
+		estimateBlockTimeValue.SetText(formatExpectedTime(e.BlockTimeSec))
+		estimateChance24hValue.SetText(formatChance(e.Chance24h))
+		estimateChance7dValue.SetText(formatChance(e.Chance7d))
+		if e.RewardPerDay > 0 {
+			estimatePerDayValue.SetText(fmt.Sprintf("%.4g OLIVO (%.3g blocks)", e.RewardPerDay, e.BlocksPerDay))
+		} else {
+			estimatePerDayValue.SetText(fmt.Sprintf("%.3g blocks", e.BlocksPerDay))
+		}
+	}
+	// runEstimate recalculates from the current inputs. Only an explicit
+	// Calculate saves the block reward; the periodic refresh just reads it.
+	runEstimate := func(saveReward bool) {
+		var reward float64
+		if text := strings.TrimSpace(blockRewardEntry.Text); text != "" {
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil || v < 0 || math.IsInf(v, 0) {
+				estimateStatus.SetText("Invalid block reward.")
+				return
+			}
+			reward = v
+		}
+		if saveReward && reward != cfg.BlockReward {
+			next := *cfg
+			next.BlockReward = reward
+			if err := validateConfig(&next); err != nil {
+				estimateStatus.SetText(fmt.Sprintf("Block reward not saved: %v", err))
+				return
+			}
+			*cfg = next
+			if err := ctrl.Replace(cfg); err != nil {
+				estimateStatus.SetText(fmt.Sprintf("Failed to save block reward: %v", err))
+			}
+		}
+		var whatIf float64
+		if text := strings.TrimSpace(whatIfEntry.Text); text != "" {
+			v, err := parseHashrate(text)
+			if err != nil {
+				estimateStatus.SetText("Invalid what-if hashrate (e.g. 850, 12.5k, 3 MH/s).")
+				return
+			}
+			whatIf = v
+		}
+		basis := estimateBasisSelect.SelectedIndex()
+		chartAvg, _ := hashrateHistory.Average()
+		estimateStatus.SetText("Calculating…")
+		go func() {
+			hashrate, from := whatIf, "what-if"
+			var hashErr error
+			if hashrate == 0 {
+				switch basis {
+				case 1, 2:
+					span := 24 * time.Hour
+					if basis == 2 {
+						span = 7 * 24 * time.Hour
+					}
+					from = strings.ToLower(estimateBasisLabels[basis])
+					if history == nil {
+						hashErr = errors.New("history is off; enable it in Setup → History")
+						break
+					}
+					points, err := history.Query(span)
+					if err != nil {
+						hashErr = err
+						break
+					}
+					hashrate, _, _, _, _ = summarizeHistory(points)
+				default:
+					hashrate, from = chartAvg, "chart average"
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			diff, source, diffErr := networkDifficulty(ctx, ctrl)
+			cancel()
+			e, ok := estimateMining(hashrate, diff, reward)
+			fyne.Do(func() {
+				showEstimate(e, ok)
+				switch {
+				case diffErr != nil:
+					estimateStatus.SetText(fmt.Sprintf("Difficulty unavailable: %v.", diffErr))
+				case hashErr != nil:
+					estimateStatus.SetText(fmt.Sprintf("No hashrate: %v.", hashErr))
+				case hashrate <= 0:
+					estimateStatus.SetText("No hashrate yet; start the miner or enter a what-if hashrate.")
+				default:
+					estimateStatus.SetText(fmt.Sprintf("%s (%s) against the %s difficulty.", formatHashrate(hashrate), from, source))
+				}
+			})
+		}()
+	}
+	estimateBtn := widget.NewButtonWithIcon("Calculate", theme.ViewRefreshIcon(), func() { runEstimate(true) })
+	estimateInputs := container.NewGridWithColumns(2,
+		fieldLabel("Hashrate"), estimateBasisSelect,
+		fieldLabel("What-if hashrate"), whatIfEntry,
+		fieldLabel("Block reward (OLIVO)"), blockRewardEntry,
+	)
+	estimateResults := container.NewGridWithColumns(2,
+		fieldLabel("Difficulty"), estimateDifficultyValue,
+		fieldLabel("Expected time to block"), estimateBlockTimeValue,
+		fieldLabel("Chance within 24 hours"), estimateChance24hValue,
+		fieldLabel("Chance within 7 days"), estimateChance7dValue,
+		fieldLabel("Expected per day"), estimatePerDayValue,
+	)
+	estimateBody := container.NewVBox(
+		estimateInputs,
+		widget.NewSeparator(),
+		estimateResults,
+		container.NewBorder(nil, nil, nil, estimateBtn, estimateStatus),
+	)
+	estimatePanel := panel("Block estimator", estimateBody)
+	estimateStop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-estimateStop:
+				return
+			case <-ticker.C:
+			}
+			if ctrl.MinerRunning() {
+				fyne.Do(func() { runEstimate(false) })
+			}
+		}
+	}()
+
+	var wallet *walletTracker
+	walletBalanceValue := canvas.NewText("—", theme.Color(theme.ColorNameForeground))
+	walletBalanceValue.Alignment = fyne.TextAlignLeading
+	walletBalanceValue.TextStyle = fyne.TextStyle{Bold: true}
+	walletBalanceValue.TextSize = theme.TextSize() * 1.8
+	walletAddressesValue := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	walletAddressesValue.Hide()
+	walletRewardsValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	walletLastRewardValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	walletStatus := widget.NewLabel("Checking balance…")
+	walletStatus.Wrapping = fyne.TextWrapWord
+	walletStatus.TextStyle = fyne.TextStyle{Italic: true}
+	walletChart := newHashrateChart(2)
+	walletChart.SetUnit("OLIVO")
+	walletRangeSelect := widget.NewSelect([]string{"24 h", "7 d", "30 d"}, nil)
+	refreshWallet := func() {
+		now := time.Now()
+		st := wallet.Status()
+		switch {
+		case st.UpdatedAt.IsZero():
+			walletStatus.SetText("Checking balance…")
+		case st.Err != nil:
+			walletStatus.SetText(fmt.Sprintf("Balance unavailable: %v.", st.Err))
+		default:
+			walletBalanceValue.Text = formatOLIVO(st.Total)
+			walletBalanceValue.Refresh()
+			text := fmt.Sprintf("Updated %s from %s at block %d.", st.UpdatedAt.Format("15:04:05"), st.Endpoint, st.Head)
+			if !cfg.HistoryEnabled {
+				text += " History is off, so the chart only covers this session."
+			}
+			walletStatus.SetText(text)
+		}
+		if len(st.Balances) > 1 {
+			addrs := make([]string, 0, len(st.Balances))
+			for addr := range st.Balances {
+				addrs = append(addrs, addr)
+			}
+			sort.Strings(addrs)
+			lines := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				lines = append(lines, fmt.Sprintf("%s  %s", addr, formatOLIVO(st.Balances[addr])))
+			}
+			walletAddressesValue.SetText(strings.Join(lines, "\n"))
+			walletAddressesValue.Show()
+		} else {
+			walletAddressesValue.Hide()
+		}
+
+		rewards := wallet.Rewards()
+		var day, week int
+		for _, r := range rewards {
+			if now.Sub(r.Time) <= 24*time.Hour {
+				day++
+			}
+			if now.Sub(r.Time) <= 7*24*time.Hour {
+				week++
+			}
+		}
+		walletRewardsValue.SetText(fmt.Sprintf("%d in 24 h, %d in 7 days", day, week))
+		if len(rewards) > 0 {
+			walletLastRewardValue.SetText(fmt.Sprintf("Block %d, %s", rewards[0].Height, rewards[0].Time.Local().Format("2006-01-02 15:04")))
+		} else {
+			walletLastRewardValue.SetText("—")
+		}
+
+		if points := wallet.BalanceSeries(historyRangeSpan(walletRangeSelect.Selected), 120, now); points != nil {
+			walletChart.SetPoints(points)
+		} else {
+			walletChart.Reset()
+		}
+	}
+	walletRangeSelect.OnChanged = func(string) { refreshWallet() }
+	walletRangeSelect.SetSelected("7 d")
+	walletTitle := widget.NewLabelWithStyle("Wallet", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	walletTitle.Wrapping = fyne.TextWrapOff
+	walletHeader := container.NewHBox(widget.NewIcon(theme.AccountIcon()), walletTitle, walletRangeSelect)
+	walletBody := container.NewVBox(
+		fieldLabel("Balance"),
+		walletBalanceValue,
+		walletAddressesValue,
+		container.NewGridWithColumns(2,
+			fieldLabel("Coinbase rewards"), walletRewardsValue,
+			fieldLabel("Last reward"), walletLastRewardValue,
+		),
+		walletChart.Object(),
+		walletStatus,
+	)
+	walletBalancePanel := panelWithHeader(walletHeader, walletBody)
+
+	dashboardStack := container.NewVBox(overviewPanel, hashratePanel, walletBalancePanel, estimatePanel, statsPanel)
 	dashboardTab := container.NewPadded(container.NewVScroll(dashboardStack))
 
+	blocksHeader := []string{"Height", "Found", "Mode", "Source", "Status", "Confirmations", "Hash"}
+	blocksColWidths := []float32{100, 160, 170, 80, 100, 120, 220}
+	blocksHeaderRow := func() fyne.CanvasObject {
+		cells := make([]fyne.CanvasObject, 0, len(blocksHeader))
+		for i, title := range blocksHeader {
+			label := widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			label.Wrapping = fyne.TextWrapOff
+			cells = append(cells, fixedSize(fyne.NewSize(blocksColWidths[i], statsHeaderHeight), label))
+		}
+		return container.NewHBox(cells...)
+	}()
+	var blockRows []foundBlock
+	blocksTable := widget.NewTable(
+		func() (int, int) {
+			return len(blockRows), len(blocksHeader)
+		},
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Wrapping = fyne.TextWrapOff
+			return l
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			text := obj.(*widget.Label)
+			text.TextStyle = fyne.TextStyle{}
+			if id.Row < 0 || id.Row >= len(blockRows) {
+				text.SetText("")
+				return
+			}
+			b := blockRows[id.Row]
+			switch id.Col {
+			case 0:
+				text.TextStyle = fyne.TextStyle{Monospace: true}
+				text.SetText(strconv.FormatInt(b.Height, 10))
+			case 1:
+				text.SetText(b.FoundAt.Local().Format("2006-01-02 15:04"))
+			case 2:
+				if label, ok := modeLabelForKey[b.Mode]; ok {
+					text.SetText(label)
+				} else {
+					text.SetText(b.Mode)
+				}
+			case 3:
+				text.SetText(b.Source)
+			case 4:
+				text.TextStyle = fyne.TextStyle{Bold: b.Status == blockStatusCanonical}
+				text.SetText(b.Status)
+			case 5:
+				text.TextStyle = fyne.TextStyle{Monospace: true}
+				switch {
+				case b.Status != blockStatusCanonical:
+					text.SetText("—")
+				case b.Confirmations >= blockFinalConfirmations:
+					text.SetText(fmt.Sprintf("%d+", blockFinalConfirmations))
+				default:
+					text.SetText(strconv.FormatInt(b.Confirmations, 10))
+				}
+			case 6:
+				text.TextStyle = fyne.TextStyle{Monospace: true}
+				hash := b.Hash
+				if len(hash) == 66 {
+					hash = hash[:10] + "…" + hash[58:]
+				}
+				if hash == "" {
+					hash = "—"
+				}
+				text.SetText(hash)
+			}
+			text.Refresh()
+		},
+	)
+	for i, w := range blocksColWidths {
+		blocksTable.SetColumnWidth(i, w)
+	}
+	blocksSummary := widget.NewLabel("")
+	blocksSummary.Wrapping = fyne.TextWrapWord
+	refreshBlocks := func() {
+		blockRows = ledger.Blocks()
+		counts := make(map[string]int)
+		for _, b := range blockRows {
+			counts[b.Status]++
+		}
+		if len(blockRows) == 0 {
+			blocksSummary.SetText("No blocks found yet. Blocks sealed by the node or found through accepted shares are listed here and checked against the chain while a node RPC is reachable.")
+		} else {
+			blocksSummary.SetText(fmt.Sprintf("%d found: %d canonical, %d uncles, %d orphaned, %d pending",
+				len(blockRows), counts[blockStatusCanonical], counts[blockStatusUncle], counts[blockStatusOrphaned], counts[blockStatusPending]))
+		}
+		blocksTable.Refresh()
+	}
+	refreshBlocks()
+	blocksBody := container.NewBorder(container.NewVBox(blocksSummary, blocksHeaderRow, widget.NewSeparator()), nil, nil, nil, blocksTable)
+	blocksTab := container.NewPadded(panel("Found blocks", blocksBody))
+
+	nodeSyncState := widget.NewLabelWithStyle("Node is off", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	nodeSyncBar := widget.NewProgressBar()
+	nodeCurrentValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeHighestValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeSpeedValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeETAValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeStatesValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodePeersValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeHeadValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeHeadAgeValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeSyncStatus := widget.NewLabel("")
+	nodeSyncStatus.Wrapping = fyne.TextWrapWord
+	nodeSyncStatus.TextStyle = fyne.TextStyle{Italic: true}
+	nodeSyncValues := []*widget.Label{nodeCurrentValue, nodeHighestValue, nodeSpeedValue, nodeETAValue, nodeStatesValue, nodePeersValue, nodeHeadValue, nodeHeadAgeValue}
+	showNodeStatus := func(running bool, st nodeStatus, err error, rate float64, rateOK bool, now time.Time) {
+		if !running || err != nil {
+			for _, l := range nodeSyncValues {
+				l.SetText("—")
+			}
+			nodeSyncBar.SetValue(0)
+			if !running {
+				nodeSyncState.SetText("Node is off")
+				nodeSyncStatus.SetText("Start the node in Setup to see its sync progress.")
+			} else {
+				nodeSyncState.SetText("Waiting for the node RPC")
+				nodeSyncStatus.SetText(fmt.Sprintf("RPC not answering: %v.", err))
+			}
+			return
+		}
+		nodeSyncStatus.SetText("")
+		nodePeersValue.SetText(strconv.FormatInt(st.Peers, 10))
+		nodeHeadValue.SetText(strconv.FormatInt(st.Head, 10))
+		if st.HeadTime.IsZero() {
+			nodeHeadAgeValue.SetText("—")
+		} else {
+			nodeHeadAgeValue.SetText(formatAge(now.Sub(st.HeadTime)))
+		}
+		if rateOK {
+			nodeSpeedValue.SetText(fmt.Sprintf("%.1f blocks/s", rate))
+		} else {
+			nodeSpeedValue.SetText("—")
+		}
+
+		if st.Sync == nil {
+			nodeCurrentValue.SetText(strconv.FormatInt(st.Head, 10))
+			nodeHighestValue.SetText("—")
+			nodeETAValue.SetText("—")
+			nodeStatesValue.SetText("—")
+			if st.Peers == 0 {
+				nodeSyncState.SetText("Waiting for peers")
+				nodeSyncBar.SetValue(0)
+			} else {
+				nodeSyncState.SetText("Synced")
+				nodeSyncBar.SetValue(1)
+			}
+			return
+		}
+		p := st.Sync
+		nodeSyncState.SetText(fmt.Sprintf("Syncing (%d blocks behind)", max(0, p.HighestBlock-p.CurrentBlock)))
+		nodeSyncBar.SetValue(p.Fraction())
+		nodeCurrentValue.SetText(strconv.FormatInt(p.CurrentBlock, 10))
+		nodeHighestValue.SetText(strconv.FormatInt(p.HighestBlock, 10))
+		if eta, ok := syncETA(p, rate); ok && rateOK {
+			nodeETAValue.SetText(formatExpectedTime(eta.Seconds()))
+		} else {
+			nodeETAValue.SetText("—")
+		}
+		if p.KnownStates > 0 {
+			nodeStatesValue.SetText(fmt.Sprintf("%d / %d", p.PulledStates, p.KnownStates))
+		} else {
+			nodeStatesValue.SetText("—")
+		}
+	}
+	nodeSyncGrid := container.NewGridWithColumns(2,
+		fieldLabel("Current block"), nodeCurrentValue,
+		fieldLabel("Highest block"), nodeHighestValue,
+		fieldLabel("Speed"), nodeSpeedValue,
+		fieldLabel("Time left"), nodeETAValue,
+		fieldLabel("States (pulled / known)"), nodeStatesValue,
+	)
+	nodeChainGrid := container.NewGridWithColumns(2,
+		fieldLabel("Peers"), nodePeersValue,
+		fieldLabel("Head block"), nodeHeadValue,
+		fieldLabel("Head block age"), nodeHeadAgeValue,
+	)
+	nodeSyncPanel := panel("Sync", container.NewVBox(nodeSyncState, nodeSyncBar, nodeSyncGrid, nodeSyncStatus))
+	nodeEnodeValue := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	nodeEnodeValue.Wrapping = fyne.TextWrapBreak
+	nodeEnode := ""
+	nodeEnodeCopyBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		if nodeEnode != "" {
+			w.Clipboard().SetContent(nodeEnode)
+		}
+	})
+	nodeEnodeCopyBtn.Disable()
+	nodeChainPanel := panel("Chain", container.NewVBox(
+		nodeChainGrid,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, fieldLabel("Our enode"), nodeEnodeCopyBtn, nodeEnodeValue),
+	))
+
+	nodeRPCEndpoint := func() string { return fmt.Sprintf("http://127.0.0.1:%d", cfg.NodeRPCPort) }
+	peersHeader := []string{"Node", "Address", "Client", "Protocols", "Head", "Flags"}
+	peersColWidths := []float32{230, 170, 240, 130, 150, 130}
+	peersHeaderRow := func() fyne.CanvasObject {
+		cells := make([]fyne.CanvasObject, 0, len(peersHeader))
+		for i, title := range peersHeader {
+			label := widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			label.Wrapping = fyne.TextWrapOff
+			cells = append(cells, fixedSize(fyne.NewSize(peersColWidths[i], statsHeaderHeight), label))
+		}
+		return container.NewHBox(cells...)
+	}()
+	var peerRows []nodePeer
+	selectedPeer := ""
+	peersTable := widget.NewTable(
+		func() (int, int) {
+			return len(peerRows), len(peersHeader)
+		},
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Wrapping = fyne.TextWrapOff
+			return l
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			text := obj.(*widget.Label)
+			text.TextStyle = fyne.TextStyle{}
+			if id.Row < 0 || id.Row >= len(peerRows) {
+				text.SetText("")
+				return
+			}
+			p := peerRows[id.Row]
+			switch id.Col {
+			case 0:
+				text.TextStyle = fyne.TextStyle{Monospace: true}
+				text.SetText(shortEnode(p.Enode))
+			case 1:
+				text.SetText(p.RemoteAddr)
+			case 2:
+				text.SetText(p.Name)
+			case 3:
+				text.SetText(strings.Join(p.Caps, ", "))
+			case 4:
+				text.TextStyle = fyne.TextStyle{Monospace: true}
+				head := p.Head
+				if len(head) == 66 {
+					head = head[:10] + "…" + head[58:]
+				}
+				if head == "" {
+					head = "—"
+				}
+				text.SetText(head)
+			case 5:
+				flags := []string{"outbound"}
+				if p.Inbound {
+					flags[0] = "inbound"
+				}
+				if p.Static {
+					flags = append(flags, "static")
+				}
+				if p.Trusted {
+					flags = append(flags, "trusted")
+				}
+				text.SetText(strings.Join(flags, ", "))
+			}
+			text.Refresh()
+		},
+	)
+	for i, w := range peersColWidths {
+		peersTable.SetColumnWidth(i, w)
+	}
+	peersSummary := widget.NewLabel("Start the node to see its peers.")
+	peersSummary.Wrapping = fyne.TextWrapWord
+	peersStatus := widget.NewLabel("")
+	peersStatus.Wrapping = fyne.TextWrapWord
+	peersStatus.TextStyle = fyne.TextStyle{Italic: true}
+	var peerRemoveBtn *widget.Button
+	peersTable.OnSelected = func(id widget.TableCellID) {
+		if id.Row >= 0 && id.Row < len(peerRows) {
+			selectedPeer = peerRows[id.Row].Enode
+			peerRemoveBtn.Enable()
+		}
+	}
+	showPeers := func(running bool, peers []nodePeer, err error, enode string) {
+		nodeEnode = enode
+		if enode != "" {
+			nodeEnodeValue.SetText(enode)
+			nodeEnodeCopyBtn.Enable()
+		} else {
+			nodeEnodeValue.SetText("—")
+			nodeEnodeCopyBtn.Disable()
+		}
+		switch {
+		case !running:
+			peers = nil
+			peersSummary.SetText("Start the node to see its peers.")
+		case err != nil:
+			peers = nil
+			peersSummary.SetText(fmt.Sprintf("Peers unavailable: %v. A node started by an older version lacks the admin API; restart it.", err))
+		case len(peers) == 0:
+			peersSummary.SetText("No peers connected.")
+		default:
+			inbound := 0
+			for _, p := range peers {
+				if p.Inbound {
+					inbound++
+				}
+			}
+			peersSummary.SetText(fmt.Sprintf("%d peers: %d outbound, %d inbound", len(peers), len(peers)-inbound, inbound))
+		}
+		peerRows = peers
+		if !slices.ContainsFunc(peerRows, func(p nodePeer) bool { return p.Enode == selectedPeer }) {
+			selectedPeer = ""
+			peersTable.UnselectAll()
+			peerRemoveBtn.Disable()
+		}
+		peersTable.Refresh()
+	}
+
+	peerAddEntry := widget.NewEntry()
+	peerAddEntry.SetPlaceHolder("enode://…@host:port")
+	peerAddKinds := []string{"Connect now", "Static (saved)", "Trusted (saved)"}
+	peerAddKindSelect := widget.NewSelect(peerAddKinds, nil)
+	peerAddKindSelect.SetSelectedIndex(0)
+	peerAddBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		enode := strings.TrimSpace(peerAddEntry.Text)
+		if !isEnodeURL(enode) {
+			peersStatus.SetText("Invalid enode URL (expected enode://<node id>@host:port).")
+			return
+		}
+		kind := peerAddKindSelect.SelectedIndex()
+		switch kind {
+		case 1:
+			cfg.NodeStaticPeers = append(withoutPeer(cfg.NodeStaticPeers, enode), enode)
+			nodeStaticPeersEntry.SetText(strings.Join(cfg.NodeStaticPeers, "\n"))
+		case 2:
+			cfg.NodeTrustedPeers = append(withoutPeer(cfg.NodeTrustedPeers, enode), enode)
+			nodeTrustedPeersEntry.SetText(strings.Join(cfg.NodeTrustedPeers, "\n"))
+		}
+		if kind > 0 {
+			if err := ctrl.Replace(cfg); err != nil {
+				peersStatus.SetText(fmt.Sprintf("Failed to save peer: %v", err))
+				return
+			}
+		}
+		peerAddEntry.SetText("")
+		if !ctrl.NodeRunning() {
+			peersStatus.SetText("Saved; the node connects on its next start.")
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var err error
+			if kind == 2 {
+				err = rpcPeerCall(ctx, nodeRPCEndpoint(), "admin_addTrustedPeer", enode)
+			}
+			if err == nil {
+				err = rpcPeerCall(ctx, nodeRPCEndpoint(), "admin_addPeer", enode)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					peersStatus.SetText(fmt.Sprintf("Failed to add peer: %v", err))
+					return
+				}
+				peersStatus.SetText(fmt.Sprintf("Connecting to %s.", shortEnode(enode)))
+			})
+		}()
+	})
+	peerRemoveBtn = widget.NewButtonWithIcon("Remove selected", theme.ContentRemoveIcon(), func() {
+		enode := selectedPeer
+		if enode == "" {
+			return
+		}
+		saved := len(withoutPeer(cfg.NodeStaticPeers, enode)) != len(cfg.NodeStaticPeers) ||
+			len(withoutPeer(cfg.NodeTrustedPeers, enode)) != len(cfg.NodeTrustedPeers)
+		if saved {
+			cfg.NodeStaticPeers = withoutPeer(cfg.NodeStaticPeers, enode)
+			cfg.NodeTrustedPeers = withoutPeer(cfg.NodeTrustedPeers, enode)
+			nodeStaticPeersEntry.SetText(strings.Join(cfg.NodeStaticPeers, "\n"))
+			nodeTrustedPeersEntry.SetText(strings.Join(cfg.NodeTrustedPeers, "\n"))
+			if err := ctrl.Replace(cfg); err != nil {
+				peersStatus.SetText(fmt.Sprintf("Failed to save peers: %v", err))
+				return
+			}
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = rpcPeerCall(ctx, nodeRPCEndpoint(), "admin_removeTrustedPeer", enode)
+			err := rpcPeerCall(ctx, nodeRPCEndpoint(), "admin_removePeer", enode)
+			fyne.Do(func() {
+				switch {
+				case err != nil:
+					peersStatus.SetText(fmt.Sprintf("Failed to remove peer: %v", err))
+				case saved:
+					peersStatus.SetText(fmt.Sprintf("Disconnected %s and removed it from the saved peers.", shortEnode(enode)))
+				default:
+					peersStatus.SetText(fmt.Sprintf("Disconnected %s.", shortEnode(enode)))
+				}
+			})
+		}()
+	})
+	peerRemoveBtn.Disable()
+	peersBar := container.NewBorder(nil, nil, nil, container.NewHBox(peerAddKindSelect, peerAddBtn, peerRemoveBtn), peerAddEntry)
+	peersBody := container.NewBorder(
+		container.NewVBox(peersSummary, peersHeaderRow, widget.NewSeparator()),
+		container.NewVBox(widget.NewSeparator(), peersBar, peersStatus),
+		nil, nil,
+		peersTable,
+	)
+	nodePeersPanel := panel("Peers", peersBody)
+	nodeTab := container.NewPadded(container.NewBorder(
+		container.NewGridWithColumns(2, nodeSyncPanel, nodeChainPanel),
+		nil, nil, nil,
+		nodePeersPanel,
+	))
+	showNodeStatus(false, nodeStatus{}, nil, 0, false, time.Now())
+	go func() {
+		ticker := time.NewTicker(nodePollInterval)
+		defer ticker.Stop()
+		var rate syncRate
+		enode := ""
+		wasRunning := false
+		for range ticker.C {
+			running := ctrl.NodeRunning()
+			if !running {
+				rate.Reset()
+				enode = ""
+				if wasRunning {
+					fyne.Do(func() {
+						showNodeStatus(false, nodeStatus{}, nil, 0, false, time.Now())
+						showPeers(false, nil, nil, "")
+					})
+				}
+				wasRunning = false
+				continue
+			}
+			wasRunning = true
+			endpoint := nodeRPCEndpoint()
+			st, err := queryNodeStatus(context.Background(), endpoint)
+			peersCtx, cancel := context.WithTimeout(context.Background(), nodeRPCTimeout)
+			peers, peersErr := rpcAdminPeers(peersCtx, endpoint)
+			if enode == "" && peersErr == nil {
+				enode, _ = rpcNodeEnode(peersCtx, endpoint)
+			}
+			cancel()
+			nodeEnodeNow := enode
+			now := time.Now()
+			if err == nil {
+				current := st.Head
+				if st.Sync != nil {
+					current = st.Sync.CurrentBlock
+				}
+				rate.Add(now, current)
+			}
+			blocksPerSec, rateOK := rate.BlocksPerSec()
+			fyne.Do(func() {
+				showNodeStatus(true, st, err, blocksPerSec, rateOK, now)
+				showPeers(true, peers, peersErr, nodeEnodeNow)
+				if err != nil || ctrl.NodeState() != nodeStateRunning {
+					return
+				}
+				if st.Sync != nil {
+					setNodeBadge(fmt.Sprintf("Node: Syncing %.0f%%", st.Sync.Fraction()*100), connConnectingColor)
+				} else {
+					setNodeBadge("Node: Running", connLiveColor)
+				}
+			})
+		}
+	}()
+
 	minerCopyLogsBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
 		w.Clipboard().SetContent(strings.Join(minerLogLines(), "\n"))
 	})
-	minerClearLogsBtn := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), resetMinerLog)
+	minerClearLogsBtn := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), ctrl.resetMinerLog)
 	minerLogBar := container.NewHBox(minerFollowTailCheck, layout.NewSpacer(), minerCopyLogsBtn, minerClearLogsBtn)
 
 	minerLogPanel := panel("Miner Logs", container.NewBorder(minerLogBar, nil, nil, nil, container.NewPadded(minerLogScroll)))
@@ -2798,7 +4302,7 @@ func main() {
 	nodeCopyLogsBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
 		w.Clipboard().SetContent(strings.Join(nodeLogLines(), "\n"))
 	})
-	nodeClearLogsBtn := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), resetNodeLog)
+	nodeClearLogsBtn := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), ctrl.resetNodeLog)
 	nodeLogBar := container.NewHBox(nodeFollowTailCheck, layout.NewSpacer(), nodeCopyLogsBtn, nodeClearLogsBtn)
 
 	nodeLogPanel := panel("Node Logs", container.NewBorder(nodeLogBar, nil, nil, nil, container.NewPadded(nodeLogScroll)))
@@ -2817,12 +4321,42 @@ func main() {
 	logToolbar := container.NewHBox(wrapLogsCheck, layout.NewSpacer())
 	logTab := container.NewPadded(container.NewBorder(logToolbar, nil, nil, nil, logTabs))
 
+	xmrigConfigText := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	xmrigConfigText.Selectable = true
+	xmrigConfigText.Wrapping = fyne.TextWrapOff
+	xmrigConfigPathLabel := widget.NewLabel("")
+	xmrigConfigPathLabel.Wrapping = fyne.TextWrapWord
+	xmrigConfigPathLabel.TextStyle = fyne.TextStyle{Italic: true}
+	if path, err := xmrigConfigPath(); err == nil {
+		xmrigConfigPathLabel.SetText(fmt.Sprintf("Generated on every start: %s (access token masked)", redactPath(path)))
+	}
+	refreshXMRigConfigView := func() {
+		text, err := readXMRigConfigForDisplay()
+		if err != nil {
+			text = err.Error()
+		}
+		xmrigConfigText.SetText(text)
+	}
+	xmrigConfigRefreshBtn := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), refreshXMRigConfigView)
+	xmrigConfigCopyBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		w.Clipboard().SetContent(xmrigConfigText.Text)
+	})
+	xmrigConfigBar := container.NewHBox(xmrigConfigPathLabel, layout.NewSpacer(), xmrigConfigCopyBtn, xmrigConfigRefreshBtn)
+	xmrigConfigPanel := panel("xmrig config.json", container.NewBorder(xmrigConfigBar, nil, nil, nil, container.NewScroll(xmrigConfigText)))
+	advancedTab := container.NewPadded(xmrigConfigPanel)
+
 	setupItem := container.NewTabItemWithIcon("Setup", theme.SettingsIcon(), setupTab)
 	dashboardItem := container.NewTabItemWithIcon("Dashboard", theme.HomeIcon(), dashboardTab)
+	blocksItem := container.NewTabItemWithIcon("Blocks", theme.GridIcon(), blocksTab)
+	nodeItem := container.NewTabItemWithIcon("Node", theme.StorageIcon(), nodeTab)
 	logsItem := container.NewTabItemWithIcon("Logs", theme.ListIcon(), logTab)
-	tabs := container.NewAppTabs(setupItem, dashboardItem, logsItem)
+	advancedItem := container.NewTabItemWithIcon("Advanced", theme.DocumentIcon(), advancedTab)
+	tabs := container.NewAppTabs(setupItem, dashboardItem, blocksItem, nodeItem, logsItem, advancedItem)
 	logsTabActive.Store(false)
 	tabs.OnSelected = func(item *container.TabItem) {
+		if item == advancedItem {
+			refreshXMRigConfigView()
+		}
 		logsTabActive.Store(item == logsItem)
 		if item == logsItem {
 			selected := logTabs.Selected()
@@ -2831,6 +4365,209 @@ func main() {
 		}
 	}
 
+	// showConfigRecovery tells that config.json failed to load and offers
+	// to restore one of its backups over the defaults the app started with.
+	showConfigRecovery := func(loadErr error, invalidPath string) {
+		message := fmt.Sprintf("The settings could not be loaded:\n%v\n\nThe app started with default settings.", loadErr)
+		if invalidPath != "" {
+			message += fmt.Sprintf(" The file was kept as %s.", redactPath(invalidPath))
+		}
+		text := widget.NewLabel(message)
+		text.Wrapping = fyne.TextWrapWord
+		path, err := configPath()
+		var backups []configBackup
+		if err == nil {
+			backups = listConfigBackups(path)
+		}
+		if len(backups) == 0 {
+			d := dialog.NewCustom("Settings not loaded", "Use defaults", text, w)
+			d.Resize(fyne.NewSize(520, d.MinSize().Height))
+			d.Show()
+			return
+		}
+		labels := make([]string, len(backups))
+		for i, b := range backups {
+			labels[i] = fmt.Sprintf("%s (saved %s)", filepath.Base(b.Path), b.ModTime.Format("2006-01-02 15:04"))
+		}
+		backupSelect := widget.NewSelect(labels, nil)
+		backupSelect.SetSelectedIndex(0)
+		content := container.NewVBox(text, fieldLabel("Backup"), backupSelect)
+		d := dialog.NewCustomConfirm("Settings not loaded", "Restore backup", "Use defaults", content, func(ok bool) {
+			i := backupSelect.SelectedIndex()
+			if !ok || i < 0 {
+				return
+			}
+			restored, err := readConfigFile(backups[i].Path)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			*cfg = *restored
+			if err := ctrl.Replace(cfg); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			applyConfigToUI()
+			applyMetrics()
+			applyAPI()
+			appendMinerLog(fmt.Sprintf("[config] Restored %s\n", filepath.Base(backups[i].Path)))
+		}, w)
+		d.Resize(fyne.NewSize(520, d.MinSize().Height))
+		d.Show()
+	}
+
+	// updateProfiles saves a change to the profile list made on a copy.
+	updateProfiles := func(change func(next *Config) error) {
+		next := *cfg
+		if err := change(&next); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		normalizeConfig(&next)
+		if err := validateConfig(&next); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		*cfg = next
+		if err := ctrl.Replace(cfg); err != nil {
+			dialog.ShowError(err, w)
+		}
+		refreshProfileSelect()
+	}
+	switchProfileUser := func(name string) {
+		minerWasRunning := ctrl.MinerRunning()
+		nodeWasRunning := ctrl.NodeRunning()
+		saveDraftFromUI()
+		prev := cloneConfig(cfg)
+		next := *cfg
+		if err := switchProfile(&next, name); err != nil {
+			refreshProfileSelect()
+			dialog.ShowError(err, w)
+			return
+		}
+		normalizeConfig(&next)
+		if err := validateConfig(&next); err != nil {
+			refreshProfileSelect()
+			dialog.ShowError(err, w)
+			return
+		}
+		*cfg = next
+		if err := ctrl.Replace(cfg); err != nil {
+			dialog.ShowError(err, w)
+		}
+		applyConfigToUI()
+		refreshPendingRestart()
+		appendMinerLog(fmt.Sprintf("[config] Switched to profile %q\n", cfg.ActiveProfile))
+
+		restartNode := nodeWasRunning && nodeSettingsChanged(prev, cfg)
+		if !minerWasRunning && !restartNode {
+			return
+		}
+		requireMiningService := minerWasRunning && cfg.Mode == modeRPCLocal
+		settings, err := nodeSettingsFromConfig(cfg, requireMiningService)
+		if restartNode && err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		go func() {
+			ctx := context.Background()
+			if minerWasRunning {
+				ctrl.StopMiner(minerStopOriginUser)
+				ctrl.waitForMinerExit(ctx, 15*time.Second)
+			}
+			if restartNode {
+				ctrl.StopNode()
+				ctrl.waitForNodeExit(ctx, 65*time.Second)
+				if settings.Enabled {
+					if err := ctrl.StartNode(settings, requireMiningService); err != nil {
+						fyne.Do(func() { dialog.ShowError(err, w) })
+						return
+					}
+					if requireMiningService {
+						waitForTCP(ctx, fmt.Sprintf("127.0.0.1:%d", settings.RPCPort), 2*time.Minute)
+					}
+				}
+			}
+			if minerWasRunning {
+				if err := ctrl.StartMiner(minerStartOriginUser); err != nil {
+					fyne.Do(func() { dialog.ShowError(err, w) })
+				}
+			}
+		}()
+	}
+	profileSelect.OnChanged = func(name string) {
+		if name == "" || strings.EqualFold(name, cfg.ActiveProfile) {
+			return
+		}
+		if !ctrl.MinerRunning() && !ctrl.NodeRunning() {
+			switchProfileUser(name)
+			return
+		}
+		dialog.ShowConfirm("Switch profile", fmt.Sprintf("Switching to %q restarts the running miner, and the node if its settings differ.\nSwitch now?", name), func(ok bool) {
+			if !ok {
+				refreshProfileSelect()
+				return
+			}
+			switchProfileUser(name)
+		}, w)
+	}
+	showProfileNameForm := func(title, confirm, initial string, apply func(next *Config, name string) error) {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(initial)
+		d := dialog.NewForm(title, confirm, "Cancel", []*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+		}, func(ok bool) {
+			if ok {
+				updateProfiles(func(next *Config) error { return apply(next, strings.TrimSpace(nameEntry.Text)) })
+			}
+		}, w)
+		d.Resize(fyne.NewSize(520, d.MinSize().Height))
+		d.Show()
+	}
+	var profileMenuBtn *widget.Button
+	profileMenuBtn = widget.NewButtonWithIcon("", theme.MoreVerticalIcon(), func() {
+		active := cfg.ActiveProfile
+		duplicate := fyne.NewMenuItem("Duplicate…", func() {
+			showProfileNameForm("Duplicate profile", "Duplicate", active+" copy", func(next *Config, name string) error {
+				return duplicateProfile(next, active, name)
+			})
+		})
+		rename := fyne.NewMenuItem("Rename…", func() {
+			showProfileNameForm("Rename profile", "Rename", active, func(next *Config, name string) error {
+				return renameProfile(next, active, name)
+			})
+		})
+		var others []string
+		for _, name := range profileNames(cfg) {
+			if !strings.EqualFold(name, active) {
+				others = append(others, name)
+			}
+		}
+		remove := fyne.NewMenuItem("Delete…", func() {
+			pick := widget.NewSelect(others, nil)
+			pick.SetSelectedIndex(0)
+			d := dialog.NewForm("Delete profile", "Delete", "Cancel", []*widget.FormItem{
+				widget.NewFormItem("Profile", pick),
+			}, func(ok bool) {
+				if ok && pick.Selected != "" {
+					name := pick.Selected
+					updateProfiles(func(next *Config) error { return deleteProfile(next, name) })
+				}
+			}, w)
+			d.Resize(fyne.NewSize(520, d.MinSize().Height))
+			d.Show()
+		})
+		remove.Disabled = len(others) == 0
+		driver := fyne.CurrentApp().Driver()
+		pos := driver.AbsolutePositionForObject(profileMenuBtn).AddXY(0, profileMenuBtn.Size().Height)
+		widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", duplicate, rename, remove), driver.CanvasForObject(profileMenuBtn), pos)
+	})
+	profileTile := container.NewVBox(
+		layout.NewSpacer(),
+		container.NewBorder(nil, nil, nil, profileMenuBtn, profileSelect),
+		layout.NewSpacer(),
+	)
+
 	headerTitle := canvas.NewText(appName, theme.Color(theme.ColorNamePrimary))
 	headerTitle.TextStyle = fyne.TextStyle{Bold: true}
 	headerTitle.TextSize = theme.TextSize() * 2.1
@@ -2854,10 +4591,12 @@ func main() {
 		return fixedSize(headerTileSize, obj)
 	}
 	headerRight := container.NewHBox(
+		wrapHeaderTile(profileTile),
 		wrapHeaderTile(nodeBadge),
 		wrapHeaderTile(connectionBadge),
 		wrapHeaderTile(statusPill),
 		wrapHeaderTile(startBtn),
+		wrapHeaderTile(pauseBtn),
 		wrapHeaderTile(stopBtn),
 	)
 	headerRow := container.NewHBox(headerLeft, layout.NewSpacer(), headerRight)
@@ -2881,12 +4620,74 @@ func main() {
 	} else {
 		refreshDevices()
 	}
+	if cfgErr != nil {
+		appendMinerLog(fmt.Sprintf("[config] %v\n", cfgErr))
+		showConfigRecovery(cfgErr, cfgInvalidPath)
+	}
+	if historyErr != nil {
+		appendMinerLog(fmt.Sprintf("[history] %v\n", historyErr))
+	}
+	if ledgerErr != nil {
+		appendMinerLog(fmt.Sprintf("[blocks] %v\n", ledgerErr))
+	}
+	ledger.Start(ctrl, func() { fyne.Do(refreshBlocks) })
+	wallet, walletErr := startWalletTracker(ctrl, func() { fyne.Do(refreshWallet) })
+	if walletErr != nil {
+		appendMinerLog(fmt.Sprintf("[wallet] %v\n", walletErr))
+	}
+
+	sensors := newSensorReader(cfg.SysfsRoot)
+	go func() {
+		ticker := time.NewTicker(3 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			devMu.Lock()
+			list := append([]Device(nil), devices...)
+			devMu.Unlock()
+			readings := sensors.Read(list)
+			logSensorMu.Lock()
+			logSensors = readings
+			logSensorMu.Unlock()
+			fyne.Do(refreshStatsTable)
+		}
+	}()
+	applyMetrics()
+	applyAPI()
+	refreshHashrateHistory()
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			fyne.Do(func() {
+				if historyRange != historyRangeLabels[0] {
+					refreshHashrateHistory()
+				}
+			})
+		}
+	}()
+
+	scheduler := startScheduler(ctrl)
+	governor := startIdleGovernor(ctrl, systemIdleDetector{}, newProcLoadSampler())
+	thermal := startThermalGovernor(ctrl, newSensorReader(cfg.SysfsRoot))
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			text := scheduler.StatusText()
+			fyne.Do(func() {
+				if text == "" {
+					scheduleTile.Hide()
+					return
+				}
+				scheduleValue.SetText(text)
+				scheduleTile.Show()
+			})
+		}
+	}()
 
 	w.SetCloseIntercept(func() {
-		procMu.Lock()
-		minerRunning := minerCmd != nil && minerCmd.Process != nil
-		nodeRunning := nodeCmd != nil && nodeCmd.Process != nil
-		procMu.Unlock()
+		minerRunning := ctrl.MinerRunning()
+		nodeRunning := ctrl.NodeRunning()
 		if !minerRunning && !nodeRunning {
 			saveDraftFromUI()
 			w.Close()
@@ -2914,48 +4715,138 @@ func main() {
 		appendMinerLog("Tip: You can run this as AppImage and launch from desktop.\n")
 	}
 	w.ShowAndRun()
+	close(estimateStop)
+	scheduler.Close()
+	governor.Close()
+	thermal.Close()
+	api.Close()
+	metrics.Close()
+	history.Close()
+	ledger.Close()
+	wallet.Close()
 }
 
-func loadConfig() *Config {
-	cfg := &Config{
-		Mode:          modeStratum,
-		StratumHost:   defaultStratumHost,
-		StratumPort:   defaultStratumPort,
-		RPCURL:        defaultRPCURL,
-		WalletAddress: "",
-		WorkerName:    "",
-
-		CPUThreads:      0,
-		CPUAffinity:     nil,
-		UseHugePages:    true,
-		EnableMSR:       true,
-		AutoGrantMSR:    true,
-		DonateLevel:     0,
-		DisplayInterval: 10,
+// loadConfig reads config.json over the defaults and migrates it to the
+// current schema. A missing file gives the defaults; a file that cannot be
+// read or parsed gives the defaults and the error, and is left as it is.
+func loadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return defaultConfig(), nil
+	}
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return defaultConfig(), nil
+		}
+		return defaultConfig(), err
+	}
+	return cfg, nil
+}
+
+// readConfigFile reads and migrates a config file, e.g. config.json or one
+// of its backups.
+func readConfigFile(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return cfg, nil
+}
+
+// parseConfig decodes and migrates config JSON over the defaults.
+func parseConfig(b []byte) (*Config, error) {
+	cfg := defaultConfig()
+	if err := decodeConfig(b, cfg); err != nil {
+		return nil, err
+	}
+	fillConfigDefaults(cfg)
+	for i, p := range cfg.Profiles {
+		c := *cfg
+		applyProfile(&c, p)
+		fillConfigDefaults(&c)
+		cfg.Profiles[i] = profileFromConfig(&c, strings.TrimSpace(p.Name))
+	}
+	syncActiveProfile(cfg)
+	return cfg, nil
+}
 
-		NodeEnabled:   false,
-		NodeMode:      nodeModeSync,
-		NodeDataDir:   "",
-		NodeRPCPort:   defaultNodeRPCPort,
-		NodeP2PPort:   defaultNodeP2PPort,
-		NodeBootnodes: defaultNodeBootnodes,
-		NodeVerbosity: defaultNodeVerbosity,
-		NodeEtherbase: "",
+func defaultConfig() *Config {
+	return &Config{
+		ProfileSettings: ProfileSettings{
+			Mode:          modeStratum,
+			StratumHost:   defaultStratumHost,
+			StratumPort:   defaultStratumPort,
+			RPCURL:        defaultRPCURL,
+			WalletAddress: "",
+			WorkerName:    "",
+
+			PoolFailbackEnabled: false,
+			PoolFailbackMin:     defaultPoolFailbackMin,
+
+			CPUThreads:      0,
+			CPUAffinity:     nil,
+			UseHugePages:    true,
+			EnableMSR:       true,
+			AutoGrantMSR:    true,
+			RandomXMode:     randomXModeAuto,
+			RandomX1GBPages: false,
+			CPUPriority:     cpuPriorityDefault,
+			CPUYield:        true,
+			DonateLevel:     0,
+
+			NodeSettings: NodeSettings{
+				NodeEnabled:   false,
+				NodeMode:      nodeModeSync,
+				NodeDataDir:   "",
+				NodeRPCPort:   defaultNodeRPCPort,
+				NodeP2PPort:   defaultNodeP2PPort,
+				NodeBootnodes: defaultNodeBootnodes,
+				NodeVerbosity: defaultNodeVerbosity,
+				NodeEtherbase: "",
+			},
+		},
+		DisplayInterval: 10,
 
 		WatchdogEnabled:         false,
 		WatchdogNoJobTimeoutSec: 120,
 		WatchdogRestartDelaySec: 10,
 		WatchdogRetryWindowMin:  10,
+
+		IdleEnabled:       false,
+		IdleAfterMin:      defaultIdleAfterMin,
+		IdleActiveAction:  throttleActionPause,
+		IdleActiveThreads: 1,
+		IdleLoadLimit:     0,
+
+		ThermalEnabled: false,
+		ThermalLimitC:  defaultThermalLimitC,
+		ThermalResumeC: defaultThermalResumeC,
+		ThermalAction:  throttleActionPause,
+
+		HistoryEnabled:             true,
+		HistoryRawRetentionHours:   defaultHistoryRawRetentionHours,
+		HistoryMinuteRetentionDays: defaultHistoryMinuteRetentionDays,
+		HistoryHourRetentionDays:   defaultHistoryHourRetentionDays,
+		HistoryDayRetentionDays:    defaultHistoryDayRetentionDays,
+
+		MetricsEnabled:     false,
+		MetricsBindAddress: defaultMetricsBindAddress,
+		MetricsPort:        defaultMetricsPort,
+
+		APIEnabled:     false,
+		APIBindAddress: defaultAPIBindAddress,
+		APIPort:        defaultAPIPort,
 	}
-	path, err := configPath()
-	if err != nil {
-		return cfg
-	}
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return cfg
-	}
-	_ = json.Unmarshal(b, cfg)
+}
+
+// fillConfigDefaults replaces missing or out of range values read from
+// config.json with their defaults.
+func fillConfigDefaults(cfg *Config) {
 	if cfg.StratumHost == "" {
 		cfg.StratumHost = defaultStratumHost
 	}
@@ -2971,18 +4862,42 @@ func loadConfig() *Config {
 	if cfg.RPCURL == "" {
 		cfg.RPCURL = defaultRPCURL
 	}
+	if cfg.PoolFailbackMin <= 0 {
+		cfg.PoolFailbackMin = defaultPoolFailbackMin
+	}
+	if cfg.IdleAfterMin <= 0 {
+		cfg.IdleAfterMin = defaultIdleAfterMin
+	}
+	if cfg.IdleActiveAction != throttleActionThreads {
+		cfg.IdleActiveAction = throttleActionPause
+	}
+	if cfg.IdleActiveThreads <= 0 {
+		cfg.IdleActiveThreads = 1
+	}
+	if cfg.ThermalLimitC <= 0 {
+		cfg.ThermalLimitC = defaultThermalLimitC
+	}
+	if cfg.ThermalResumeC <= 0 {
+		cfg.ThermalResumeC = defaultThermalResumeC
+	}
+	if cfg.ThermalAction != throttleActionThreads {
+		cfg.ThermalAction = throttleActionPause
+	}
 	if cfg.DisplayInterval == 0 {
 		cfg.DisplayInterval = 10
 	}
 	if cfg.CPUThreads < 0 {
 		cfg.CPUThreads = 0
 	}
-	if len(cfg.CPUAffinity) == 0 && len(cfg.SelectedDevices) > 0 {
-		cfg.CPUAffinity = append([]int(nil), cfg.SelectedDevices...)
-	}
 	if cfg.DonateLevel < 0 || cfg.DonateLevel > 100 {
 		cfg.DonateLevel = 0
 	}
+	if cfg.RandomXMode != randomXModeFast && cfg.RandomXMode != randomXModeLight {
+		cfg.RandomXMode = randomXModeAuto
+	}
+	if cfg.CPUPriority < cpuPriorityDefault || cfg.CPUPriority > 5 {
+		cfg.CPUPriority = cpuPriorityDefault
+	}
 	if cfg.NodeMode != nodeModeSync && cfg.NodeMode != nodeModeMine {
 		cfg.NodeMode = nodeModeSync
 	}
@@ -3003,12 +4918,10 @@ func loadConfig() *Config {
 	if cfg.NodeVerbosity == 0 {
 		cfg.NodeVerbosity = defaultNodeVerbosity
 	}
-	if cfg.NodeEtherbase != "" {
-		if !isHexAddress(cfg.NodeEtherbase) {
-			cfg.NodeEtherbase = ""
-		} else {
-			cfg.NodeEtherbase = strings.ToLower(cfg.NodeEtherbase)
-		}
+	// The case is kept so validateConfig can check the EIP-55 checksum;
+	// normalizeConfig lowercases the address once it passes.
+	if cfg.NodeEtherbase != "" && !isHexAddress(cfg.NodeEtherbase) {
+		cfg.NodeEtherbase = ""
 	}
 	if cfg.WatchdogNoJobTimeoutSec <= 0 {
 		cfg.WatchdogNoJobTimeoutSec = 120
@@ -3019,7 +4932,30 @@ func loadConfig() *Config {
 	if cfg.WatchdogRetryWindowMin <= 0 {
 		cfg.WatchdogRetryWindowMin = 10
 	}
-	return cfg
+	if cfg.HistoryRawRetentionHours <= 0 {
+		cfg.HistoryRawRetentionHours = defaultHistoryRawRetentionHours
+	}
+	if cfg.HistoryMinuteRetentionDays <= 0 {
+		cfg.HistoryMinuteRetentionDays = defaultHistoryMinuteRetentionDays
+	}
+	if cfg.HistoryHourRetentionDays <= 0 {
+		cfg.HistoryHourRetentionDays = defaultHistoryHourRetentionDays
+	}
+	if cfg.HistoryDayRetentionDays <= 0 {
+		cfg.HistoryDayRetentionDays = defaultHistoryDayRetentionDays
+	}
+	if strings.TrimSpace(cfg.MetricsBindAddress) == "" {
+		cfg.MetricsBindAddress = defaultMetricsBindAddress
+	}
+	if cfg.MetricsPort <= 0 || cfg.MetricsPort > 65535 {
+		cfg.MetricsPort = defaultMetricsPort
+	}
+	if strings.TrimSpace(cfg.APIBindAddress) == "" {
+		cfg.APIBindAddress = defaultAPIBindAddress
+	}
+	if cfg.APIPort <= 0 || cfg.APIPort > 65535 {
+		cfg.APIPort = defaultAPIPort
+	}
 }
 
 func saveConfig(cfg *Config) error {
@@ -3030,14 +4966,247 @@ func saveConfig(cfg *Config) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
+	syncActiveProfile(cfg)
+	cfg.SchemaVersion = configSchemaVersion
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0o644)
+	if old, err := os.ReadFile(path); err == nil {
+		if bytes.Equal(old, b) {
+			return nil
+		}
+		if err := rotateConfigBackups(path, old); err != nil {
+			return fmt.Errorf("failed to back up config: %w", err)
+		}
+	}
+	return writeFileAtomic(path, b, 0o644)
+}
+
+var workerNamePattern = regexp.MustCompile(`^[0-9A-Za-z_-]{1,16}$`)
+
+// normalizeConfig canonicalizes values that have more than one accepted
+// spelling before validation and saving.
+func normalizeConfig(cfg *Config) {
+	cfg.StratumHost = strings.TrimSpace(cfg.StratumHost)
+	cfg.WalletAddress = normalizeAddress(cfg.WalletAddress)
+	cfg.WorkerName = strings.TrimSpace(cfg.WorkerName)
+	if normalized, err := normalizeRPCURL(cfg.RPCURL); err == nil {
+		cfg.RPCURL = normalized
+	}
+	cfg.WalletRPCURL = strings.TrimSpace(cfg.WalletRPCURL)
+	if cfg.WalletRPCURL != "" {
+		if normalized, err := normalizeRPCURL(cfg.WalletRPCURL); err == nil {
+			cfg.WalletRPCURL = normalized
+		}
+	}
+	for i := range cfg.Pools {
+		normalizePool(&cfg.Pools[i])
+	}
+	for i := range cfg.Schedule {
+		normalizeScheduleWindow(&cfg.Schedule[i])
+	}
+	cfg.IdleActiveAction = strings.ToLower(strings.TrimSpace(cfg.IdleActiveAction))
+	cfg.ThermalAction = strings.ToLower(strings.TrimSpace(cfg.ThermalAction))
+	cfg.NodeEtherbase = normalizeAddress(cfg.NodeEtherbase)
+	normalizeAddressBook(cfg.AddressBook)
+	cfg.ActiveProfile = strings.TrimSpace(cfg.ActiveProfile)
+	for i := range cfg.Profiles {
+		cfg.Profiles[i].Name = strings.TrimSpace(cfg.Profiles[i].Name)
+	}
+}
+
+// validateConfig checks a configuration before it is saved. The Setup form
+// and the control API both go through it.
+func validateConfig(cfg *Config) error {
+	switch cfg.Mode {
+	case modeStratum, modeRPCLocal, modeRPCGateway:
+	default:
+		return fmt.Errorf("invalid mode %q", cfg.Mode)
+	}
+	if cfg.Mode == modeStratum {
+		if cfg.StratumHost == "" {
+			return errors.New("stratum host is required")
+		}
+		if cfg.StratumPort < 1 || cfg.StratumPort > 65535 {
+			return errors.New("invalid stratum port")
+		}
+		if cfg.WorkerName != "" && !workerNamePattern.MatchString(cfg.WorkerName) {
+			return errors.New("invalid worker name (allowed: 0-9 A-Z a-z _ -; max 16)")
+		}
+	} else if _, err := normalizeRPCURL(cfg.RPCURL); err != nil {
+		return err
+	}
+	if cfg.Mode != modeRPCLocal && !isHexAddress(cfg.WalletAddress) {
+		return errors.New("invalid wallet address (expected 0x + 40 hex chars)")
+	}
+	if err := validateAddressChecksum("wallet address", cfg.WalletAddress); err != nil {
+		return err
+	}
+	if err := validateAddressChecksum("node mining address", cfg.NodeEtherbase); err != nil {
+		return err
+	}
+	if err := validateAddressBook(cfg.AddressBook); err != nil {
+		return err
+	}
+	if len(cfg.Pools) > maxBackupPools {
+		return fmt.Errorf("too many backup pools (max %d)", maxBackupPools)
+	}
+	for i, p := range cfg.Pools {
+		if err := validatePool(p); err != nil {
+			return fmt.Errorf("backup pool %d: %w", i+1, err)
+		}
+	}
+	if cfg.PoolFailbackEnabled && (cfg.PoolFailbackMin < 1 || cfg.PoolFailbackMin > 1440) {
+		return errors.New("invalid failback time (1..1440 minutes)")
+	}
+	if err := validatePeerList("static", cfg.NodeStaticPeers); err != nil {
+		return err
+	}
+	if err := validatePeerList("trusted", cfg.NodeTrustedPeers); err != nil {
+		return err
+	}
+
+	if cfg.CPUThreads < 0 || cfg.CPUThreads > 4096 {
+		return errors.New("invalid CPU threads value (0..4096)")
+	}
+	seenCPU := make(map[int]bool, len(cfg.CPUAffinity))
+	for _, id := range cfg.CPUAffinity {
+		if id < 0 {
+			return fmt.Errorf("invalid CPU index %d", id)
+		}
+		if seenCPU[id] {
+			return fmt.Errorf("CPU %d is selected more than once", id)
+		}
+		seenCPU[id] = true
+	}
+	if cfg.DisplayInterval < 1 || cfg.DisplayInterval > 1800 {
+		return errors.New("invalid display interval (1..1800)")
+	}
+	if cfg.DonateLevel < 0 || cfg.DonateLevel > 100 {
+		return errors.New("invalid donate level (0..100)")
+	}
+	switch cfg.RandomXMode {
+	case randomXModeAuto, randomXModeFast, randomXModeLight:
+	default:
+		return fmt.Errorf("invalid RandomX mode %q (auto, fast or light)", cfg.RandomXMode)
+	}
+	if cfg.CPUPriority < cpuPriorityDefault || cfg.CPUPriority > 5 {
+		return errors.New("invalid CPU priority (0..5, or -1 for default)")
+	}
+
+	if cfg.NodeMode != nodeModeSync && cfg.NodeMode != nodeModeMine {
+		return fmt.Errorf("invalid node mode %q", cfg.NodeMode)
+	}
+	if cfg.NodeRPCPort < 1 || cfg.NodeRPCPort > 65535 {
+		return errors.New("invalid node RPC port")
+	}
+	if cfg.NodeP2PPort < 1 || cfg.NodeP2PPort > 65535 {
+		return errors.New("invalid node P2P port")
+	}
+	if cfg.NodeVerbosity < 0 || cfg.NodeVerbosity > 5 {
+		return errors.New("invalid node verbosity (0..5)")
+	}
+	if cfg.NodeEtherbase != "" && !isHexAddress(cfg.NodeEtherbase) {
+		return errors.New("invalid node mining address (expected 0x + 40 hex chars)")
+	}
+
+	if len(cfg.Schedule) > maxScheduleWindows {
+		return fmt.Errorf("too many schedule windows (max %d)", maxScheduleWindows)
+	}
+	for i, w := range cfg.Schedule {
+		if err := validateScheduleWindow(w); err != nil {
+			return fmt.Errorf("schedule window %d: %w", i+1, err)
+		}
+	}
+	if cfg.ScheduleEnabled && len(cfg.Schedule) == 0 {
+		return errors.New("schedule is enabled but has no windows")
+	}
+
+	if cfg.IdleEnabled {
+		if cfg.IdleAfterMin < 1 || cfg.IdleAfterMin > 1440 {
+			return errors.New("invalid idle time (1..1440 minutes)")
+		}
+		if cfg.IdleActiveAction != throttleActionThreads && cfg.IdleActiveAction != throttleActionPause {
+			return fmt.Errorf("invalid idle action %q (threads or pause)", cfg.IdleActiveAction)
+		}
+		if cfg.IdleActiveAction == throttleActionThreads && (cfg.IdleActiveThreads < 1 || cfg.IdleActiveThreads > 4096) {
+			return errors.New("invalid threads while active (1..4096)")
+		}
+	}
+	if cfg.IdleLoadLimit < 0 || cfg.IdleLoadLimit > 100 {
+		return errors.New("invalid CPU load limit (0..100 percent)")
+	}
+
+	if cfg.ThermalEnabled {
+		if cfg.ThermalLimitC < 40 || cfg.ThermalLimitC > 110 {
+			return errors.New("invalid temperature limit (40..110 °C)")
+		}
+		if cfg.ThermalResumeC < 30 || cfg.ThermalResumeC >= cfg.ThermalLimitC {
+			return errors.New("invalid resume temperature (30 °C up to below the limit)")
+		}
+		if cfg.ThermalAction != throttleActionThreads && cfg.ThermalAction != throttleActionPause {
+			return fmt.Errorf("invalid thermal action %q (threads or pause)", cfg.ThermalAction)
+		}
+	}
+	if cfg.BlockReward < 0 {
+		return errors.New("invalid block reward (0 or more OLIVO)")
+	}
+	if cfg.WalletRPCURL != "" {
+		if _, err := normalizeRPCURL(cfg.WalletRPCURL); err != nil {
+			return errors.New("invalid balance RPC URL")
+		}
+	}
+
+	if cfg.WatchdogEnabled {
+		if cfg.WatchdogNoJobTimeoutSec < 5 || cfg.WatchdogNoJobTimeoutSec > 3600 {
+			return errors.New("invalid watchdog no-job timeout (5..3600 seconds)")
+		}
+		if cfg.WatchdogRestartDelaySec < 1 || cfg.WatchdogRestartDelaySec > 600 {
+			return errors.New("invalid watchdog restart delay (1..600 seconds)")
+		}
+		if cfg.WatchdogRetryWindowMin < 1 || cfg.WatchdogRetryWindowMin > 1440 {
+			return errors.New("invalid watchdog retry window (1..1440 minutes)")
+		}
+	}
+
+	if cfg.HistoryEnabled {
+		if cfg.HistoryRawRetentionHours < 1 || cfg.HistoryRawRetentionHours > 168 {
+			return errors.New("invalid raw history retention (1..168 hours)")
+		}
+		if cfg.HistoryMinuteRetentionDays < 1 || cfg.HistoryMinuteRetentionDays > 90 {
+			return errors.New("invalid 1-minute history retention (1..90 days)")
+		}
+		if cfg.HistoryHourRetentionDays < 1 || cfg.HistoryHourRetentionDays > 1825 {
+			return errors.New("invalid 1-hour history retention (1..1825 days)")
+		}
+		if cfg.HistoryDayRetentionDays < 1 || cfg.HistoryDayRetentionDays > 3650 {
+			return errors.New("invalid daily history retention (1..3650 days)")
+		}
+	}
+
+	if cfg.MetricsEnabled && (cfg.MetricsPort < 1 || cfg.MetricsPort > 65535) {
+		return errors.New("invalid metrics port (1..65535)")
+	}
+	if cfg.APIEnabled {
+		if cfg.APIPort < 1 || cfg.APIPort > 65535 {
+			return errors.New("invalid API port (1..65535)")
+		}
+		if len(cfg.APIToken) < 16 {
+			return errors.New("API token must be at least 16 characters")
+		}
+	}
+	return validateProfiles(cfg)
 }
 
+// configPathOverride is the config file given with --config. History and
+// the block ledger are kept next to it.
+var configPathOverride string
+
 func configPath() (string, error) {
+	if configPathOverride != "" {
+		return configPathOverride, nil
+	}
 	dir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
@@ -3092,47 +5261,7 @@ func normalizeRPCURL(s string) (string, error) {
 }
 
 func buildPoolURL(cfg *Config) (string, error) {
-	switch cfg.Mode {
-	case modeStratum:
-		if cfg.StratumHost == "" {
-			return "", errors.New("missing stratum host")
-		}
-		if cfg.StratumPort < 1 || cfg.StratumPort > 65535 {
-			return "", errors.New("invalid stratum port")
-		}
-		if !isHexAddress(cfg.WalletAddress) {
-			return "", errors.New("invalid wallet address (expected 0x + 40 hex chars)")
-		}
-		return fmt.Sprintf("stratum1+tcp://%s:%d", cfg.StratumHost, cfg.StratumPort), nil
-
-	case modeRPCLocal:
-		rpcURL, err := normalizeRPCURL(cfg.RPCURL)
-		if err != nil {
-			return "", err
-		}
-		u, err := url.Parse(rpcURL)
-		if err != nil {
-			return "", fmt.Errorf("invalid RPC URL: %w", err)
-		}
-		return fmt.Sprintf("daemon+%s://%s", u.Scheme, u.Host), nil
-
-	case modeRPCGateway:
-		if !isHexAddress(cfg.WalletAddress) {
-			return "", errors.New("invalid wallet address (expected 0x + 40 hex chars)")
-		}
-		rpcURL, err := normalizeRPCURL(cfg.RPCURL)
-		if err != nil {
-			return "", err
-		}
-		u, err := url.Parse(rpcURL)
-		if err != nil {
-			return "", fmt.Errorf("invalid RPC URL: %w", err)
-		}
-		return fmt.Sprintf("daemon+%s://%s", u.Scheme, u.Host), nil
-
-	default:
-		return "", fmt.Errorf("unknown mining mode: %q", cfg.Mode)
-	}
+	return poolURL(primaryPool(cfg))
 }
 
 func findXMRig() (string, error) {
@@ -3165,11 +5294,10 @@ func findXMRig() (string, error) {
 }
 
 func prepareXMRigBinary(src string) (string, error) {
-	cacheDir, err := os.UserCacheDir()
+	dstDir, err := xmrigCacheDir()
 	if err != nil {
 		return "", err
 	}
-	dstDir := filepath.Join(cacheDir, configDirName, "pkexec-bin")
 	if err := os.MkdirAll(dstDir, 0o755); err != nil {
 		return "", err
 	}
@@ -3281,8 +5409,11 @@ func listCPUDevices() ([]Device, error) {
 		res := make([]Device, 0, n)
 		for i := 0; i < n; i++ {
 			res = append(res, Device{
-				Index: i,
-				Name:  fmt.Sprintf("Logical CPU %d", i),
+				Index:  i,
+				Name:   fmt.Sprintf("Logical CPU %d", i),
+				Core:   -1,
+				Socket: -1,
+				Node:   -1,
 			})
 		}
 		return res, nil
@@ -3323,9 +5454,12 @@ func listCPUDevices() ([]Device, error) {
 		}
 
 		res = append(res, Device{
-			Index: cpu,
-			Name:  name,
-			PCI:   "",
+			Index:  cpu,
+			Name:   name,
+			PCI:    "",
+			Core:   lscpuTopologyField(core),
+			Socket: lscpuTopologyField(socket),
+			Node:   lscpuTopologyField(node),
 		})
 	}
 
@@ -3333,15 +5467,12 @@ func listCPUDevices() ([]Device, error) {
 	return res, nil
 }
 
-func affinityMask(cpuIDs []int) (string, bool) {
-	var mask uint64
-	for _, id := range cpuIDs {
-		if id < 0 || id >= 64 {
-			return "", false
-		}
-		mask |= (uint64(1) << uint(id))
+func lscpuTopologyField(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return -1
 	}
-	return fmt.Sprintf("0x%x", mask), true
+	return n
 }
 
 var xmrigJobLine = regexp.MustCompile(`\bnew job\b.*\bdiff\s+([^\s]+)\b.*\bheight\s+(\d+)`)
@@ -3378,15 +5509,17 @@ type apiResp struct {
 }
 
 type deviceSensors struct {
-	Temp  int
-	Fan   int
-	Power float64
+	Temp   int
+	Fan    int
+	FanRPM int
+	Power  float64
 }
 
 type xmrigSummary struct {
 	Version string `json:"version"`
 	Uptime  int64  `json:"uptime"`
 	Algo    string `json:"algo"`
+	Paused  bool   `json:"paused"`
 	Results struct {
 		DiffCurrent float64 `json:"diff_current"`
 		SharesGood  int64   `json:"shares_good"`
@@ -3413,7 +5546,7 @@ type xmrigBackends []struct {
 	} `json:"threads"`
 }
 
-func pollStats(ctx context.Context, host string, port int, _ bool, onStat func(Stat), onErr func(error)) {
+func pollStats(ctx context.Context, host string, port int, accessToken string, onStat func(Stat), onErr func(error)) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -3422,13 +5555,13 @@ func pollStats(ctx context.Context, host string, port int, _ bool, onStat func(S
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			st, err := getSummary(host, port)
+			st, err := getSummary(host, port, accessToken)
 			if err != nil {
 				onErr(err)
 				continue
 			}
 
-			backends, err := getBackends(host, port)
+			backends, err := getBackends(host, port, accessToken)
 			if err != nil {
 				onErr(err)
 			} else {
@@ -3440,12 +5573,15 @@ func pollStats(ctx context.Context, host string, port int, _ bool, onStat func(S
 	}
 }
 
-func getSummary(host string, port int) (Stat, error) {
+func getSummary(host string, port int, accessToken string) (Stat, error) {
 	endpoint := fmt.Sprintf("http://%s:%d/1/summary", host, port)
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return Stat{}, err
 	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
 	client := &http.Client{Timeout: 1500 * time.Millisecond}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -3465,6 +5601,7 @@ func getSummary(host string, port int) (Stat, error) {
 		Version:   summary.Version,
 		UptimeMin: int(summary.Uptime / 60),
 		Pool:      summary.Connection.Pool,
+		Paused:    summary.Paused,
 		Difficulty: func() float64 {
 			if summary.Connection.Diff > 0 {
 				return summary.Connection.Diff
@@ -3491,12 +5628,15 @@ func getSummary(host string, port int) (Stat, error) {
 	return st, nil
 }
 
-func getBackends(host string, port int) (xmrigBackends, error) {
+func getBackends(host string, port int, accessToken string) (xmrigBackends, error) {
 	endpoint := fmt.Sprintf("http://%s:%d/2/backends", host, port)
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
 	client := &http.Client{Timeout: 1500 * time.Millisecond}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -3513,6 +5653,75 @@ func getBackends(host string, port int) (xmrigBackends, error) {
 	return backends, nil
 }
 
+// callXMRigRPC calls a /json_rpc method without parameters, such as pause or
+// resume. It needs the HTTP API to be started unrestricted.
+func callXMRigRPC(host string, port int, accessToken, method string) error {
+	endpoint := fmt.Sprintf("http://%s:%d/json_rpc", host, port)
+	body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	client := &http.Client{Timeout: 1500 * time.Millisecond}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("json_rpc status %d", resp.StatusCode)
+	}
+	var reply struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return err
+	}
+	if reply.Error != nil {
+		return fmt.Errorf("json_rpc %s: %s (%d)", method, reply.Error.Message, reply.Error.Code)
+	}
+	return nil
+}
+
+// putXMRigConfig replaces the config of a running xmrig through PUT
+// /1/config. xmrig reloads it in place: pools reconnect and CPU threads are
+// re-created, but the process and its RandomX dataset stay.
+func putXMRigConfig(host string, port int, accessToken string, xc *xmrigConfig) error {
+	endpoint := fmt.Sprintf("http://%s:%d/1/config", host, port)
+	body, err := json.Marshal(xc)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("config status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func applyBackends(st *Stat, backends xmrigBackends) {
 	for i := range backends {
 		backend := backends[i]
@@ -3521,32 +5730,37 @@ func applyBackends(st *Stat, backends xmrigBackends) {
 		}
 		st.ActiveThreads = len(backend.Threads)
 
+		// Threads are keyed by their affinity CPU when every thread is pinned;
+		// otherwise by position, like the summary, and remapped later.
+		cpuIndexed := true
+		for _, thread := range backend.Threads {
+			if thread.Affinity < 0 {
+				cpuIndexed = false
+				break
+			}
+		}
 		maxIdx := -1
-		hashes := map[int]int64{}
+		hashes := make(map[int]int64, len(backend.Threads))
 		for i, thread := range backend.Threads {
 			idx := i
-			if thread.Affinity >= 0 {
+			if cpuIndexed {
 				idx = thread.Affinity
 			}
 			if idx > maxIdx {
 				maxIdx = idx
 			}
-			h := seriesFirst(thread.Hashrate)
-			if h > 0 {
-				hashes[idx] = int64(math.Round(h))
-			}
-		}
-		if maxIdx < 0 {
-			return
+			hashes[idx] += int64(math.Round(seriesFirst(thread.Hashrate)))
 		}
 
 		perThreadKH := make([]int64, maxIdx+1)
+		for idx := range perThreadKH {
+			perThreadKH[idx] = -1
+		}
 		for idx, kh := range hashes {
-			if idx >= 0 && idx < len(perThreadKH) {
-				perThreadKH[idx] = kh
-			}
+			perThreadKH[idx] = kh
 		}
 		st.PerGPU_KHs = perThreadKH
+		st.CPUIndexed = cpuIndexed
 		return
 	}
 }
@@ -3754,26 +5968,15 @@ func rpcHexInt(ctx context.Context, endpoint, method string) (int64, error) {
 	if err := json.Unmarshal(result, &s); err != nil {
 		return 0, err
 	}
-	s = strings.TrimSpace(strings.TrimPrefix(s, "0x"))
-	if s == "" {
-		return 0, nil
-	}
-	v, err := strconv.ParseInt(s, 16, 64)
-	if err != nil {
-		return 0, err
-	}
-	return v, nil
+	return parseHexQuantity(s)
 }
 
 func rpcEthSyncing(ctx context.Context, endpoint string) (bool, error) {
-	result, err := rpcCall(ctx, endpoint, "eth_syncing", nil)
+	p, err := rpcSyncProgress(ctx, endpoint)
 	if err != nil {
 		return false, err
 	}
-	if bytes.Equal(bytes.TrimSpace(result), []byte("false")) {
-		return false, nil
-	}
-	return true, nil
+	return p != nil, nil
 }
 
 func rpcMinerStart(ctx context.Context, endpoint string, threads int) error {
@@ -3785,7 +5988,7 @@ func autoStartMiningService(ctx context.Context, rpcPort int, logf func(string))
 	endpoint := fmt.Sprintf("http://127.0.0.1:%d", rpcPort)
 	logf("[node] Mining service will start automatically after the initial sync completes.\n")
 
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(nodePollInterval)
 	defer ticker.Stop()
 
 	readyStreak := 0
@@ -3796,7 +5999,7 @@ func autoStartMiningService(ctx context.Context, rpcPort int, logf func(string))
 		case <-ticker.C:
 		}
 
-		checkCtx, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+		checkCtx, cancel := context.WithTimeout(ctx, nodeRPCTimeout)
 		peers, err := rpcHexInt(checkCtx, endpoint, "net_peerCount")
 		cancel()
 		if err != nil || peers <= 0 {
@@ -3804,7 +6007,7 @@ func autoStartMiningService(ctx context.Context, rpcPort int, logf func(string))
 			continue
 		}
 
-		checkCtx, cancel = context.WithTimeout(ctx, 1500*time.Millisecond)
+		checkCtx, cancel = context.WithTimeout(ctx, nodeRPCTimeout)
 		blockNum, err := rpcHexInt(checkCtx, endpoint, "eth_blockNumber")
 		cancel()
 		if err != nil || blockNum <= 0 {
@@ -3812,7 +6015,7 @@ func autoStartMiningService(ctx context.Context, rpcPort int, logf func(string))
 			continue
 		}
 
-		checkCtx, cancel = context.WithTimeout(ctx, 1500*time.Millisecond)
+		checkCtx, cancel = context.WithTimeout(ctx, nodeRPCTimeout)
 		syncing, err := rpcEthSyncing(checkCtx, endpoint)
 		cancel()
 		if err != nil || syncing {
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMetricsBindAddress = "127.0.0.1"
+	defaultMetricsPort        = 9479
+)
+
+// metricsServer exposes miner and node state on /metrics in the Prometheus
+// text exposition format.
+type metricsServer struct {
+	ctrl *Controller
+	srv  *http.Server
+	addr string
+}
+
+func metricsListenAddress(cfg *Config) string {
+	host := strings.TrimSpace(cfg.MetricsBindAddress)
+	if host == "" {
+		host = defaultMetricsBindAddress
+	}
+	port := cfg.MetricsPort
+	if port <= 0 {
+		port = defaultMetricsPort
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func startMetricsServer(ctrl *Controller, addr string) (*metricsServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	m := &metricsServer{ctrl: ctrl, addr: ln.Addr().String()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", m.handleMetrics)
+	m.srv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := m.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ctrl.appendMinerLog(fmt.Sprintf("[metrics] %v\n", err))
+		}
+	}()
+	return m, nil
+}
+
+func (m *metricsServer) Addr() string {
+	return m.addr
+}
+
+func (m *metricsServer) Close() {
+	if m == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = m.srv.Shutdown(ctx)
+}
+
+func (m *metricsServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var buf bytes.Buffer
+	m.writeMetrics(r.Context(), &buf)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+type metricsWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w metricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(w.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (w metricsWriter) sample(name, labels string, value float64) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w.buf, "%s%s %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (w metricsWriter) gauge(name, help string, value float64) {
+	w.header(name, "gauge", help)
+	w.sample(name, "", value)
+}
+
+func boolMetric(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func (m *metricsServer) writeMetrics(ctx context.Context, buf *bytes.Buffer) {
+	w := metricsWriter{buf: buf}
+	ctrl := m.ctrl
+
+	st, haveStats := ctrl.LastStats()
+	w.gauge("olivetum_miner_up", "Whether xmrig is running.", boolMetric(ctrl.MinerRunning()))
+	w.gauge("olivetum_miner_paused", "Whether xmrig is paused.", boolMetric(ctrl.MinerPaused()))
+	w.gauge("olivetum_miner_throttled", "Whether the thermal governor is limiting xmrig.", boolMetric(ctrl.Throttled()))
+	w.gauge("olivetum_miner_hashrate_hashes_per_second", "Total miner hashrate.", st.Hashrate)
+	w.gauge("olivetum_miner_threads", "Mining threads in use.", float64(st.Threads))
+
+	w.header("olivetum_miner_thread_hashrate_hashes_per_second", "gauge", "Hashrate per logical CPU.")
+	if haveStats {
+		for i, v := range st.PerGPU_KHs {
+			if v < 0 {
+				continue
+			}
+			w.sample("olivetum_miner_thread_hashrate_hashes_per_second", fmt.Sprintf(`cpu="%d"`, i), float64(v))
+		}
+	}
+
+	w.header("olivetum_miner_shares_total", "counter", "Shares by result since the miner started.")
+	w.sample("olivetum_miner_shares_total", `result="accepted"`, float64(st.Accepted))
+	w.sample("olivetum_miner_shares_total", `result="rejected"`, float64(st.Rejected))
+	w.sample("olivetum_miner_shares_total", `result="invalid"`, float64(st.Invalid))
+
+	w.header("olivetum_miner_pool_switches_total", "counter", "Pool switches since the miner started.")
+	w.sample("olivetum_miner_pool_switches_total", "", float64(st.PoolSwitches))
+
+	w.gauge("olivetum_miner_pool_active_index", "Active pool: 0 is the primary, then backups in order; -1 when unknown.", float64(ctrl.ActivePool()))
+
+	w.gauge("olivetum_miner_uptime_seconds", "Miner uptime as reported by xmrig.", float64(st.UptimeMin*60))
+	w.gauge("olivetum_miner_job_height", "Block height of the current mining job.", float64(ctrl.CurrentJobBlock()))
+	w.gauge("olivetum_miner_job_difficulty", "Difficulty of the current mining job.", st.Stat.Difficulty)
+	w.gauge("olivetum_miner_last_found_block", "Height of the last block found in this session.", float64(ctrl.LastFoundBlock()))
+
+	w.header("olivetum_watchdog_restarts_total", "counter", "Miner restarts performed by the watchdog.")
+	w.sample("olivetum_watchdog_restarts_total", "", float64(ctrl.WatchdogRestarts()))
+
+	nodeUp := ctrl.NodeRunning()
+	w.gauge("olivetum_node_up", "Whether the embedded node is running.", boolMetric(nodeUp))
+	if !nodeUp {
+		return
+	}
+	endpoint := fmt.Sprintf("http://127.0.0.1:%d", ctrl.Snapshot().NodeRPCPort)
+	rpcCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	rpcUp := true
+	if peers, err := rpcHexInt(rpcCtx, endpoint, "net_peerCount"); err == nil {
+		w.gauge("olivetum_node_peers", "Connected peers of the embedded node.", float64(peers))
+	} else {
+		rpcUp = false
+	}
+	if head, err := rpcHexInt(rpcCtx, endpoint, "eth_blockNumber"); err == nil {
+		w.gauge("olivetum_node_head_block", "Head block of the embedded node.", float64(head))
+	} else {
+		rpcUp = false
+	}
+	if syncing, err := rpcEthSyncing(rpcCtx, endpoint); err == nil {
+		w.gauge("olivetum_node_syncing", "Whether the embedded node is syncing.", boolMetric(syncing))
+	} else {
+		rpcUp = false
+	}
+	w.gauge("olivetum_node_rpc_up", "Whether the embedded node answered all RPC queries.", boolMetric(rpcUp))
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gethPeersConfigName is the geth TOML config written to the data directory
+// for the static and trusted peers; geth has no command line flags for them.
+const gethPeersConfigName = "olivetum-peers.toml"
+
+// nodePeer is one entry of admin_peers.
+type nodePeer struct {
+	Enode      string
+	Name       string
+	RemoteAddr string
+	Inbound    bool
+	Static     bool
+	Trusted    bool
+	Caps       []string
+	Head       string // head hash announced over eth, when known
+}
+
+func rpcAdminPeers(ctx context.Context, endpoint string) ([]nodePeer, error) {
+	result, err := rpcCall(ctx, endpoint, "admin_peers", nil)
+	if err != nil {
+		return nil, err
+	}
+	var raw []struct {
+		Enode   string   `json:"enode"`
+		Name    string   `json:"name"`
+		Caps    []string `json:"caps"`
+		Network struct {
+			RemoteAddress string `json:"remoteAddress"`
+			Inbound       bool   `json:"inbound"`
+			Trusted       bool   `json:"trusted"`
+			Static        bool   `json:"static"`
+		} `json:"network"`
+		Protocols map[string]json.RawMessage `json:"protocols"`
+	}
+	if err := json.Unmarshal(result, &raw); err != nil {
+		return nil, err
+	}
+	peers := make([]nodePeer, 0, len(raw))
+	for _, r := range raw {
+		p := nodePeer{
+			Enode:      r.Enode,
+			Name:       r.Name,
+			RemoteAddr: r.Network.RemoteAddress,
+			Inbound:    r.Network.Inbound,
+			Static:     r.Network.Static,
+			Trusted:    r.Network.Trusted,
+			Caps:       r.Caps,
+		}
+		// protocols.eth is an object once the handshake is done and the
+		// string "handshake" before that.
+		var eth struct {
+			Head string `json:"head"`
+		}
+		if b, ok := r.Protocols["eth"]; ok && json.Unmarshal(b, &eth) == nil {
+			p.Head = eth.Head
+		}
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].RemoteAddr < peers[j].RemoteAddr })
+	return peers, nil
+}
+
+// rpcNodeEnode returns the enode URL of the node itself.
+func rpcNodeEnode(ctx context.Context, endpoint string) (string, error) {
+	result, err := rpcCall(ctx, endpoint, "admin_nodeInfo", nil)
+	if err != nil {
+		return "", err
+	}
+	var info struct {
+		Enode string `json:"enode"`
+	}
+	if err := json.Unmarshal(result, &info); err != nil {
+		return "", err
+	}
+	if info.Enode == "" {
+		return "", errors.New("node did not report its enode")
+	}
+	return info.Enode, nil
+}
+
+// rpcPeerCall runs one of the admin peer methods, which take an enode URL
+// and return whether the node accepted it.
+func rpcPeerCall(ctx context.Context, endpoint, method, enode string) error {
+	result, err := rpcCall(ctx, endpoint, method, []any{enode})
+	if err != nil {
+		return err
+	}
+	var ok bool
+	if err := json.Unmarshal(result, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s was refused", method)
+	}
+	return nil
+}
+
+// isEnodeURL reports whether s is enode://<128 hex node id>@host:port.
+func isEnodeURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "enode" || u.User == nil {
+		return false
+	}
+	id := u.User.Username()
+	if len(id) != 128 {
+		return false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return false
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil || host == "" {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// parsePeerList splits one enode URL per line (or comma separated) and
+// drops blanks and duplicates.
+func parsePeerList(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' || r == ',' })
+	var out []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// shortEnode shortens the node id of an enode URL for display.
+func shortEnode(enode string) string {
+	id, host, ok := strings.Cut(strings.TrimPrefix(enode, "enode://"), "@")
+	if !ok || len(id) < 16 {
+		return enode
+	}
+	return id[:8] + "…" + id[len(id)-8:] + "@" + host
+}
+
+// writeGethPeersConfig writes the static and trusted peers as a geth TOML
+// config in dataDir and returns its path, or removes a stale one and returns
+// "" when there are none.
+func writeGethPeersConfig(dataDir string, static, trusted []string) (string, error) {
+	path := filepath.Join(dataDir, gethPeersConfigName)
+	if len(static) == 0 && len(trusted) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		return "", nil
+	}
+	quoteAll := func(list []string) string {
+		quoted := make([]string, len(list))
+		for i, s := range list {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	var b strings.Builder
+	b.WriteString("# Written by Olivetum Miner GUI on every node start; edit the peers in Setup.\n")
+	b.WriteString("[Node.P2P]\n")
+	fmt.Fprintf(&b, "StaticNodes = %s\n", quoteAll(static))
+	fmt.Fprintf(&b, "TrustedNodes = %s\n", quoteAll(trusted))
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func validatePeerList(kind string, peers []string) error {
+	for _, p := range peers {
+		if !isEnodeURL(p) {
+			return fmt.Errorf("invalid %s peer %q (expected enode://<node id>@host:port)", kind, p)
+		}
+	}
+	return nil
+}
+
+// enodeID returns the lowercase node id of an enode URL.
+func enodeID(enode string) string {
+	id, _, _ := strings.Cut(strings.TrimPrefix(enode, "enode://"), "@")
+	return strings.ToLower(id)
+}
+
+// withoutPeer returns peers without the entries for the node id of enode.
+func withoutPeer(peers []string, enode string) []string {
+	id := enodeID(enode)
+	var out []string
+	for _, p := range peers {
+		if enodeID(p) != id {
+			out = append(out, p)
+		}
+	}
+	return out
+}
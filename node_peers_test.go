@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var (
+	testEnodeA = "enode://" + strings.Repeat("a1", 64) + "@203.0.113.1:30303"
+	testEnodeB = "enode://" + strings.Repeat("b2", 64) + "@[2001:db8::1]:30304"
+)
+
+func TestIsEnodeURL(t *testing.T) {
+	id := strings.Repeat("a1", 64)
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{testEnodeA, true},
+		{testEnodeB, true},
+		{"enode://" + id + "@node.example.org:30303?discport=30301", true},
+		{"enode://" + strings.ToUpper(id) + "@203.0.113.1:30303", true},
+		{"enode://" + id[:126] + "@203.0.113.1:30303", false},
+		{"enode://" + id[:126] + "zz@203.0.113.1:30303", false},
+		{"enode://" + id + "@203.0.113.1", false},
+		{"enode://" + id + "@:30303", false},
+		{"enode://" + id + "@203.0.113.1:0", false},
+		{"enode://" + id + "@203.0.113.1:65536", false},
+		{"enode://203.0.113.1:30303", false},
+		{"enr://" + id + "@203.0.113.1:30303", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isEnodeURL(tt.in); got != tt.want {
+			t.Errorf("isEnodeURL(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePeerList(t *testing.T) {
+	got := parsePeerList(" " + testEnodeA + "\r\n\n" + testEnodeB + ", " + testEnodeA + ",")
+	if want := []string{testEnodeA, testEnodeB}; !slices.Equal(got, want) {
+		t.Errorf("parsePeerList() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteGethPeersConfig(t *testing.T) {
+	dir := t.TempDir()
+	path, err := writeGethPeersConfig(dir, []string{testEnodeA, testEnodeB}, []string{testEnodeB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, gethPeersConfigName); path != want {
+		t.Fatalf("path = %q, want %q", path, want)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Written by Olivetum Miner GUI on every node start; edit the peers in Setup.\n" +
+		"[Node.P2P]\n" +
+		`StaticNodes = ["` + testEnodeA + `", "` + testEnodeB + `"]` + "\n" +
+		`TrustedNodes = ["` + testEnodeB + `"]` + "\n"
+	if string(b) != want {
+		t.Errorf("config =\n%s\nwant\n%s", b, want)
+	}
+
+	// Only static peers still writes both keys.
+	if _, err := writeGethPeersConfig(dir, []string{testEnodeA}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "TrustedNodes = []\n") {
+		t.Errorf("config without trusted peers =\n%s", b)
+	}
+
+	// No peers removes the stale file, also when it is already gone.
+	for range 2 {
+		path, err := writeGethPeersConfig(dir, nil, nil)
+		if err != nil || path != "" {
+			t.Fatalf("writeGethPeersConfig() = %q, %v, want no file", path, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, gethPeersConfigName)); !os.IsNotExist(err) {
+			t.Errorf("stale config left behind: %v", err)
+		}
+	}
+}
+
+func TestWithoutPeer(t *testing.T) {
+	sameID := strings.Replace(testEnodeA, "203.0.113.1:30303", "198.51.100.9:30305", 1)
+	peers := []string{testEnodeA, testEnodeB, sameID}
+	tests := []struct {
+		name  string
+		enode string
+		want  []string
+	}{
+		{"every address of the node", testEnodeA, []string{testEnodeB}},
+		{"case-insensitive id", "enode://" + strings.Repeat("B2", 64) + "@203.0.113.2:30303", []string{testEnodeA, sameID}},
+		{"unknown node", "enode://" + strings.Repeat("c3", 64) + "@203.0.113.3:30303", peers},
+	}
+	for _, tt := range tests {
+		if got := withoutPeer(peers, tt.enode); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: withoutPeer() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := withoutPeer(nil, testEnodeA); got != nil {
+		t.Errorf("withoutPeer(nil) = %q", got)
+	}
+}
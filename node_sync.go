@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// nodePollInterval and nodeRPCTimeout pace every poll of the embedded
+	// node: the mining service auto-start and the Node tab.
+	nodePollInterval = 2 * time.Second
+	nodeRPCTimeout   = 1500 * time.Millisecond
+
+	// syncRateWindow is how far back the sync speed is averaged.
+	syncRateWindow = time.Minute
+)
+
+// syncProgress is the eth_syncing result while the node is syncing.
+// PulledStates and KnownStates are only reported by the state download of
+// fast and snap sync.
+type syncProgress struct {
+	StartingBlock int64
+	CurrentBlock  int64
+	HighestBlock  int64
+	PulledStates  int64
+	KnownStates   int64
+}
+
+// Fraction is the share of the chain the node has, 0..1.
+func (p *syncProgress) Fraction() float64 {
+	if p.HighestBlock <= 0 {
+		return 0
+	}
+	return min(1, float64(p.CurrentBlock)/float64(p.HighestBlock))
+}
+
+// nodeStatus is one poll of the embedded node RPC.
+type nodeStatus struct {
+	Peers    int64
+	Head     int64
+	HeadTime time.Time     // zero when unknown
+	Sync     *syncProgress // nil when not syncing
+}
+
+func parseHexQuantity(s string) (int64, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(s, "0x"))
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 16, 64)
+}
+
+// rpcSyncProgress returns the sync progress of the node, or nil when it is
+// not syncing.
+func rpcSyncProgress(ctx context.Context, endpoint string) (*syncProgress, error) {
+	result, err := rpcCall(ctx, endpoint, "eth_syncing", nil)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(bytes.TrimSpace(result), []byte("false")) {
+		return nil, nil
+	}
+	var raw struct {
+		StartingBlock string `json:"startingBlock"`
+		CurrentBlock  string `json:"currentBlock"`
+		HighestBlock  string `json:"highestBlock"`
+		PulledStates  string `json:"pulledStates"`
+		KnownStates   string `json:"knownStates"`
+	}
+	if err := json.Unmarshal(result, &raw); err != nil {
+		return nil, err
+	}
+	var p syncProgress
+	for _, f := range []struct {
+		text string
+		dst  *int64
+	}{
+		{raw.StartingBlock, &p.StartingBlock},
+		{raw.CurrentBlock, &p.CurrentBlock},
+		{raw.HighestBlock, &p.HighestBlock},
+		{raw.PulledStates, &p.PulledStates},
+		{raw.KnownStates, &p.KnownStates},
+	} {
+		v, err := parseHexQuantity(f.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid eth_syncing result: %w", err)
+		}
+		*f.dst = v
+	}
+	return &p, nil
+}
+
+func rpcLatestBlockTime(ctx context.Context, endpoint string) (time.Time, error) {
+	result, err := rpcCall(ctx, endpoint, "eth_getBlockByNumber", []any{"latest", false})
+	if err != nil {
+		return time.Time{}, err
+	}
+	var block struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(result, &block); err != nil {
+		return time.Time{}, err
+	}
+	ts, err := parseHexQuantity(block.Timestamp)
+	if err != nil || ts <= 0 {
+		return time.Time{}, fmt.Errorf("invalid block timestamp %q", block.Timestamp)
+	}
+	return time.Unix(ts, 0), nil
+}
+
+// queryNodeStatus polls peers, head block and sync progress of the node at
+// endpoint. The head block time is best effort.
+func queryNodeStatus(ctx context.Context, endpoint string) (nodeStatus, error) {
+	var st nodeStatus
+	var err error
+	rpcCtx, cancel := context.WithTimeout(ctx, nodeRPCTimeout)
+	defer cancel()
+	if st.Peers, err = rpcHexInt(rpcCtx, endpoint, "net_peerCount"); err != nil {
+		return st, err
+	}
+	if st.Head, err = rpcHexInt(rpcCtx, endpoint, "eth_blockNumber"); err != nil {
+		return st, err
+	}
+	if st.Sync, err = rpcSyncProgress(rpcCtx, endpoint); err != nil {
+		return st, err
+	}
+	if at, err := rpcLatestBlockTime(rpcCtx, endpoint); err == nil {
+		st.HeadTime = at
+	}
+	return st, nil
+}
+
+type syncSample struct {
+	at    time.Time
+	block int64
+}
+
+// syncRate averages the block import speed over syncRateWindow.
+type syncRate struct {
+	samples []syncSample
+}
+
+func (r *syncRate) Add(at time.Time, block int64) {
+	// A lower block means a restart or a rewind; start over.
+	if n := len(r.samples); n > 0 && block < r.samples[n-1].block {
+		r.samples = r.samples[:0]
+	}
+	r.samples = append(r.samples, syncSample{at: at, block: block})
+	i := 0
+	for i < len(r.samples)-2 && at.Sub(r.samples[i+1].at) >= syncRateWindow {
+		i++
+	}
+	r.samples = r.samples[i:]
+}
+
+func (r *syncRate) Reset() {
+	r.samples = r.samples[:0]
+}
+
+// BlocksPerSec returns the import speed, or false until two samples at
+// least a few seconds apart exist.
+func (r *syncRate) BlocksPerSec() (float64, bool) {
+	if len(r.samples) < 2 {
+		return 0, false
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	sec := last.at.Sub(first.at).Seconds()
+	if sec < 5 {
+		return 0, false
+	}
+	return float64(last.block-first.block) / sec, true
+}
+
+// syncETA estimates the time left to reach the highest known block.
+func syncETA(p *syncProgress, blocksPerSec float64) (time.Duration, bool) {
+	if p == nil || blocksPerSec <= 0 {
+		return 0, false
+	}
+	left := p.HighestBlock - p.CurrentBlock
+	if left <= 0 {
+		return 0, true
+	}
+	return time.Duration(float64(left) / blocksPerSec * float64(time.Second)), true
+}
+
+// formatAge renders how long ago something happened, e.g. "12 s", "4 min".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "0 s"
+	case d < time.Minute:
+		return fmt.Sprintf("%d s", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.1f h", d.Hours())
+	default:
+		return fmt.Sprintf("%.0f days", d.Hours()/24)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRPCSyncProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		want    *syncProgress
+		wantErr string
+	}{
+		{name: "not syncing", result: `false`},
+		{
+			name:   "full sync",
+			result: `{"startingBlock":"0x0","currentBlock":"0x64","highestBlock":"0x3e8"}`,
+			want:   &syncProgress{CurrentBlock: 100, HighestBlock: 1000},
+		},
+		{
+			name:   "state download",
+			result: `{"startingBlock":"0xa","currentBlock":"0x64","highestBlock":"0x3e8","pulledStates":"0x10","knownStates":"0x20"}`,
+			want:   &syncProgress{StartingBlock: 10, CurrentBlock: 100, HighestBlock: 1000, PulledStates: 16, KnownStates: 32},
+		},
+		{name: "bad quantity", result: `{"currentBlock":"0xzz"}`, wantErr: "invalid eth_syncing result"},
+		{name: "not an object", result: `true`, wantErr: "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := newTestRPC(t, func(method string, params []json.RawMessage) (any, error) {
+				return json.RawMessage(tt.result), nil
+			})
+			got, err := rpcSyncProgress(context.Background(), endpoint)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("rpcSyncProgress() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncRate(t *testing.T) {
+	var r syncRate
+	start := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	r.Add(at(0), 0)
+	if _, ok := r.BlocksPerSec(); ok {
+		t.Error("rate from a single sample")
+	}
+	r.Add(at(2), 2)
+	if _, ok := r.BlocksPerSec(); ok {
+		t.Error("rate from samples 2 s apart")
+	}
+	// 1 block/s for a minute, then 10 blocks/s.
+	for sec := 10; sec <= 60; sec += 10 {
+		r.Add(at(sec), int64(sec))
+	}
+	if rate, ok := r.BlocksPerSec(); !ok || rate != 1 {
+		t.Errorf("rate = %v, %v, want 1", rate, ok)
+	}
+	for sec := 70; sec <= 120; sec += 10 {
+		r.Add(at(sec), int64(60+(sec-60)*10))
+	}
+	// Only the last minute counts.
+	if rate, ok := r.BlocksPerSec(); !ok || rate != 10 {
+		t.Errorf("rate = %v, %v, want 10 over the window", rate, ok)
+	}
+	if first := r.samples[0].at; !first.Equal(at(60)) {
+		t.Errorf("oldest sample at %s, want %s", first, at(60))
+	}
+
+	// A lower block starts over.
+	r.Add(at(130), 5)
+	if _, ok := r.BlocksPerSec(); ok || len(r.samples) != 1 {
+		t.Errorf("%d samples after a rewind, want 1", len(r.samples))
+	}
+	r.Add(at(140), 105)
+	if rate, ok := r.BlocksPerSec(); !ok || rate != 10 {
+		t.Errorf("rate after a rewind = %v, %v, want 10", rate, ok)
+	}
+	r.Reset()
+	if _, ok := r.BlocksPerSec(); ok {
+		t.Error("rate after Reset")
+	}
+}
+
+func TestSyncETA(t *testing.T) {
+	p := &syncProgress{CurrentBlock: 100, HighestBlock: 1100}
+	tests := []struct {
+		name string
+		p    *syncProgress
+		rate float64
+		want time.Duration
+		ok   bool
+	}{
+		{"syncing", p, 10, 100 * time.Second, true},
+		{"slow", p, 0.5, 2000 * time.Second, true},
+		{"caught up", &syncProgress{CurrentBlock: 1100, HighestBlock: 1000}, 10, 0, true},
+		{"no rate", p, 0, 0, false},
+		{"not syncing", nil, 10, 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := syncETA(tt.p, tt.rate); got != tt.want || ok != tt.ok {
+			t.Errorf("%s: syncETA() = %s, %v, want %s, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPoolFailbackMin = 10
+	maxBackupPools         = 8
+)
+
+// PoolConfig is one mining endpoint. The primary pool is described by the
+// top-level Config fields; Config.Pools holds the backups, tried in order.
+type PoolConfig struct {
+	Mode          string `json:"mode"`
+	Host          string `json:"host,omitempty"`
+	Port          int    `json:"port,omitempty"`
+	RPCURL        string `json:"rpcUrl,omitempty"`
+	WalletAddress string `json:"walletAddress,omitempty"`
+	WorkerName    string `json:"workerName,omitempty"`
+	Disabled      bool   `json:"disabled,omitempty"`
+}
+
+func primaryPool(cfg *Config) PoolConfig {
+	return PoolConfig{
+		Mode:          cfg.Mode,
+		Host:          cfg.StratumHost,
+		Port:          cfg.StratumPort,
+		RPCURL:        cfg.RPCURL,
+		WalletAddress: cfg.WalletAddress,
+		WorkerName:    cfg.WorkerName,
+	}
+}
+
+// miningPools returns the primary pool followed by the enabled backups, in
+// the order xmrig tries them.
+func miningPools(cfg *Config) []PoolConfig {
+	pools := []PoolConfig{primaryPool(cfg)}
+	for _, p := range cfg.Pools {
+		if !p.Disabled {
+			pools = append(pools, p)
+		}
+	}
+	return pools
+}
+
+func normalizePool(p *PoolConfig) {
+	p.Mode = strings.TrimSpace(p.Mode)
+	p.Host = strings.TrimSpace(p.Host)
+	p.WalletAddress = normalizeAddress(p.WalletAddress)
+	p.WorkerName = strings.TrimSpace(p.WorkerName)
+	if normalized, err := normalizeRPCURL(p.RPCURL); err == nil {
+		p.RPCURL = normalized
+	}
+}
+
+func validatePool(p PoolConfig) error {
+	switch p.Mode {
+	case modeStratum:
+		if p.Host == "" {
+			return errors.New("stratum host is required")
+		}
+		if p.Port < 1 || p.Port > 65535 {
+			return errors.New("invalid stratum port")
+		}
+		if p.WorkerName != "" && !workerNamePattern.MatchString(p.WorkerName) {
+			return errors.New("invalid worker name (allowed: 0-9 A-Z a-z _ -; max 16)")
+		}
+	case modeRPCLocal, modeRPCGateway:
+		if _, err := normalizeRPCURL(p.RPCURL); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid mode %q", p.Mode)
+	}
+	if p.Mode != modeRPCLocal && !isHexAddress(p.WalletAddress) {
+		return errors.New("invalid wallet address (expected 0x + 40 hex chars)")
+	}
+	return validateAddressChecksum("wallet address", p.WalletAddress)
+}
+
+// poolURL returns the xmrig pool URL for p.
+func poolURL(p PoolConfig) (string, error) {
+	switch p.Mode {
+	case modeStratum:
+		if p.Host == "" {
+			return "", errors.New("missing stratum host")
+		}
+		if p.Port < 1 || p.Port > 65535 {
+			return "", errors.New("invalid stratum port")
+		}
+		if !isHexAddress(p.WalletAddress) {
+			return "", errors.New("invalid wallet address (expected 0x + 40 hex chars)")
+		}
+		return fmt.Sprintf("stratum1+tcp://%s", net.JoinHostPort(p.Host, strconv.Itoa(p.Port))), nil
+
+	case modeRPCLocal, modeRPCGateway:
+		if p.Mode == modeRPCGateway && !isHexAddress(p.WalletAddress) {
+			return "", errors.New("invalid wallet address (expected 0x + 40 hex chars)")
+		}
+		rpcURL, err := normalizeRPCURL(p.RPCURL)
+		if err != nil {
+			return "", err
+		}
+		u, err := url.Parse(rpcURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid RPC URL: %w", err)
+		}
+		return fmt.Sprintf("daemon+%s://%s", u.Scheme, u.Host), nil
+
+	default:
+		return "", fmt.Errorf("unknown mining mode: %q", p.Mode)
+	}
+}
+
+// poolEndpoint is the host:port xmrig connects to for p, as reported in the
+// summary's connection.pool field. It is empty when p is not valid.
+func poolEndpoint(p PoolConfig) string {
+	switch p.Mode {
+	case modeStratum:
+		if p.Host == "" || p.Port < 1 || p.Port > 65535 {
+			return ""
+		}
+		return net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	case modeRPCLocal, modeRPCGateway:
+		rpcURL, err := normalizeRPCURL(p.RPCURL)
+		if err != nil {
+			return ""
+		}
+		u, err := url.Parse(rpcURL)
+		if err != nil || u.Hostname() == "" {
+			return ""
+		}
+		if u.Port() != "" {
+			return u.Host
+		}
+		if strings.EqualFold(u.Scheme, "https") {
+			return net.JoinHostPort(u.Hostname(), "443")
+		}
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+	return ""
+}
+
+// activePoolIndex matches xmrig's connection.pool against pools and returns
+// the index of the active one, or -1 when it is not known.
+func activePoolIndex(pools []PoolConfig, active string) int {
+	active = strings.TrimSpace(active)
+	if active == "" {
+		return -1
+	}
+	for i, p := range pools {
+		if strings.EqualFold(poolEndpoint(p), active) {
+			return i
+		}
+	}
+	// xmrig may report a resolved or differently spelled host; fall back to
+	// the port when it identifies a single pool.
+	_, activePort, err := net.SplitHostPort(active)
+	if err != nil {
+		return -1
+	}
+	match := -1
+	for i, p := range pools {
+		_, port, err := net.SplitHostPort(poolEndpoint(p))
+		if err != nil || port != activePort {
+			continue
+		}
+		if match >= 0 {
+			return -1
+		}
+		match = i
+	}
+	return match
+}
+
+func describePool(p PoolConfig) string {
+	var b strings.Builder
+	switch p.Mode {
+	case modeStratum:
+		b.WriteString("Stratum ")
+	case modeRPCLocal:
+		b.WriteString("Local RPC ")
+	case modeRPCGateway:
+		b.WriteString("RPC gateway ")
+	}
+	if endpoint := poolEndpoint(p); endpoint != "" {
+		b.WriteString(endpoint)
+	} else {
+		b.WriteString("(invalid)")
+	}
+	if p.Mode != modeRPCLocal && p.WalletAddress != "" {
+		wallet := p.WalletAddress
+		if len(wallet) > 12 {
+			wallet = wallet[:6] + "…" + wallet[len(wallet)-4:]
+		}
+		b.WriteString(" → " + wallet)
+		if p.WorkerName != "" && p.Mode == modeStratum {
+			b.WriteString("." + p.WorkerName)
+		}
+	}
+	if p.Disabled {
+		b.WriteString(" (disabled)")
+	}
+	return b.String()
+}
+
+// poolStatusText is the Dashboard text for the active pool.
+func poolStatusText(st minerStats) string {
+	text := strings.TrimSpace(st.Pool)
+	if text == "" {
+		text = "—"
+	}
+	if st.PoolIndex > 0 {
+		text += fmt.Sprintf(" (backup %d)", st.PoolIndex)
+	}
+	if st.PoolSwitches > 0 {
+		text += fmt.Sprintf(" · %d switches", st.PoolSwitches)
+	}
+	return text
+}
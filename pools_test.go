@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMiningPoolsOrder(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Mode = modeStratum
+	cfg.StratumHost = "primary.example.org"
+	cfg.StratumPort = 8008
+	cfg.WalletAddress = testWallet
+	cfg.WorkerName = "rig1"
+	cfg.Pools = []PoolConfig{
+		{Mode: modeStratum, Host: "b1.example.org", Port: 3333},
+		{Mode: modeStratum, Host: "off.example.org", Port: 4444, Disabled: true},
+		{Mode: modeRPCGateway, RPCURL: "https://gw.example.org"},
+	}
+	want := []PoolConfig{
+		{Mode: modeStratum, Host: "primary.example.org", Port: 8008, RPCURL: cfg.RPCURL, WalletAddress: testWallet, WorkerName: "rig1"},
+		cfg.Pools[0],
+		cfg.Pools[2],
+	}
+	got := miningPools(cfg)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("miningPools() = %+v, want %+v", got, want)
+	}
+	// Failback always returns to the pool built from the top-level fields.
+	if primary := primaryPool(cfg); !reflect.DeepEqual(primary, got[0]) {
+		t.Errorf("primaryPool() = %+v, want %+v", primary, got[0])
+	}
+}
+
+func TestPoolEndpoint(t *testing.T) {
+	tests := []struct {
+		pool PoolConfig
+		want string
+	}{
+		{PoolConfig{Mode: modeStratum, Host: "pool.example.org", Port: 8008}, "pool.example.org:8008"},
+		{PoolConfig{Mode: modeStratum, Host: "::1", Port: 8008}, "[::1]:8008"},
+		{PoolConfig{Mode: modeStratum, Host: "pool.example.org"}, ""},
+		{PoolConfig{Mode: modeRPCLocal, RPCURL: "http://127.0.0.1:8545"}, "127.0.0.1:8545"},
+		{PoolConfig{Mode: modeRPCGateway, RPCURL: "https://gw.example.org"}, "gw.example.org:443"},
+		{PoolConfig{Mode: modeRPCGateway, RPCURL: "http://gw.example.org/rpc"}, "gw.example.org:80"},
+		{PoolConfig{Mode: "bogus", Host: "pool.example.org", Port: 1}, ""},
+	}
+	for _, tt := range tests {
+		if got := poolEndpoint(tt.pool); got != tt.want {
+			t.Errorf("poolEndpoint(%+v) = %q, want %q", tt.pool, got, tt.want)
+		}
+	}
+}
+
+func TestActivePoolIndex(t *testing.T) {
+	pools := []PoolConfig{
+		{Mode: modeStratum, Host: "primary.example.org", Port: 8008},
+		{Mode: modeStratum, Host: "b1.example.org", Port: 3333},
+		{Mode: modeStratum, Host: "b2.example.org", Port: 4444},
+		{Mode: modeStratum, Host: "b3.example.org", Port: 4444},
+		{Mode: modeRPCGateway, RPCURL: "https://gw.example.org"},
+	}
+	tests := []struct {
+		name   string
+		active string
+		want   int
+	}{
+		{"primary", "primary.example.org:8008", 0},
+		{"backup", "b1.example.org:3333", 1},
+		{"case-insensitive", "B1.Example.ORG:3333", 1},
+		{"surrounding space", " b2.example.org:4444 ", 2},
+		{"default https port", "gw.example.org:443", 4},
+		{"resolved address, unique port", "203.0.113.7:8008", 0},
+		{"resolved address, backup port", "203.0.113.7:3333", 1},
+		{"resolved address, shared port", "203.0.113.7:4444", -1},
+		{"unknown port", "203.0.113.7:9999", -1},
+		{"no port", "primary.example.org", -1},
+		{"empty", "", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := activePoolIndex(pools, tt.active); got != tt.want {
+				t.Errorf("activePoolIndex(%q) = %d, want %d", tt.active, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultProfileName = "Default"
+	maxProfiles        = 20
+	maxProfileNameLen  = 32
+)
+
+// ProfileSettings are the miner and node settings a profile switches:
+// everything in the Connection, Pools, Node and CPU settings of Setup.
+// Config embeds them as the settings of the active profile.
+type ProfileSettings struct {
+	Mode          string `json:"mode"`
+	StratumHost   string `json:"stratumHost"`
+	StratumPort   int    `json:"stratumPort"`
+	RPCURL        string `json:"rpcUrl"`
+	WalletAddress string `json:"walletAddress"`
+	WorkerName    string `json:"workerName"`
+
+	// Pools are backup pools xmrig fails over to, in order, when the
+	// primary pool above is unreachable.
+	Pools               []PoolConfig `json:"pools"`
+	PoolFailbackEnabled bool         `json:"poolFailbackEnabled"`
+	PoolFailbackMin     int          `json:"poolFailbackMin"`
+
+	CPUThreads      int    `json:"cpuThreads"`
+	CPUAffinity     []int  `json:"cpuAffinity"`
+	UseHugePages    bool   `json:"useHugePages"`
+	EnableMSR       bool   `json:"enableMsr"`
+	AutoGrantMSR    bool   `json:"autoGrantMsr"`
+	RandomXMode     string `json:"randomxMode"`
+	RandomX1GBPages bool   `json:"randomx1gbPages"`
+	CPUPriority     int    `json:"cpuPriority"`
+	CPUYield        bool   `json:"cpuYield"`
+	DonateLevel     int    `json:"donateLevel"`
+
+	NodeSettings
+}
+
+// NodeSettings are the profile settings that decide how the embedded node
+// is started.
+type NodeSettings struct {
+	NodeEnabled   bool   `json:"nodeEnabled"`
+	NodeMode      string `json:"nodeMode"`
+	NodeDataDir   string `json:"nodeDataDir"`
+	NodeRPCPort   int    `json:"nodeRpcPort"`
+	NodeP2PPort   int    `json:"nodeP2pPort"`
+	NodeBootnodes string `json:"nodeBootnodes"`
+	// Static peers are always kept connected; trusted peers may connect
+	// even when the node is at its peer limit.
+	NodeStaticPeers  []string `json:"nodeStaticPeers"`
+	NodeTrustedPeers []string `json:"nodeTrustedPeers"`
+	NodeVerbosity    int      `json:"nodeVerbosity"`
+	NodeEtherbase    string   `json:"nodeEtherbase"`
+}
+
+// clone copies s together with its slices.
+func (s ProfileSettings) clone() ProfileSettings {
+	s.Pools = slices.Clone(s.Pools)
+	s.CPUAffinity = slices.Clone(s.CPUAffinity)
+	s.NodeStaticPeers = slices.Clone(s.NodeStaticPeers)
+	s.NodeTrustedPeers = slices.Clone(s.NodeTrustedPeers)
+	return s
+}
+
+// MiningProfile is a named set of ProfileSettings. The copy of the active
+// profile in Config.Profiles is kept in sync with Config on every save.
+type MiningProfile struct {
+	Name string `json:"name"`
+	ProfileSettings
+}
+
+func profileFromConfig(cfg *Config, name string) MiningProfile {
+	return MiningProfile{Name: name, ProfileSettings: cfg.ProfileSettings.clone()}
+}
+
+// applyProfile makes the settings of p the ones of cfg.
+func applyProfile(cfg *Config, p MiningProfile) {
+	cfg.ProfileSettings = p.ProfileSettings.clone()
+}
+
+func findProfile(cfg *Config, name string) int {
+	return slices.IndexFunc(cfg.Profiles, func(p MiningProfile) bool { return strings.EqualFold(p.Name, name) })
+}
+
+func profileNames(cfg *Config) []string {
+	names := make([]string, len(cfg.Profiles))
+	for i, p := range cfg.Profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// syncActiveProfile stores the flat fields as the active profile. A config
+// from before profiles existed becomes the "Default" profile.
+func syncActiveProfile(cfg *Config) {
+	cfg.ActiveProfile = strings.TrimSpace(cfg.ActiveProfile)
+	if cfg.ActiveProfile == "" {
+		cfg.ActiveProfile = defaultProfileName
+		if len(cfg.Profiles) > 0 {
+			cfg.ActiveProfile = cfg.Profiles[0].Name
+		}
+	}
+	cfg.Profiles = slices.Clone(cfg.Profiles)
+	i := findProfile(cfg, cfg.ActiveProfile)
+	if i < 0 {
+		cfg.Profiles = append(cfg.Profiles, MiningProfile{})
+		i = len(cfg.Profiles) - 1
+	}
+	cfg.Profiles[i] = profileFromConfig(cfg, cfg.ActiveProfile)
+}
+
+// switchProfile keeps the current settings in the active profile and makes
+// name the active one.
+func switchProfile(cfg *Config, name string) error {
+	i := findProfile(cfg, name)
+	if i < 0 {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	syncActiveProfile(cfg)
+	p := cfg.Profiles[i]
+	applyProfile(cfg, p)
+	cfg.ActiveProfile = p.Name
+	return nil
+}
+
+// duplicateProfile adds a copy of the profile src named name.
+func duplicateProfile(cfg *Config, src, name string) error {
+	syncActiveProfile(cfg)
+	i := findProfile(cfg, src)
+	if i < 0 {
+		return fmt.Errorf("unknown profile %q", src)
+	}
+	name = strings.TrimSpace(name)
+	if err := checkNewProfileName(cfg, name, -1); err != nil {
+		return err
+	}
+	if len(cfg.Profiles) >= maxProfiles {
+		return fmt.Errorf("too many profiles (max %d)", maxProfiles)
+	}
+	cfg.Profiles = append(cfg.Profiles, MiningProfile{Name: name, ProfileSettings: cfg.Profiles[i].ProfileSettings.clone()})
+	return nil
+}
+
+func renameProfile(cfg *Config, from, to string) error {
+	syncActiveProfile(cfg)
+	i := findProfile(cfg, from)
+	if i < 0 {
+		return fmt.Errorf("unknown profile %q", from)
+	}
+	to = strings.TrimSpace(to)
+	if err := checkNewProfileName(cfg, to, i); err != nil {
+		return err
+	}
+	if strings.EqualFold(cfg.ActiveProfile, cfg.Profiles[i].Name) {
+		cfg.ActiveProfile = to
+	}
+	cfg.Profiles[i].Name = to
+	return nil
+}
+
+// deleteProfile removes an inactive profile; the active one has to be
+// switched away from first.
+func deleteProfile(cfg *Config, name string) error {
+	syncActiveProfile(cfg)
+	i := findProfile(cfg, name)
+	if i < 0 {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	if strings.EqualFold(cfg.ActiveProfile, cfg.Profiles[i].Name) {
+		return errors.New("the active profile cannot be deleted; switch to another profile first")
+	}
+	cfg.Profiles = slices.Delete(cfg.Profiles, i, i+1)
+	return nil
+}
+
+func checkNewProfileName(cfg *Config, name string, self int) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if i := findProfile(cfg, name); i >= 0 && i != self {
+		return fmt.Errorf("a profile named %q already exists", name)
+	}
+	return nil
+}
+
+func validateProfileName(name string) error {
+	if name == "" || len(name) > maxProfileNameLen || strings.TrimSpace(name) != name {
+		return fmt.Errorf("invalid profile name (1..%d chars)", maxProfileNameLen)
+	}
+	return nil
+}
+
+// validateProfiles checks the profile list and the settings of the inactive
+// profiles, so switching always lands on a config that validates.
+func validateProfiles(cfg *Config) error {
+	if len(cfg.Profiles) > maxProfiles {
+		return fmt.Errorf("too many profiles (max %d)", maxProfiles)
+	}
+	seen := make(map[string]bool)
+	active := false
+	for _, p := range cfg.Profiles {
+		if err := validateProfileName(p.Name); err != nil {
+			return err
+		}
+		key := strings.ToLower(p.Name)
+		if seen[key] {
+			return fmt.Errorf("duplicate profile name %q", p.Name)
+		}
+		seen[key] = true
+		if strings.EqualFold(p.Name, cfg.ActiveProfile) {
+			active = true
+			continue
+		}
+		c := *cfg
+		c.Profiles = nil
+		applyProfile(&c, p)
+		normalizeConfig(&c)
+		if err := validateConfig(&c); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	if len(cfg.Profiles) > 0 && !active {
+		return fmt.Errorf("unknown active profile %q", cfg.ActiveProfile)
+	}
+	return nil
+}
+
+// nodeSettingsChanged reports whether switching from a to b changes how the
+// embedded node is started.
+func nodeSettingsChanged(a, b *Config) bool {
+	x, y := a.NodeSettings, b.NodeSettings
+	if !slices.Equal(x.NodeStaticPeers, y.NodeStaticPeers) || !slices.Equal(x.NodeTrustedPeers, y.NodeTrustedPeers) {
+		return true
+	}
+	// Nil and empty peer lists are the same; the slices are compared above.
+	x.NodeStaticPeers, y.NodeStaticPeers = nil, nil
+	x.NodeTrustedPeers, y.NodeTrustedPeers = nil, nil
+	return !reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestSwitchProfile(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.WalletAddress = testWallet
+	cfg.Pools = []PoolConfig{{Mode: modeStratum, Host: "a.example.org", Port: 1}}
+	if err := duplicateProfile(cfg, defaultProfileName, "Night"); err != nil {
+		t.Fatal(err)
+	}
+	if err := switchProfile(cfg, "night"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ActiveProfile != "Night" {
+		t.Fatalf("active profile = %q, want Night", cfg.ActiveProfile)
+	}
+
+	// Edits of the active settings must not reach the stored profiles.
+	cfg.CPUThreads = 2
+	cfg.Pools[0].Host = "b.example.org"
+	for _, p := range cfg.Profiles {
+		if p.CPUThreads != 0 || p.Pools[0].Host != "a.example.org" {
+			t.Fatalf("profile %q changed with the active settings: %+v", p.Name, p.ProfileSettings)
+		}
+	}
+
+	if err := switchProfile(cfg, defaultProfileName); err != nil {
+		t.Fatal(err)
+	}
+	night := cfg.Profiles[findProfile(cfg, "Night")]
+	if night.CPUThreads != 2 || night.Pools[0].Host != "b.example.org" {
+		t.Errorf("switching away did not keep the Night settings: %+v", night.ProfileSettings)
+	}
+	if cfg.CPUThreads != 0 || cfg.Pools[0].Host != "a.example.org" {
+		t.Errorf("switching back did not restore the Default settings: %+v", cfg.ProfileSettings)
+	}
+	if err := switchProfile(cfg, "Missing"); err == nil {
+		t.Error("switched to a missing profile")
+	}
+}
+
+func TestProfileSettingsClone(t *testing.T) {
+	s := ProfileSettings{
+		Pools:       []PoolConfig{{Host: "a"}},
+		CPUAffinity: []int{0, 1},
+		NodeSettings: NodeSettings{
+			NodeStaticPeers:  []string{"a"},
+			NodeTrustedPeers: []string{"b"},
+		},
+	}
+	c := s.clone()
+	c.Pools[0].Host = "x"
+	c.CPUAffinity[0] = 9
+	c.NodeStaticPeers[0] = "x"
+	c.NodeTrustedPeers[0] = "x"
+	if s.Pools[0].Host != "a" || s.CPUAffinity[0] != 0 || s.NodeStaticPeers[0] != "a" || s.NodeTrustedPeers[0] != "b" {
+		t.Errorf("clone shares memory with the original: %+v", s)
+	}
+}
+
+func TestNodeSettingsChanged(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Config)
+		want bool
+	}{
+		{"nothing", func(*Config) {}, false},
+		{"miner only", func(c *Config) { c.CPUThreads = 3; c.StratumHost = "x" }, false},
+		{"empty peer list", func(c *Config) { c.NodeStaticPeers = []string{} }, false},
+		{"mode", func(c *Config) { c.NodeMode = nodeModeMine }, true},
+		{"mining address", func(c *Config) { c.NodeEtherbase = testWallet }, true},
+		{"static peers", func(c *Config) { c.NodeStaticPeers = []string{"enode://x"} }, true},
+		{"trusted peers", func(c *Config) { c.NodeTrustedPeers = []string{"enode://x"} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := defaultConfig()
+			b := cloneConfig(a)
+			tt.edit(b)
+			if got := nodeSettingsChanged(a, b); got != tt.want {
+				t.Errorf("nodeSettingsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxScheduleWindows = 32
+
+var weekdayKeys = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ScheduleWindow is a weekly mining window in local time. A window whose end
+// is not after its start runs past midnight into the next day. Days holds
+// weekday keys ("mon".."sun"); empty means every day. Threads, when set,
+// caps the miner threads while the window is active.
+type ScheduleWindow struct {
+	Days    []string `json:"days,omitempty"`
+	Start   string   `json:"start"`
+	End     string   `json:"end"`
+	Threads int      `json:"threads,omitempty"`
+}
+
+// scheduleState is what the schedule asks for at a given time.
+type scheduleState struct {
+	Active  bool
+	Threads int
+}
+
+// parseClock parses "HH:MM" into minutes after midnight. "24:00" is accepted
+// as the end of the day.
+func parseClock(text string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(text), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", text)
+	}
+	hour, err1 := strconv.Atoi(h)
+	minute, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || len(m) != 2 || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", text)
+	}
+	return hour*60 + minute, nil
+}
+
+func normalizeScheduleWindow(w *ScheduleWindow) {
+	w.Start = strings.TrimSpace(w.Start)
+	w.End = strings.TrimSpace(w.End)
+	days := make([]string, 0, len(w.Days))
+	for _, d := range w.Days {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) > 3 {
+			d = d[:3]
+		}
+		days = append(days, d)
+	}
+	w.Days = days
+}
+
+func validateScheduleWindow(w ScheduleWindow) error {
+	for _, d := range w.Days {
+		if weekdayIndex(d) < 0 {
+			return fmt.Errorf("invalid day %q", d)
+		}
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+	if start == 24*60 {
+		return errors.New("window cannot start at 24:00")
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("window start and end are the same")
+	}
+	if w.Threads < 0 || w.Threads > 4096 {
+		return errors.New("invalid window threads (0..4096)")
+	}
+	return nil
+}
+
+func weekdayIndex(key string) int {
+	for i, k := range weekdayKeys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w ScheduleWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdayIndex(d) == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// span returns the window that starts on the given calendar day.
+func (w ScheduleWindow) span(year int, month time.Month, day int, loc *time.Location) (time.Time, time.Time, bool) {
+	start, err1 := parseClock(w.Start)
+	end, err2 := parseClock(w.End)
+	if err1 != nil || err2 != nil || start == end {
+		return time.Time{}, time.Time{}, false
+	}
+	from := time.Date(year, month, day, start/60, start%60, 0, 0, loc)
+	if !w.onDay(from.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+	endDay := day
+	if end <= start {
+		endDay++
+	}
+	to := time.Date(year, month, endDay, end/60, end%60, 0, 0, loc)
+	return from, to, true
+}
+
+// scheduleStateAt returns the state of the first window covering t.
+func scheduleStateAt(windows []ScheduleWindow, t time.Time) scheduleState {
+	y, m, d := t.Date()
+	for _, w := range windows {
+		// A window covering t started today or, past midnight, yesterday.
+		for _, offset := range []int{0, -1} {
+			from, to, ok := w.span(y, m, d+offset, t.Location())
+			if ok && !t.Before(from) && t.Before(to) {
+				return scheduleState{Active: true, Threads: w.Threads}
+			}
+		}
+	}
+	return scheduleState{}
+}
+
+// nextScheduleTransition returns the next time within a week after t at which
+// the scheduled state changes, and the state from then on.
+func nextScheduleTransition(windows []ScheduleWindow, t time.Time) (time.Time, scheduleState, bool) {
+	y, m, d := t.Date()
+	var bounds []time.Time
+	for _, w := range windows {
+		for offset := -1; offset <= 7; offset++ {
+			from, to, ok := w.span(y, m, d+offset, t.Location())
+			if !ok {
+				continue
+			}
+			if from.After(t) {
+				bounds = append(bounds, from)
+			}
+			if to.After(t) {
+				bounds = append(bounds, to)
+			}
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+	current := scheduleStateAt(windows, t)
+	for _, b := range bounds {
+		if state := scheduleStateAt(windows, b); state != current {
+			return b, state, true
+		}
+	}
+	return time.Time{}, scheduleState{}, false
+}
+
+func describeScheduleWindow(w ScheduleWindow) string {
+	days := "Every day"
+	if len(w.Days) > 0 && len(w.Days) < 7 {
+		var names []string
+		for _, day := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+			if w.onDay(day) {
+				key := weekdayKeys[day]
+				names = append(names, strings.ToUpper(key[:1])+key[1:])
+			}
+		}
+		days = strings.Join(names, ", ")
+	}
+	text := fmt.Sprintf("%s %s–%s", days, w.Start, w.End)
+	if w.Threads > 0 {
+		text += fmt.Sprintf(" · %d threads", w.Threads)
+	}
+	return text
+}
+
+// minerScheduler starts or resumes the miner when a window of the weekly
+// schedule in Config begins and pauses it when the window ends. It only acts
+// when the scheduled state changes, so a manual start, stop or resume holds
+// until the next transition.
+type minerScheduler struct {
+	ctrl   *Controller
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	status string
+}
+
+func startScheduler(ctrl *Controller) *minerScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &minerScheduler{ctrl: ctrl, cancel: cancel}
+	go s.run(ctx)
+	return s
+}
+
+func (s *minerScheduler) Close() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+}
+
+// StatusText describes the current scheduled state and the next transition;
+// it is empty when the schedule is off.
+func (s *minerScheduler) StatusText() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *minerScheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	applied := false
+	var last scheduleState
+	for {
+		cfg := s.ctrl.Snapshot()
+		windows := cfg.Schedule
+		if !cfg.ScheduleEnabled || len(windows) == 0 {
+			if applied {
+				s.ctrl.setThreadLimit(limitSourceSchedule, 0)
+				s.ctrl.setPauseHold(limitSourceSchedule, false)
+				s.ctrl.restartIfLimitChanged(ctx, "[schedule]")
+			}
+			applied = false
+			s.setStatus("")
+		} else {
+			now := time.Now()
+			state := scheduleStateAt(windows, now)
+			if !applied || state != last {
+				s.apply(ctx, state)
+				applied = true
+				last = state
+			}
+			s.setStatus(scheduleStatusText(state, windows, now))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *minerScheduler) setStatus(text string) {
+	s.mu.Lock()
+	s.status = text
+	s.mu.Unlock()
+}
+
+func scheduleStatusText(state scheduleState, windows []ScheduleWindow, now time.Time) string {
+	next, nextState, ok := nextScheduleTransition(windows, now)
+	switch {
+	case state.Active && ok && nextState.Active:
+		return "Mining; threads change " + formatScheduleTime(next, now)
+	case state.Active && ok:
+		return "Mining until " + formatScheduleTime(next, now)
+	case state.Active:
+		return "Mining (no end this week)"
+	case ok:
+		return "Next start " + formatScheduleTime(next, now)
+	default:
+		return "No window this week"
+	}
+}
+
+func formatScheduleTime(t, now time.Time) string {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	if y1 == y2 && m1 == m2 && d1 == d2 {
+		return t.Format("15:04")
+	}
+	return t.Format("Mon 15:04")
+}
+
+func (s *minerScheduler) apply(ctx context.Context, state scheduleState) {
+	ctrl := s.ctrl
+	running := ctrl.MinerRunning()
+	if !state.Active {
+		if running {
+			ctrl.appendMinerLog("[schedule] Window ended. Pausing miner.\n")
+			ctrl.setPauseHold(limitSourceSchedule, true)
+		} else {
+			ctrl.setThreadLimit(limitSourceSchedule, 0)
+		}
+		return
+	}
+
+	ctrl.setThreadLimit(limitSourceSchedule, state.Threads)
+	if !running {
+		ctrl.setPauseHold(limitSourceSchedule, false)
+		ctrl.appendMinerLog("[schedule] Window started. Starting miner.\n")
+		if err := ctrl.StartMiner(minerStartOriginScheduler); err != nil {
+			ctrl.appendMinerLog(fmt.Sprintf("[schedule] Start failed: %v\n", err))
+		}
+		return
+	}
+	if slices.Contains(ctrl.PauseReasons(), limitSourceSchedule) {
+		ctrl.appendMinerLog("[schedule] Window started. Resuming miner.\n")
+		ctrl.setPauseHold(limitSourceSchedule, false)
+	}
+	// Only a different thread count needs the process restarted.
+	ctrl.restartIfLimitChanged(ctx, "[schedule]")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestScheduleStateAt(t *testing.T) {
+	// 2026-10-16 is a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+	overnight := []ScheduleWindow{{Start: "22:00", End: "06:00", Threads: 2}}
+	friday := []ScheduleWindow{{Days: []string{"fri"}, Start: "22:00", End: "06:00"}}
+	tests := []struct {
+		name    string
+		windows []ScheduleWindow
+		t       time.Time
+		want    scheduleState
+	}{
+		{"before overnight window", overnight, at(16, 21, 59), scheduleState{}},
+		{"overnight window start", overnight, at(16, 22, 0), scheduleState{Active: true, Threads: 2}},
+		{"overnight window past midnight", overnight, at(17, 5, 59), scheduleState{Active: true, Threads: 2}},
+		{"overnight window end", overnight, at(17, 6, 0), scheduleState{}},
+		{"friday night into saturday", friday, at(17, 3, 0), scheduleState{Active: true}},
+		{"friday early morning", friday, at(16, 3, 0), scheduleState{}},
+		{"saturday night", friday, at(17, 23, 0), scheduleState{}},
+		{"until 24:00", []ScheduleWindow{{Start: "18:00", End: "24:00"}}, at(16, 23, 59), scheduleState{Active: true}},
+		{"first window wins", []ScheduleWindow{{Start: "08:00", End: "12:00", Threads: 1}, {Start: "10:00", End: "14:00", Threads: 4}}, at(16, 11, 0), scheduleState{Active: true, Threads: 1}},
+		{"no windows", nil, at(16, 12, 0), scheduleState{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleStateAt(tt.windows, tt.t); got != tt.want {
+				t.Errorf("scheduleStateAt(%v) = %+v, want %+v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextScheduleTransition(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	at := func(loc *time.Location, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+	overnight := []ScheduleWindow{{Start: "22:00", End: "06:00"}}
+	tests := []struct {
+		name      string
+		windows   []ScheduleWindow
+		t         time.Time
+		wantAt    time.Time
+		wantState scheduleState
+	}{
+		{
+			name:      "start tonight",
+			windows:   overnight,
+			t:         at(time.UTC, time.October, 16, 12, 0),
+			wantAt:    at(time.UTC, time.October, 16, 22, 0),
+			wantState: scheduleState{Active: true},
+		},
+		{
+			name:    "end after midnight",
+			windows: overnight,
+			t:       at(time.UTC, time.October, 16, 23, 0),
+			wantAt:  at(time.UTC, time.October, 17, 6, 0),
+		},
+		{
+			name:    "adjacent windows across midnight",
+			windows: []ScheduleWindow{{Start: "20:00", End: "24:00"}, {Start: "00:00", End: "02:00"}},
+			t:       at(time.UTC, time.October, 16, 21, 0),
+			wantAt:  at(time.UTC, time.October, 17, 2, 0),
+		},
+		{
+			name:      "thread cap changes at midnight",
+			windows:   []ScheduleWindow{{Start: "20:00", End: "24:00"}, {Start: "00:00", End: "02:00", Threads: 2}},
+			t:         at(time.UTC, time.October, 16, 21, 0),
+			wantAt:    at(time.UTC, time.October, 17, 0, 0),
+			wantState: scheduleState{Active: true, Threads: 2},
+		},
+		{
+			name:      "next week",
+			windows:   []ScheduleWindow{{Days: []string{"thu"}, Start: "08:00", End: "09:00"}},
+			t:         at(time.UTC, time.October, 15, 10, 0),
+			wantAt:    at(time.UTC, time.October, 22, 8, 0),
+			wantState: scheduleState{Active: true},
+		},
+		{
+			// Clocks go forward from 02:00 to 03:00 on 2026-03-29.
+			name:    "spring forward",
+			windows: overnight,
+			t:       at(berlin, time.March, 28, 23, 0),
+			wantAt:  at(berlin, time.March, 29, 6, 0),
+		},
+		{
+			// Clocks go back from 03:00 to 02:00 on 2026-10-25.
+			name:    "fall back",
+			windows: overnight,
+			t:       at(berlin, time.October, 24, 23, 0),
+			wantAt:  at(berlin, time.October, 25, 6, 0),
+		},
+		{
+			name:      "window starting after fall back",
+			windows:   []ScheduleWindow{{Start: "04:00", End: "05:00"}},
+			t:         at(berlin, time.October, 25, 0, 30),
+			wantAt:    at(berlin, time.October, 25, 4, 0),
+			wantState: scheduleState{Active: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAt, gotState, ok := nextScheduleTransition(tt.windows, tt.t)
+			if !ok {
+				t.Fatal("no transition")
+			}
+			if !gotAt.Equal(tt.wantAt) || gotState != tt.wantState {
+				t.Errorf("nextScheduleTransition() = %v, %+v, want %v, %+v", gotAt, gotState, tt.wantAt, tt.wantState)
+			}
+		})
+	}
+
+	if _, _, ok := nextScheduleTransition(nil, time.Now()); ok {
+		t.Error("transition without windows")
+	}
+	always := []ScheduleWindow{{Start: "00:00", End: "24:00"}}
+	if _, _, ok := nextScheduleTransition(always, time.Now()); ok {
+		t.Error("transition in a window that never ends")
+	}
+}
+
+func TestScheduleStateAtDST(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	windows := []ScheduleWindow{{Start: "22:00", End: "06:00"}}
+	tests := []struct {
+		name string
+		utc  time.Time
+		want bool
+	}{
+		// 2026-03-29 06:00 CEST is 04:00 UTC; the night is an hour shorter.
+		{"spring forward, before end", time.Date(2026, 3, 29, 3, 59, 0, 0, time.UTC), true},
+		{"spring forward, end", time.Date(2026, 3, 29, 4, 0, 0, 0, time.UTC), false},
+		// 02:30 happens twice on 2026-10-25; both are in the window.
+		{"fall back, first 02:30", time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC), true},
+		{"fall back, second 02:30", time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC), true},
+		// 06:00 CET is 05:00 UTC; the night is an hour longer.
+		{"fall back, before end", time.Date(2026, 10, 25, 4, 59, 0, 0, time.UTC), true},
+		{"fall back, end", time.Date(2026, 10, 25, 5, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleStateAt(windows, tt.utc.In(berlin)).Active; got != tt.want {
+				t.Errorf("active at %v = %v, want %v", tt.utc.In(berlin), got, tt.want)
+			}
+		})
+	}
+}
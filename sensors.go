@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSysfsRoot = "/sys"
+
+// cpuTopology locates a logical CPU on its package and physical core. Core is
+// the per-package core_id that hwmon drivers use in their labels.
+type cpuTopology struct {
+	Package int
+	Core    int
+}
+
+// sensorReadings are the raw values collected from the platform before they
+// are mapped to logical CPUs.
+type sensorReadings struct {
+	topology     map[int]cpuTopology
+	coreTemps    map[cpuTopology]int
+	packageTemps map[int]int
+	packageWatts map[int]float64
+	fanPercent   int
+	fanRPM       int
+}
+
+type raplSample struct {
+	energyUJ uint64
+	rangeUJ  uint64
+	at       time.Time
+}
+
+// sensorReader samples CPU temperatures, fans and package power. Power is
+// derived from energy counters, so the first Read after start has none.
+type sensorReader struct {
+	root string
+
+	mu   sync.Mutex
+	rapl map[string]raplSample
+}
+
+func newSensorReader(root string) *sensorReader {
+	root = strings.TrimSpace(root)
+	if root == "" {
+		root = defaultSysfsRoot
+	}
+	return &sensorReader{root: root, rapl: make(map[string]raplSample)}
+}
+
+// HottestTemp returns the highest package or core temperature in °C.
+func (r *sensorReader) HottestTemp() (int, bool) {
+	readings := r.collect()
+	hottest, found := 0, false
+	for _, temp := range readings.packageTemps {
+		if !found || temp > hottest {
+			hottest, found = temp, true
+		}
+	}
+	for _, temp := range readings.coreTemps {
+		if !found || temp > hottest {
+			hottest, found = temp, true
+		}
+	}
+	return hottest, found
+}
+
+// Read returns sensor values keyed by logical CPU index. Package power is
+// split evenly across the logical CPUs of the package so the column sums
+// to the package total.
+func (r *sensorReader) Read(devices []Device) map[int]deviceSensors {
+	readings := r.collect()
+	out := make(map[int]deviceSensors, len(devices))
+	if len(devices) == 0 {
+		return out
+	}
+
+	topo := make(map[int]cpuTopology, len(devices))
+	perPackage := make(map[int]int)
+	for _, d := range devices {
+		t, ok := readings.topology[d.Index]
+		if !ok {
+			t = cpuTopology{Package: d.Socket, Core: d.Core}
+			if t.Package < 0 {
+				t.Package = 0
+			}
+		}
+		topo[d.Index] = t
+		perPackage[t.Package]++
+	}
+
+	for _, d := range devices {
+		t := topo[d.Index]
+		s := deviceSensors{Power: -1, Fan: readings.fanPercent, FanRPM: readings.fanRPM}
+		if temp, ok := readings.coreTemps[t]; ok {
+			s.Temp = temp
+		} else if temp, ok := readings.packageTemps[t.Package]; ok {
+			s.Temp = temp
+		}
+		if watts, ok := readings.packageWatts[t.Package]; ok && perPackage[t.Package] > 0 {
+			s.Power = watts / float64(perPackage[t.Package])
+		}
+		if s.Temp == 0 && s.Fan == 0 && s.FanRPM == 0 && s.Power < 0 {
+			continue
+		}
+		out[d.Index] = s
+	}
+	return out
+}
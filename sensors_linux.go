@@ -0,0 +1,246 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (r *sensorReader) collect() sensorReadings {
+	readings := sensorReadings{
+		topology:     r.readTopology(),
+		coreTemps:    make(map[cpuTopology]int),
+		packageTemps: make(map[int]int),
+		packageWatts: make(map[int]float64),
+	}
+	r.readHWMon(&readings)
+	r.readRAPL(&readings)
+	return readings
+}
+
+func readSysfsInt(path string) (int64, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func readSysfsString(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// sysfsIndexedEntries lists directory entries named prefix+number, sorted by
+// that number, e.g. hwmon0, hwmon1, ..., hwmon10.
+func sysfsIndexedEntries(dir, prefix string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type indexed struct {
+		name string
+		n    int
+	}
+	var list []indexed
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err != nil {
+			continue
+		}
+		list = append(list, indexed{name: name, n: n})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].n < list[j].n })
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		out = append(out, item.name)
+	}
+	return out
+}
+
+func (r *sensorReader) readTopology() map[int]cpuTopology {
+	dir := filepath.Join(r.root, "devices", "system", "cpu")
+	out := make(map[int]cpuTopology)
+	for _, name := range sysfsIndexedEntries(dir, "cpu") {
+		cpu, _ := strconv.Atoi(strings.TrimPrefix(name, "cpu"))
+		pkg, ok1 := readSysfsInt(filepath.Join(dir, name, "topology", "physical_package_id"))
+		core, ok2 := readSysfsInt(filepath.Join(dir, name, "topology", "core_id"))
+		if !ok1 || !ok2 {
+			continue
+		}
+		if pkg < 0 {
+			pkg = 0
+		}
+		out[cpu] = cpuTopology{Package: int(pkg), Core: int(core)}
+	}
+	return out
+}
+
+type hwmonChip struct {
+	dir   string
+	name  string
+	temps map[string]int // label -> °C
+}
+
+func (r *sensorReader) readHWMon(readings *sensorReadings) {
+	dir := filepath.Join(r.root, "class", "hwmon")
+	coretempIndex := 0
+	amdIndex := 0
+	fanFound := false
+	for _, entry := range sysfsIndexedEntries(dir, "hwmon") {
+		chip := hwmonChip{dir: filepath.Join(dir, entry), temps: make(map[string]int)}
+		chip.name = readSysfsString(filepath.Join(chip.dir, "name"))
+		if chip.name == "" {
+			chip.name = readSysfsString(filepath.Join(chip.dir, "device", "name"))
+		}
+		for i := 1; i <= 128; i++ {
+			base := filepath.Join(chip.dir, "temp"+strconv.Itoa(i))
+			milli, ok := readSysfsInt(base + "_input")
+			if !ok {
+				continue
+			}
+			label := readSysfsString(base + "_label")
+			if label == "" {
+				label = "temp" + strconv.Itoa(i)
+			}
+			chip.temps[label] = int((milli + 500) / 1000)
+		}
+
+		switch chip.name {
+		case "coretemp":
+			pkg := coretempIndex
+			coretempIndex++
+			for label := range chip.temps {
+				if rest, ok := strings.CutPrefix(label, "Package id "); ok {
+					if n, err := strconv.Atoi(strings.TrimSpace(rest)); err == nil {
+						pkg = n
+					}
+				}
+			}
+			for label, temp := range chip.temps {
+				if strings.HasPrefix(label, "Package id ") {
+					readings.packageTemps[pkg] = temp
+					continue
+				}
+				if rest, ok := strings.CutPrefix(label, "Core "); ok {
+					if n, err := strconv.Atoi(strings.TrimSpace(rest)); err == nil {
+						readings.coreTemps[cpuTopology{Package: pkg, Core: n}] = temp
+					}
+				}
+			}
+		case "k10temp", "zenpower":
+			pkg := amdIndex
+			amdIndex++
+			// Tdie is the real die temperature; Tctl may carry a fan-control offset.
+			for _, label := range []string{"Tdie", "Tctl", "temp1"} {
+				if temp, ok := chip.temps[label]; ok {
+					readings.packageTemps[pkg] = temp
+					break
+				}
+			}
+		}
+
+		if !fanFound {
+			if rpm, percent, cpuFan, ok := readHWMonFan(chip.dir); ok {
+				readings.fanRPM = rpm
+				readings.fanPercent = percent
+				fanFound = cpuFan
+			}
+		}
+	}
+}
+
+// readHWMonFan returns the speed of the chip's CPU fan when one is labelled
+// as such, otherwise of its first spinning fan. cpuFan reports whether the
+// label identified a CPU fan.
+func readHWMonFan(chipDir string) (rpm, percent int, cpuFan, ok bool) {
+	for i := 1; i <= 16; i++ {
+		base := filepath.Join(chipDir, "fan"+strconv.Itoa(i))
+		v, exists := readSysfsInt(base + "_input")
+		if !exists || v <= 0 {
+			continue
+		}
+		isCPU := strings.Contains(strings.ToLower(readSysfsString(base+"_label")), "cpu")
+		if ok && !isCPU {
+			continue
+		}
+		rpm, cpuFan, ok = int(v), isCPU, true
+		percent = 0
+		if pwm, exists := readSysfsInt(filepath.Join(chipDir, "pwm"+strconv.Itoa(i))); exists && pwm >= 0 {
+			percent = int((pwm*100 + 127) / 255)
+		}
+		if isCPU {
+			return
+		}
+	}
+	return
+}
+
+func (r *sensorReader) readRAPL(readings *sensorReadings) {
+	dir := filepath.Join(r.root, "class", "powercap")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range entries {
+		name := e.Name()
+		// Top-level zones only (intel-rapl:0); sub-zones (intel-rapl:0:0) are
+		// cores/uncore/dram and already included in the package counter.
+		rest, ok := strings.CutPrefix(name, "intel-rapl:")
+		if !ok || strings.Contains(rest, ":") {
+			continue
+		}
+		zone := filepath.Join(dir, name)
+		zoneName := readSysfsString(filepath.Join(zone, "name"))
+		pkgText, ok := strings.CutPrefix(zoneName, "package-")
+		if !ok {
+			continue
+		}
+		pkg, err := strconv.Atoi(pkgText)
+		if err != nil {
+			continue
+		}
+		energy, ok := readSysfsInt(filepath.Join(zone, "energy_uj"))
+		if !ok || energy < 0 {
+			continue
+		}
+		maxRange, _ := readSysfsInt(filepath.Join(zone, "max_energy_range_uj"))
+		cur := raplSample{energyUJ: uint64(energy), rangeUJ: uint64(maxRange), at: now}
+		prev, seen := r.rapl[name]
+		r.rapl[name] = cur
+		if !seen {
+			continue
+		}
+		elapsed := cur.at.Sub(prev.at).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+		delta := cur.energyUJ - prev.energyUJ
+		if cur.energyUJ < prev.energyUJ {
+			if cur.rangeUJ == 0 {
+				continue
+			}
+			delta = cur.rangeUJ - prev.energyUJ + cur.energyUJ
+		}
+		readings.packageWatts[pkg] = float64(delta) / 1e6 / elapsed
+	}
+}
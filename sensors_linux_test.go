@@ -0,0 +1,169 @@
+//go:build linux
+
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeSysfs creates a fake sysfs tree under root from path -> content.
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func cpuTopologyFiles(topology map[int]cpuTopology) map[string]string {
+	files := make(map[string]string)
+	for cpu, t := range topology {
+		dir := "devices/system/cpu/cpu" + strconv.Itoa(cpu) + "/topology/"
+		files[dir+"physical_package_id"] = strconv.Itoa(t.Package)
+		files[dir+"core_id"] = strconv.Itoa(t.Core)
+	}
+	return files
+}
+
+func TestSensorReaderCoretemp(t *testing.T) {
+	root := t.TempDir()
+	writeSysfs(t, root, cpuTopologyFiles(map[int]cpuTopology{
+		0: {Package: 0, Core: 0},
+		1: {Package: 0, Core: 1},
+		2: {Package: 0, Core: 0},
+		3: {Package: 0, Core: 1},
+	}))
+	writeSysfs(t, root, map[string]string{
+		"class/hwmon/hwmon0/name":        "acpitz",
+		"class/hwmon/hwmon0/temp1_input": "27800",
+		"class/hwmon/hwmon2/name":        "coretemp",
+		"class/hwmon/hwmon2/temp1_label": "Package id 0",
+		"class/hwmon/hwmon2/temp1_input": "71000",
+		"class/hwmon/hwmon2/temp2_label": "Core 0",
+		"class/hwmon/hwmon2/temp2_input": "64499",
+		"class/hwmon/hwmon2/temp3_label": "Core 1",
+		"class/hwmon/hwmon2/temp3_input": "68500",
+		"class/hwmon/hwmon2/fan1_label":  "CPU Fan",
+		"class/hwmon/hwmon2/fan1_input":  "1500",
+		"class/hwmon/hwmon2/pwm1":        "128",
+	})
+
+	r := newSensorReader(root)
+	devices := []Device{{Index: 0}, {Index: 1}, {Index: 2}, {Index: 3}}
+	got := r.Read(devices)
+	want := map[int]deviceSensors{
+		0: {Temp: 64, Fan: 50, FanRPM: 1500, Power: -1},
+		1: {Temp: 69, Fan: 50, FanRPM: 1500, Power: -1},
+		2: {Temp: 64, Fan: 50, FanRPM: 1500, Power: -1},
+		3: {Temp: 69, Fan: 50, FanRPM: 1500, Power: -1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+	if temp, ok := r.HottestTemp(); !ok || temp != 71 {
+		t.Errorf("HottestTemp() = %d, %v, want 71, true", temp, ok)
+	}
+}
+
+func TestSensorReaderK10temp(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   int
+	}{
+		{"Tdie over Tctl", map[string]string{"Tctl": "80000", "Tdie": "70000"}, 70},
+		{"Tctl", map[string]string{"Tctl": "55400"}, 55},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeSysfs(t, root, cpuTopologyFiles(map[int]cpuTopology{0: {}, 1: {Core: 1}}))
+			files := map[string]string{"class/hwmon/hwmon1/name": "k10temp"}
+			i := 1
+			for label, milli := range tt.labels {
+				base := "class/hwmon/hwmon1/temp" + strconv.Itoa(i)
+				files[base+"_label"] = label
+				files[base+"_input"] = milli
+				i++
+			}
+			writeSysfs(t, root, files)
+
+			got := newSensorReader(root).Read([]Device{{Index: 0}, {Index: 1}})
+			for cpu := range 2 {
+				if got[cpu].Temp != tt.want {
+					t.Errorf("cpu%d temp = %d, want %d", cpu, got[cpu].Temp, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSensorReaderRAPL(t *testing.T) {
+	const maxRange = 262143328850
+	tests := []struct {
+		name         string
+		first, after int64
+		wantWatts    float64
+	}{
+		{"counting up", 1_000_000, 81_000_000, 40},
+		{"wrap-around", maxRange - 20_000_000, 60_000_000, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeSysfs(t, root, cpuTopologyFiles(map[int]cpuTopology{0: {}, 1: {Core: 1}}))
+			zone := "class/powercap/intel-rapl:0/"
+			writeSysfs(t, root, map[string]string{
+				zone + "name":                "package-0",
+				zone + "energy_uj":           strconv.FormatInt(tt.first, 10),
+				zone + "max_energy_range_uj": strconv.FormatInt(maxRange, 10),
+				// Sub-zones are part of the package counter.
+				"class/powercap/intel-rapl:0:0/name":      "core",
+				"class/powercap/intel-rapl:0:0/energy_uj": "5",
+			})
+			r := newSensorReader(root)
+			devices := []Device{{Index: 0}, {Index: 1}}
+			if got := r.Read(devices); len(got) != 0 {
+				t.Fatalf("first Read() = %v, want no power yet", got)
+			}
+
+			// Pretend the first sample was taken two seconds ago.
+			r.mu.Lock()
+			prev := r.rapl["intel-rapl:0"]
+			prev.at = prev.at.Add(-2 * time.Second)
+			r.rapl["intel-rapl:0"] = prev
+			r.mu.Unlock()
+			writeSysfs(t, root, map[string]string{zone + "energy_uj": strconv.FormatInt(tt.after, 10)})
+
+			got := r.Read(devices)
+			var total float64
+			for _, s := range got {
+				total += s.Power
+			}
+			if len(got) != 2 || math.Abs(total-tt.wantWatts) > 0.5 {
+				t.Errorf("package power = %.2f W over %v, want %.0f W", total, got, tt.wantWatts)
+			}
+		})
+	}
+}
+
+func TestSensorReaderEmptyRoot(t *testing.T) {
+	r := newSensorReader(t.TempDir())
+	if got := r.Read([]Device{{Index: 0}}); len(got) != 0 {
+		t.Errorf("Read() = %v, want none", got)
+	}
+	if _, ok := r.HottestTemp(); ok {
+		t.Error("HottestTemp() found a temperature in an empty tree")
+	}
+}
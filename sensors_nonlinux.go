@@ -0,0 +1,7 @@
+//go:build !linux
+
+package main
+
+func (r *sensorReader) collect() sensorReadings {
+	return sensorReadings{}
+}
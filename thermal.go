@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultThermalLimitC  = 85
+	defaultThermalResumeC = 75
+
+	thermalCheckInterval = 5 * time.Second
+	// thermalStepAfter is how long a reduction gets to take effect before
+	// the next one; thermalResumeAfter is how long the temperature must stay
+	// at or below the resume point before the full configuration returns.
+	thermalStepAfter   = time.Minute
+	thermalResumeAfter = time.Minute
+)
+
+// tempSource reports the hottest CPU temperature in °C. sensorReader
+// implements it from hwmon; tests can substitute a fake.
+type tempSource interface {
+	HottestTemp() (int, bool)
+}
+
+// thermalGovernor keeps the CPU under Config.ThermalLimitC. Over the limit
+// it halves the miner threads step by step and pauses xmrig once a single
+// thread is still too hot (or right away with the pause action). It
+// restores the full configuration after the temperature has stayed at or
+// below Config.ThermalResumeC for thermalResumeAfter.
+type thermalGovernor struct {
+	ctrl    *Controller
+	sensors tempSource
+	cancel  context.CancelFunc
+
+	limit     int
+	paused    bool
+	lastStep  time.Time
+	coolSince time.Time
+	noSensor  bool
+	// restartPending is set when a changed limit could not be applied
+	// because another restart was in progress.
+	restartPending bool
+}
+
+func startThermalGovernor(ctrl *Controller, sensors tempSource) *thermalGovernor {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &thermalGovernor{ctrl: ctrl, sensors: sensors, cancel: cancel}
+	go g.run(ctx)
+	return g
+}
+
+func (g *thermalGovernor) Close() {
+	if g == nil {
+		return
+	}
+	g.cancel()
+}
+
+func (g *thermalGovernor) run(ctx context.Context) {
+	ticker := time.NewTicker(thermalCheckInterval)
+	defer ticker.Stop()
+	for {
+		g.check(ctx, g.ctrl.Snapshot(), time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (g *thermalGovernor) throttling() bool {
+	return g.limit > 0 || g.paused
+}
+
+func (g *thermalGovernor) check(ctx context.Context, cfg *Config, now time.Time) {
+	if g.restartPending {
+		g.applyLimit(ctx)
+	}
+	if !cfg.ThermalEnabled {
+		g.restore(ctx, "[thermal] Disabled. Restoring full configuration.\n")
+		return
+	}
+	temp, ok := g.sensors.HottestTemp()
+	if !ok {
+		if !g.noSensor {
+			g.ctrl.appendMinerLog("[thermal] No CPU temperature sensor found; thermal protection is inactive.\n")
+		}
+		g.noSensor = true
+		g.restore(ctx, "")
+		return
+	}
+	g.noSensor = false
+
+	switch {
+	case temp >= cfg.ThermalLimitC:
+		g.coolSince = time.Time{}
+		if !g.ctrl.MinerRunning() || g.paused {
+			return
+		}
+		if g.throttling() && now.Sub(g.lastStep) < thermalStepAfter {
+			return
+		}
+		g.tighten(ctx, cfg, temp, now)
+
+	case g.throttling() && temp <= cfg.ThermalResumeC:
+		if g.coolSince.IsZero() {
+			g.coolSince = now
+		}
+		if now.Sub(g.coolSince) >= thermalResumeAfter {
+			g.restore(ctx, fmt.Sprintf("[thermal] %d°C, at or below %d°C for %s. Restoring full configuration.\n", temp, cfg.ThermalResumeC, thermalResumeAfter))
+		}
+
+	default:
+		g.coolSince = time.Time{}
+	}
+}
+
+// tighten takes the next step down: half the threads in use, or a pause.
+func (g *thermalGovernor) tighten(ctx context.Context, cfg *Config, temp int, now time.Time) {
+	g.lastStep = now
+	g.ctrl.setThrottled(true)
+
+	threads := g.limit
+	if threads == 0 {
+		threads = configuredThreads(applyThreadLimit(cfg, g.ctrl.threadLimit()))
+		if st, ok := g.ctrl.LastStats(); ok && st.Threads > 0 {
+			threads = st.Threads
+		}
+	}
+	if cfg.ThermalAction == throttleActionPause || threads <= 1 {
+		g.paused = true
+		g.ctrl.appendMinerLog(fmt.Sprintf("[thermal] %d°C, limit %d°C. Pausing miner.\n", temp, cfg.ThermalLimitC))
+		g.ctrl.setPauseHold(limitSourceThermal, true)
+		return
+	}
+
+	g.limit = max(1, threads/2)
+	g.ctrl.appendMinerLog(fmt.Sprintf("[thermal] %d°C, limit %d°C. Reducing miner to %s.\n", temp, cfg.ThermalLimitC, threadsText(g.limit)))
+	g.ctrl.setThreadLimit(limitSourceThermal, g.limit)
+	g.applyLimit(ctx)
+}
+
+// restore lifts the thermal cap and pause, logging msg when the governor
+// was throttling.
+func (g *thermalGovernor) restore(ctx context.Context, msg string) {
+	g.coolSince = time.Time{}
+	if !g.throttling() {
+		return
+	}
+	if msg != "" {
+		g.ctrl.appendMinerLog(msg)
+	}
+	g.limit = 0
+	g.paused = false
+	g.ctrl.setThrottled(false)
+	g.ctrl.setPauseHold(limitSourceThermal, false)
+	g.ctrl.setThreadLimit(limitSourceThermal, 0)
+	g.applyLimit(ctx)
+}
+
+// applyLimit restarts the miner with the current thread limit, or keeps the
+// restart pending for the next sample while another restart is in progress.
+func (g *thermalGovernor) applyLimit(ctx context.Context) {
+	g.restartPending = !g.ctrl.restartIfLimitChanged(ctx, "[thermal]")
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTempSource struct {
+	temp int
+	ok   bool
+}
+
+func (f *fakeTempSource) HottestTemp() (int, bool) { return f.temp, f.ok }
+
+// fakeRunningMiner makes c see a running miner, started without a thread
+// limit. The process is a sleep, so a restart only interrupts it.
+func fakeRunningMiner(t *testing.T, c *Controller) {
+	t.Helper()
+	path, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep command")
+	}
+	cmd := exec.Command(path, "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	c.procMu.Lock()
+	c.minerCmd = cmd
+	c.procMu.Unlock()
+	// Keep syncPause from calling the xmrig API.
+	c.waitingForStats.Store(true)
+}
+
+func TestThermalGovernorSteps(t *testing.T) {
+	type step struct {
+		after       time.Duration // since the previous step
+		temp        int
+		noSensor    bool
+		wantThreads int
+		wantPause   bool
+	}
+	tests := []struct {
+		name   string
+		action string
+		steps  []step
+	}{
+		{
+			name:   "halve threads, then pause",
+			action: throttleActionThreads,
+			steps: []step{
+				{temp: 84},
+				{after: 5 * time.Second, temp: 85, wantThreads: 4},
+				// A step gets a minute to take effect.
+				{after: 30 * time.Second, temp: 90, wantThreads: 4},
+				{after: 30 * time.Second, temp: 90, wantThreads: 2},
+				{after: time.Minute, temp: 90, wantThreads: 1},
+				{after: time.Minute, temp: 90, wantThreads: 1, wantPause: true},
+			},
+		},
+		{
+			name:   "pause action",
+			action: throttleActionPause,
+			steps: []step{
+				{temp: 86, wantPause: true},
+				{after: time.Minute, temp: 86, wantPause: true},
+			},
+		},
+		{
+			name:   "resume after staying cool",
+			action: throttleActionThreads,
+			steps: []step{
+				{temp: 90, wantThreads: 4},
+				// Between the resume point and the limit nothing changes.
+				{after: 5 * time.Minute, temp: 80, wantThreads: 4},
+				{after: 5 * time.Second, temp: 75, wantThreads: 4},
+				{after: 59 * time.Second, temp: 70, wantThreads: 4},
+				{after: time.Second, temp: 70},
+			},
+		},
+		{
+			name:   "warming up restarts the cool-down",
+			action: throttleActionThreads,
+			steps: []step{
+				{temp: 90, wantThreads: 4},
+				{after: 5 * time.Second, temp: 70, wantThreads: 4},
+				{after: 50 * time.Second, temp: 76, wantThreads: 4},
+				{after: 5 * time.Second, temp: 70, wantThreads: 4},
+				{after: 50 * time.Second, temp: 70, wantThreads: 4},
+				{after: 10 * time.Second, temp: 70},
+			},
+		},
+		{
+			name:   "sensor lost",
+			action: throttleActionPause,
+			steps: []step{
+				{temp: 90, wantPause: true},
+				{after: 5 * time.Second, noSensor: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.CPUThreads = 8
+			cfg.ThermalEnabled = true
+			cfg.ThermalLimitC = 85
+			cfg.ThermalResumeC = 75
+			cfg.ThermalAction = tt.action
+			c := newController(cfg, "", nil)
+			fakeRunningMiner(t, c)
+			// Restarts are covered below; a restart in progress for the
+			// whole test keeps the governor from interrupting the miner.
+			c.watchdogRestarting.Store(true)
+			sensor := &fakeTempSource{}
+			g := &thermalGovernor{ctrl: c, sensors: sensor}
+			now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				sensor.temp, sensor.ok = s.temp, !s.noSensor
+				g.check(context.Background(), cfg, now)
+				if got := c.threadLimit(); got != s.wantThreads {
+					t.Errorf("step %d: thread limit = %d, want %d", i, got, s.wantThreads)
+				}
+				if got := c.pauseHeld(); got != s.wantPause {
+					t.Errorf("step %d: paused = %v, want %v", i, got, s.wantPause)
+				}
+				if got := c.Throttled(); got != (s.wantThreads > 0 || s.wantPause) {
+					t.Errorf("step %d: throttled = %v", i, got)
+				}
+			}
+		})
+	}
+}
+
+func TestThermalGovernorPendingRestart(t *testing.T) {
+	tests := []struct {
+		name string
+		// startedWith is the limit the concurrent restart started xmrig
+		// with.
+		startedWith int
+		wantRestart bool
+	}{
+		{"restart started xmrig before the cap", 0, true},
+		{"restart picked up the cap", 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.CPUThreads = 8
+			cfg.ThermalEnabled = true
+			cfg.ThermalLimitC = 85
+			cfg.ThermalAction = throttleActionThreads
+			c := newController(cfg, "", nil)
+			fakeRunningMiner(t, c)
+			sensor := &fakeTempSource{temp: 90, ok: true}
+			g := &thermalGovernor{ctrl: c, sensors: sensor}
+			// A cancelled context stops restartMiner right after it
+			// interrupted the fake miner.
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+			c.watchdogRestarting.Store(true)
+			g.check(ctx, cfg, now)
+			if !g.restartPending {
+				t.Fatal("cap not kept pending while another restart runs")
+			}
+
+			c.procMu.Lock()
+			c.minerLimit = tt.startedWith
+			c.procMu.Unlock()
+			c.watchdogRestarting.Store(false)
+			g.check(ctx, cfg, now.Add(5*time.Second))
+
+			restarted := false
+			for _, line := range c.minerLog.Snapshot() {
+				if strings.Contains(line, "[thermal] Restarting miner with at most 4 threads.") {
+					restarted = true
+				}
+			}
+			if restarted != tt.wantRestart {
+				t.Errorf("restarted = %v, want %v", restarted, tt.wantRestart)
+			}
+			if g.restartPending {
+				t.Error("restart still pending")
+			}
+			if got := c.threadLimit(); got != 4 {
+				t.Errorf("thread limit = %d, want 4", got)
+			}
+		})
+	}
+}
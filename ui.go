@@ -57,6 +57,56 @@ func fieldLabel(text string) *widget.Label {
 	return l
 }
 
+// addressField adds an address book picker and a checksum hint to an
+// address entry. book is read on every change, so it may grow later.
+func addressField(entry *widget.Entry, book func() []AddressBookEntry) fyne.CanvasObject {
+	hint := widget.NewLabel("")
+	hint.Wrapping = fyne.TextWrapWord
+	hint.TextStyle = fyne.TextStyle{Italic: true}
+	hint.Hide()
+	update := func(text string) {
+		msg, warn := addressHint(text, book())
+		if msg == "" {
+			hint.Hide()
+			return
+		}
+		hint.Importance = widget.LowImportance
+		if warn {
+			hint.Importance = widget.WarningImportance
+		}
+		hint.SetText(msg)
+		hint.Show()
+	}
+	prev := entry.OnChanged
+	entry.OnChanged = func(text string) {
+		if prev != nil {
+			prev(text)
+		}
+		update(text)
+	}
+
+	var pick *widget.Button
+	pick = widget.NewButtonWithIcon("", theme.AccountIcon(), func() {
+		entries := book()
+		items := make([]*fyne.MenuItem, 0, len(entries))
+		for _, e := range entries {
+			items = append(items, fyne.NewMenuItem(e.Name+"  "+e.Address, func() {
+				entry.SetText(e.Address)
+			}))
+		}
+		if len(items) == 0 {
+			empty := fyne.NewMenuItem("Address book is empty", nil)
+			empty.Disabled = true
+			items = append(items, empty)
+		}
+		driver := fyne.CurrentApp().Driver()
+		pos := driver.AbsolutePositionForObject(pick).AddXY(0, pick.Size().Height)
+		widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), driver.CanvasForObject(pick), pos)
+	})
+	update(entry.Text)
+	return container.NewVBox(container.NewBorder(nil, nil, nil, pick, entry), hint)
+}
+
 func formRow(label string, field fyne.CanvasObject) fyne.CanvasObject {
 	l := fieldLabel(label)
 	return container.NewBorder(nil, nil, l, nil, field)
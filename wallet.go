@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	walletFileName      = "wallet.jsonl"
+	walletCheckInterval = time.Minute
+	// walletSampleEvery is how often an unchanged balance is still written,
+	// so the chart has points across quiet periods.
+	walletSampleEvery = time.Hour
+	// walletMaxScan bounds the blocks scanned for rewards per check; a
+	// longer gap is caught up over the following checks.
+	walletMaxScan    = 500
+	walletMaxSamples = 50000
+)
+
+var weiPerOLIVO = new(big.Float).SetFloat64(1e18)
+
+// walletRecord is one line in wallet.jsonl: a balance sample (Wei, with the
+// head block scanned for rewards up to) or a coinbase reward (Block).
+type walletRecord struct {
+	T     int64  `json:"t"`
+	Wei   string `json:"wei,omitempty"`
+	Head  int64  `json:"head,omitempty"`
+	Block int64  `json:"block,omitempty"`
+	Hash  string `json:"hash,omitempty"`
+	Miner string `json:"miner,omitempty"`
+}
+
+type walletBalance struct {
+	Time time.Time
+	Wei  *big.Int
+}
+
+type walletReward struct {
+	Time   time.Time
+	Height int64
+	Hash   string
+	Miner  string
+}
+
+// walletStatus is the latest result of a balance check.
+type walletStatus struct {
+	Balances  map[string]*big.Int // by lowercase address
+	Total     *big.Int
+	Head      int64
+	Endpoint  string
+	UpdatedAt time.Time
+	Err       error
+}
+
+// walletTracker polls the balance of our mining addresses with
+// eth_getBalance and scans new blocks for coinbase rewards to them. With
+// history enabled both are appended to wallet.jsonl in the history folder.
+type walletTracker struct {
+	ctrl *Controller
+	path string
+
+	mu          sync.Mutex
+	balances    []walletBalance
+	rewards     []walletReward
+	lastScanned int64
+	lastWritten time.Time
+	status      walletStatus
+	onChange    func()
+	cancel      context.CancelFunc
+}
+
+func startWalletTracker(ctrl *Controller, onChange func()) (*walletTracker, error) {
+	t := &walletTracker{ctrl: ctrl, onChange: onChange}
+	var loadErr error
+	if ctrl.Snapshot().HistoryEnabled {
+		dir, err := historyDir()
+		if err == nil {
+			t.path = filepath.Join(dir, walletFileName)
+			loadErr = t.load()
+		} else {
+			loadErr = err
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	go t.run(ctx)
+	return t, loadErr
+}
+
+func (t *walletTracker) Close() {
+	if t == nil {
+		return
+	}
+	t.cancel()
+}
+
+func (t *walletTracker) load() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var recs []walletRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec walletRecord
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	err = sc.Err()
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	// The file is appended to on every change; cut it back to the samples
+	// kept in memory so it does not grow forever.
+	if kept, dropped := compactWalletRecords(recs, walletMaxSamples); dropped {
+		recs = kept
+		if err := writeWalletRecords(t.path, recs); err != nil {
+			return fmt.Errorf("compact %s: %w", walletFileName, err)
+		}
+	}
+
+	for _, rec := range recs {
+		at := time.Unix(rec.T, 0)
+		switch {
+		case rec.Block > 0:
+			t.rewards = append(t.rewards, walletReward{Time: at, Height: rec.Block, Hash: rec.Hash, Miner: rec.Miner})
+		case rec.Wei != "":
+			wei, ok := new(big.Int).SetString(rec.Wei, 10)
+			if !ok {
+				continue
+			}
+			t.balances = append(t.balances, walletBalance{Time: at, Wei: wei})
+			t.lastScanned = max(t.lastScanned, rec.Head)
+			t.lastWritten = at
+		}
+	}
+	return nil
+}
+
+// compactWalletRecords drops the oldest balance samples beyond limit. Reward
+// records are all kept. dropped reports whether anything was removed.
+func compactWalletRecords(recs []walletRecord, limit int) (kept []walletRecord, dropped bool) {
+	extra := -limit
+	for _, rec := range recs {
+		if rec.Block == 0 && rec.Wei != "" {
+			extra++
+		}
+	}
+	if extra <= 0 {
+		return recs, false
+	}
+	kept = make([]walletRecord, 0, len(recs)-extra)
+	for _, rec := range recs {
+		if extra > 0 && rec.Block == 0 && rec.Wei != "" {
+			extra--
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	return kept, true
+}
+
+// writeWalletRecords replaces the file at path with recs.
+func writeWalletRecords(path string, recs []walletRecord) error {
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0o644)
+}
+
+func (t *walletTracker) appendLocked(recs ...walletRecord) error {
+	if t.path == "" || len(recs) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, rec := range recs {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *walletTracker) run(ctx context.Context) {
+	ticker := time.NewTicker(walletCheckInterval)
+	defer ticker.Stop()
+	for {
+		t.check(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// walletEndpoint is the RPC the balance is read from: Config.WalletRPCURL
+// when set, else the embedded node or the RPC endpoint of the RPC modes.
+func walletEndpoint(ctrl *Controller) string {
+	if url := ctrl.Snapshot().WalletRPCURL; strings.TrimSpace(url) != "" {
+		if endpoint, err := normalizeRPCURL(url); err == nil {
+			return endpoint
+		}
+	}
+	return chainRPCEndpoint(ctrl)
+}
+
+func (t *walletTracker) check(ctx context.Context, now time.Time) {
+	ours := miningAddresses(t.ctrl.Snapshot())
+	endpoint := walletEndpoint(t.ctrl)
+	status := walletStatus{Endpoint: endpoint, UpdatedAt: now}
+	switch {
+	case len(ours) == 0:
+		status.Err = errors.New("no wallet or node mining address configured")
+	case endpoint == "":
+		status.Err = errors.New("no RPC to read the balance from; run the node or set a balance RPC URL")
+	default:
+		status.Err = t.poll(ctx, endpoint, ours, &status, now)
+	}
+	t.mu.Lock()
+	t.status = status
+	onChange := t.onChange
+	t.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+func (t *walletTracker) poll(ctx context.Context, endpoint string, ours map[string]bool, status *walletStatus, now time.Time) error {
+	rpcCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	head, err := rpcHexInt(rpcCtx, endpoint, "eth_blockNumber")
+	if err != nil {
+		return err
+	}
+	status.Head = head
+	status.Balances = make(map[string]*big.Int, len(ours))
+	status.Total = new(big.Int)
+	for addr := range ours {
+		wei, err := rpcBalance(rpcCtx, endpoint, addr)
+		if err != nil {
+			return err
+		}
+		status.Balances[addr] = wei
+		status.Total.Add(status.Total, wei)
+	}
+
+	t.mu.Lock()
+	from := t.lastScanned + 1
+	if t.lastScanned == 0 || t.lastScanned > head {
+		// First check, or another chain: start watching from here.
+		from = head + 1
+	}
+	t.mu.Unlock()
+	to := min(head, from+walletMaxScan-1)
+	var found []walletReward
+	for n := from; n <= to; n++ {
+		blk, err := rpcBlockByNumber(rpcCtx, endpoint, n)
+		if err != nil {
+			return err
+		}
+		if blk == nil {
+			to = n - 1
+			break
+		}
+		if ours[strings.ToLower(blk.Miner)] {
+			at := now
+			if ts, err := parseHexQuantity(blk.Timestamp); err == nil && ts > 0 {
+				at = time.Unix(ts, 0)
+			}
+			found = append(found, walletReward{Time: at, Height: n, Hash: blk.Hash, Miner: strings.ToLower(blk.Miner)})
+		}
+	}
+	scanned := max(to, from-1)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var recs []walletRecord
+	for _, r := range found {
+		t.rewards = append(t.rewards, r)
+		recs = append(recs, walletRecord{T: r.Time.Unix(), Block: r.Height, Hash: r.Hash, Miner: r.Miner})
+		t.ctrl.appendMinerLog(fmt.Sprintf("[wallet] Coinbase reward in block %d to %s\n", r.Height, r.Miner))
+	}
+	changed := len(t.balances) == 0 || t.balances[len(t.balances)-1].Wei.Cmp(status.Total) != 0
+	if changed || len(found) > 0 || now.Sub(t.lastWritten) >= walletSampleEvery {
+		t.balances = append(t.balances, walletBalance{Time: now, Wei: status.Total})
+		if n := len(t.balances); n > walletMaxSamples {
+			t.balances = slices.Delete(t.balances, 0, n-walletMaxSamples)
+		}
+		recs = append(recs, walletRecord{T: now.Unix(), Wei: status.Total.String(), Head: scanned})
+		t.lastWritten = now
+	}
+	t.lastScanned = scanned
+	return t.appendLocked(recs...)
+}
+
+// Status returns the latest balance check.
+func (t *walletTracker) Status() walletStatus {
+	if t == nil {
+		return walletStatus{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Rewards returns the detected coinbase rewards, newest first.
+func (t *walletTracker) Rewards() []walletReward {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	out := slices.Clone(t.rewards)
+	t.mu.Unlock()
+	slices.Reverse(out)
+	return out
+}
+
+// BalanceSeries resamples the balance history of the last span into n
+// evenly spaced values in OLIVO, each the last balance known at that time.
+// Points before the first sample are NaN, so the series always has n values
+// aligned with the chart axis. It returns nil when there is no sample in or
+// before the span.
+func (t *walletTracker) BalanceSeries(span time.Duration, n int, now time.Time) []float64 {
+	if t == nil || n < 2 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	from := now.Add(-span)
+	step := span / time.Duration(n-1)
+	out := make([]float64, n)
+	i := 0
+	known := false
+	var cur *big.Int
+	for k := range out {
+		at := from.Add(time.Duration(k) * step)
+		for i < len(t.balances) && !t.balances[i].Time.After(at) {
+			cur = t.balances[i].Wei
+			i++
+		}
+		if cur == nil {
+			out[k] = math.NaN()
+			continue
+		}
+		out[k] = weiToOLIVO(cur)
+		known = true
+	}
+	if !known {
+		return nil
+	}
+	return out
+}
+
+func rpcBalance(ctx context.Context, endpoint, addr string) (*big.Int, error) {
+	result, err := rpcCall(ctx, endpoint, "eth_getBalance", []any{addr, "latest"})
+	if err != nil {
+		return nil, err
+	}
+	var s string
+	if err := json.Unmarshal(result, &s); err != nil {
+		return nil, err
+	}
+	wei, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid balance %q", s)
+	}
+	return wei, nil
+}
+
+func weiToOLIVO(wei *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerOLIVO).Float64()
+	return f
+}
+
+// formatOLIVO renders a wei amount in OLIVO with four decimals.
+func formatOLIVO(wei *big.Int) string {
+	if wei == nil {
+		return "—"
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerOLIVO).Text('f', 4) + " OLIVO"
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBalanceSeries(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	olivo := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18)) }
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		balances []walletBalance
+		want     []float64
+	}{
+		{
+			name: "history before the span",
+			balances: []walletBalance{
+				{Time: now.Add(-10 * time.Hour), Wei: olivo(1)},
+				{Time: now.Add(-2 * time.Hour), Wei: olivo(3)},
+			},
+			want: []float64{1, 1, 3, 3, 3},
+		},
+		{
+			name: "first sample inside the span",
+			balances: []walletBalance{
+				{Time: now.Add(-150 * time.Minute), Wei: olivo(2)},
+				{Time: now.Add(-30 * time.Minute), Wei: olivo(5)},
+			},
+			want: []float64{nan, nan, 2, 2, 5},
+		},
+		{
+			name:     "sample exactly at a point",
+			balances: []walletBalance{{Time: now, Wei: olivo(7)}},
+			want:     []float64{nan, nan, nan, nan, 7},
+		},
+		{
+			name:     "no sample in or before the span",
+			balances: []walletBalance{{Time: now.Add(time.Minute), Wei: olivo(7)}},
+		},
+		{
+			name: "no samples",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &walletTracker{balances: tt.balances}
+			got := w.BalanceSeries(4*time.Hour, 5, now)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("BalanceSeries() = %v, want nil", got)
+				}
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("BalanceSeries() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.IsNaN(tt.want[i]) != math.IsNaN(got[i]) || !math.IsNaN(got[i]) && math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Fatalf("BalanceSeries() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompactWalletRecords(t *testing.T) {
+	balance := func(ts int64) walletRecord { return walletRecord{T: ts, Wei: "1"} }
+	reward := func(ts int64) walletRecord { return walletRecord{T: ts, Block: ts, Hash: "0x1"} }
+	tests := []struct {
+		name    string
+		recs    []walletRecord
+		want    []walletRecord
+		dropped bool
+	}{
+		{
+			name: "at the limit",
+			recs: []walletRecord{balance(1), reward(2), balance(3)},
+			want: []walletRecord{balance(1), reward(2), balance(3)},
+		},
+		{
+			name:    "oldest balances go, rewards stay",
+			recs:    []walletRecord{balance(1), reward(2), balance(3), balance(4), reward(5), balance(6)},
+			want:    []walletRecord{reward(2), balance(4), reward(5), balance(6)},
+			dropped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dropped := compactWalletRecords(tt.recs, 2)
+			if !reflect.DeepEqual(got, tt.want) || dropped != tt.dropped {
+				t.Errorf("compactWalletRecords() = %+v, %v, want %+v, %v", got, dropped, tt.want, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestWalletLoadCompactsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), walletFileName)
+	var recs []walletRecord
+	for i := range walletMaxSamples + 10 {
+		recs = append(recs, walletRecord{T: int64(i), Wei: "1000", Head: int64(i)})
+		if i == 5 {
+			recs = append(recs, walletRecord{T: int64(i), Block: 5, Hash: "0xabc", Miner: testWallet})
+		}
+	}
+	if err := writeWalletRecords(path, recs); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &walletTracker{path: path}
+	if err := w.load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.balances) != walletMaxSamples || len(w.rewards) != 1 {
+		t.Fatalf("loaded %d balances and %d rewards, want %d and 1", len(w.balances), len(w.rewards), walletMaxSamples)
+	}
+	if first := w.balances[0].Time.Unix(); first != 10 {
+		t.Errorf("oldest balance at %d, want 10", first)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		lines++
+	}
+	if want := walletMaxSamples + 1; lines != want {
+		t.Errorf("%s has %d lines after loading, want %d", walletFileName, lines, want)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	xmrigConfigFileName = "config.json"
+	xmrigCoin           = "OLIVO"
+
+	randomXModeAuto  = "auto"
+	randomXModeFast  = "fast"
+	randomXModeLight = "light"
+
+	// cpuPriorityDefault leaves the xmrig thread priority unchanged.
+	cpuPriorityDefault = -1
+)
+
+// xmrigConfig is the subset of the xmrig config.json schema the GUI writes.
+// Field names and JSON keys follow xmrig's own config file.
+type xmrigConfig struct {
+	API             xmrigAPIConfig     `json:"api"`
+	HTTP            xmrigHTTPConfig    `json:"http"`
+	Autosave        bool               `json:"autosave"`
+	Background      bool               `json:"background"`
+	Colors          bool               `json:"colors"`
+	Title           bool               `json:"title"`
+	RandomX         xmrigRandomXConfig `json:"randomx"`
+	CPU             xmrigCPUConfig     `json:"cpu"`
+	OpenCL          xmrigToggle        `json:"opencl"`
+	CUDA            xmrigToggle        `json:"cuda"`
+	DonateLevel     int                `json:"donate-level"`
+	DonateOverProxy int                `json:"donate-over-proxy"`
+	LogFile         *string            `json:"log-file"`
+	Pools           []xmrigPoolConfig  `json:"pools"`
+	PrintTime       int                `json:"print-time"`
+	HealthPrintTime int                `json:"health-print-time"`
+	DMI             bool               `json:"dmi"`
+	Retries         int                `json:"retries"`
+	RetryPause      int                `json:"retry-pause"`
+	Syslog          bool               `json:"syslog"`
+	UserAgent       *string            `json:"user-agent"`
+	Verbose         int                `json:"verbose"`
+	Watch           bool               `json:"watch"`
+	PauseOnBattery  bool               `json:"pause-on-battery"`
+	PauseOnActive   bool               `json:"pause-on-active"`
+}
+
+type xmrigAPIConfig struct {
+	ID       *string `json:"id"`
+	WorkerID *string `json:"worker-id"`
+}
+
+type xmrigHTTPConfig struct {
+	Enabled     bool    `json:"enabled"`
+	Host        string  `json:"host"`
+	Port        int     `json:"port"`
+	AccessToken *string `json:"access-token"`
+	Restricted  bool    `json:"restricted"`
+}
+
+type xmrigRandomXConfig struct {
+	Init                   int    `json:"init"`
+	InitAVX2               int    `json:"init-avx2"`
+	Mode                   string `json:"mode"`
+	OneGBPages             bool   `json:"1gb-pages"`
+	RDMSR                  bool   `json:"rdmsr"`
+	WRMSR                  bool   `json:"wrmsr"`
+	CacheQoS               bool   `json:"cache_qos"`
+	NUMA                   bool   `json:"numa"`
+	ScratchpadPrefetchMode int    `json:"scratchpad_prefetch_mode"`
+}
+
+// xmrigCPUConfig.RX is either omitted (xmrig picks threads using
+// MaxThreadsHint) or an explicit thread array. Each element is an affinity
+// CPU index, -1 for no affinity, or [intensity, affinity].
+type xmrigCPUConfig struct {
+	Enabled        bool  `json:"enabled"`
+	HugePages      bool  `json:"huge-pages"`
+	HugePagesJIT   bool  `json:"huge-pages-jit"`
+	HWAES          *bool `json:"hw-aes"`
+	Priority       *int  `json:"priority"`
+	MemoryPool     bool  `json:"memory-pool"`
+	Yield          bool  `json:"yield"`
+	MaxThreadsHint int   `json:"max-threads-hint"`
+	ASM            bool  `json:"asm"`
+	RX             []any `json:"rx,omitempty"`
+}
+
+type xmrigToggle struct {
+	Enabled bool `json:"enabled"`
+}
+
+type xmrigPoolConfig struct {
+	Algo      *string `json:"algo"`
+	Coin      string  `json:"coin"`
+	URL       string  `json:"url"`
+	User      string  `json:"user,omitempty"`
+	Pass      string  `json:"pass,omitempty"`
+	RigID     *string `json:"rig-id"`
+	Nicehash  bool    `json:"nicehash"`
+	Keepalive bool    `json:"keepalive"`
+	Enabled   bool    `json:"enabled"`
+	TLS       bool    `json:"tls"`
+	Daemon    bool    `json:"daemon"`
+}
+
+// buildXMRigConfig renders cfg into an xmrig config. The HTTP API listens
+// on 127.0.0.1:apiPort and requires accessToken when it is not empty. It is
+// unrestricted so the miner can be paused and resumed through /json_rpc.
+func buildXMRigConfig(cfg *Config, apiPort int, accessToken string) (*xmrigConfig, error) {
+	xc := &xmrigConfig{
+		HTTP: xmrigHTTPConfig{
+			Enabled:    true,
+			Host:       "127.0.0.1",
+			Port:       apiPort,
+			Restricted: false,
+		},
+		RandomX: xmrigRandomXConfig{
+			Init:                   -1,
+			InitAVX2:               -1,
+			Mode:                   randomXModeAuto,
+			RDMSR:                  true,
+			WRMSR:                  cfg.EnableMSR,
+			NUMA:                   true,
+			ScratchpadPrefetchMode: 1,
+		},
+		CPU: xmrigCPUConfig{
+			Enabled:        true,
+			HugePages:      cfg.UseHugePages,
+			HugePagesJIT:   false,
+			MemoryPool:     false,
+			Yield:          cfg.CPUYield,
+			MaxThreadsHint: 100,
+			ASM:            true,
+		},
+		DonateLevel:     cfg.DonateLevel,
+		DonateOverProxy: cfg.DonateLevel,
+		PrintTime:       cfg.DisplayInterval,
+		HealthPrintTime: 60,
+		Retries:         5,
+		RetryPause:      5,
+		Watch:           false,
+	}
+	if accessToken != "" {
+		token := accessToken
+		xc.HTTP.AccessToken = &token
+	}
+	switch strings.TrimSpace(cfg.RandomXMode) {
+	case randomXModeFast, randomXModeLight:
+		xc.RandomX.Mode = cfg.RandomXMode
+	}
+	xc.RandomX.OneGBPages = cfg.RandomX1GBPages && runtime.GOOS == "linux"
+	if cfg.CPUPriority >= 0 && cfg.CPUPriority <= 5 {
+		priority := cfg.CPUPriority
+		xc.CPU.Priority = &priority
+	}
+
+	switch {
+	case len(cfg.CPUAffinity) > 0:
+		for _, cpu := range cfg.CPUAffinity {
+			xc.CPU.RX = append(xc.CPU.RX, cpu)
+		}
+	case cfg.CPUThreads > 0:
+		for i := 0; i < cfg.CPUThreads; i++ {
+			xc.CPU.RX = append(xc.CPU.RX, -1)
+		}
+	}
+
+	for i, p := range miningPools(cfg) {
+		pool, err := buildXMRigPool(p)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("backup pool %d: %w", i, err)
+		}
+		xc.Pools = append(xc.Pools, pool)
+	}
+	return xc, nil
+}
+
+// buildXMRigPool renders one pool; xmrig tries the pools in config order and
+// fails over to the next one when the active pool stops responding.
+func buildXMRigPool(p PoolConfig) (xmrigPoolConfig, error) {
+	url, err := poolURL(p)
+	if err != nil {
+		return xmrigPoolConfig{}, err
+	}
+	pool := xmrigPoolConfig{
+		Coin:      xmrigCoin,
+		URL:       url,
+		Keepalive: true,
+		Enabled:   true,
+	}
+	switch p.Mode {
+	case modeStratum:
+		pool.User = p.WalletAddress
+		if p.WorkerName != "" {
+			pool.User += "." + p.WorkerName
+		}
+		pool.Pass = "x"
+	case modeRPCGateway:
+		pool.User = p.WalletAddress
+		pool.Daemon = true
+	case modeRPCLocal:
+		pool.Daemon = true
+	}
+	return pool, nil
+}
+
+// xmrigCacheDir is where the launch copy of xmrig and its generated config
+// live.
+func xmrigCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, configDirName, "pkexec-bin"), nil
+}
+
+func xmrigConfigPath() (string, error) {
+	dir, err := xmrigCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, xmrigConfigFileName), nil
+}
+
+// writeXMRigConfig writes xc to the cache dir and returns its path. The file
+// holds the HTTP access token, so it is private to the user.
+func writeXMRigConfig(xc *xmrigConfig) (string, error) {
+	path, err := xmrigConfigPath()
+	if err != nil {
+		return "", err
+	}
+	if err := writeXMRigConfigFile(xc, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func writeXMRigConfigFile(xc *xmrigConfig, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(xc, "", "    ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// readXMRigConfigForDisplay returns the last generated config with the
+// access token masked.
+func readXMRigConfigForDisplay() (string, error) {
+	path, err := xmrigConfigPath()
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", errors.New("no xmrig config generated yet; start mining once")
+		}
+		return "", err
+	}
+	var xc xmrigConfig
+	if err := json.Unmarshal(b, &xc); err != nil {
+		return "", err
+	}
+	return formatXMRigConfigForDisplay(&xc)
+}
+
+func formatXMRigConfigForDisplay(xc *xmrigConfig) (string, error) {
+	masked := *xc
+	if masked.HTTP.AccessToken != nil {
+		redacted := "********"
+		masked.HTTP.AccessToken = &redacted
+	}
+	b, err := json.MarshalIndent(&masked, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
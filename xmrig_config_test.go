@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// renderedField returns the compact JSON at the dotted path in the config
+// file rendered from xc, or "" when the key is absent.
+func renderedField(t *testing.T, xc *xmrigConfig, path string) string {
+	t.Helper()
+	b, err := json.Marshal(xc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		if v, ok = m[key]; !ok {
+			return ""
+		}
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+func TestBuildXMRigConfigThreads(t *testing.T) {
+	tests := []struct {
+		name     string
+		threads  int
+		affinity []int
+		want     string
+	}{
+		{"affinity list", 8, []int{0, 2, 5}, `[0,2,5]`},
+		{"thread count", 3, nil, `[-1,-1,-1]`},
+		{"chosen by xmrig", 0, nil, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.WalletAddress = testWallet
+			cfg.CPUThreads = tt.threads
+			cfg.CPUAffinity = tt.affinity
+			xc, err := buildXMRigConfig(cfg, 18000, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := renderedField(t, xc, "cpu.rx"); got != tt.want {
+				t.Errorf("cpu.rx = %s, want %s", got, tt.want)
+			}
+			if got := renderedField(t, xc, "cpu.max-threads-hint"); got != "100" {
+				t.Errorf("cpu.max-threads-hint = %s, want 100", got)
+			}
+		})
+	}
+}
+
+func TestBuildXMRigConfigFields(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.WalletAddress = testWallet
+	cfg.DonateLevel = 1
+	cfg.DisplayInterval = 30
+	cfg.CPUPriority = 2
+	cfg.UseHugePages = false
+	cfg.EnableMSR = false
+	cfg.RandomXMode = randomXModeLight
+	xc, err := buildXMRigConfig(cfg, 18000, "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"http", `{"access-token":"secret-token","enabled":true,"host":"127.0.0.1","port":18000,"restricted":false}`},
+		{"donate-level", `1`},
+		{"donate-over-proxy", `1`},
+		{"print-time", `30`},
+		{"cpu.priority", `2`},
+		{"cpu.huge-pages", `false`},
+		{"randomx.mode", `"light"`},
+		{"randomx.wrmsr", `false`},
+		{"opencl.enabled", `false`},
+		{"cuda.enabled", `false`},
+	}
+	for _, tt := range tests {
+		if got := renderedField(t, xc, tt.path); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	cfg.CPUPriority = cpuPriorityDefault
+	cfg.RandomXMode = "bogus"
+	xc, err = buildXMRigConfig(cfg, 18000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"http.access-token": `null`,
+		"cpu.priority":      `null`,
+		"randomx.mode":      `"auto"`,
+	} {
+		if got := renderedField(t, xc, path); got != want {
+			t.Errorf("%s = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestBuildXMRigConfigPools(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Mode = modeStratum
+	cfg.StratumHost = "pool.example.org"
+	cfg.StratumPort = 8008
+	cfg.WalletAddress = testWallet
+	cfg.WorkerName = "rig1"
+	cfg.Pools = []PoolConfig{
+		{Mode: modeRPCGateway, RPCURL: "https://gw.example.org/", WalletAddress: testWallet},
+		{Mode: modeStratum, Host: "off.example.org", Port: 1, WalletAddress: testWallet, Disabled: true},
+		{Mode: modeRPCLocal, RPCURL: "http://127.0.0.1:8545"},
+	}
+	xc, err := buildXMRigConfig(cfg, 18000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[` +
+		`{"algo":null,"coin":"OLIVO","daemon":false,"enabled":true,"keepalive":true,"nicehash":false,"pass":"x","rig-id":null,"tls":false,"url":"stratum1+tcp://pool.example.org:8008","user":"` + testWallet + `.rig1"},` +
+		`{"algo":null,"coin":"OLIVO","daemon":true,"enabled":true,"keepalive":true,"nicehash":false,"rig-id":null,"tls":false,"url":"daemon+https://gw.example.org","user":"` + testWallet + `"},` +
+		`{"algo":null,"coin":"OLIVO","daemon":true,"enabled":true,"keepalive":true,"nicehash":false,"rig-id":null,"tls":false,"url":"daemon+http://127.0.0.1:8545"}` +
+		`]`
+	if got := renderedField(t, xc, "pools"); got != want {
+		t.Errorf("pools =\n%s\nwant\n%s", got, want)
+	}
+
+	cfg.Pools = append(cfg.Pools, PoolConfig{Mode: modeStratum, Host: "bad.example.org", WalletAddress: testWallet})
+	if _, err := buildXMRigConfig(cfg, 18000, ""); err == nil || !strings.HasPrefix(err.Error(), "backup pool 3:") {
+		t.Errorf("invalid backup pool: error = %v, want one naming backup pool 3", err)
+	}
+	cfg.StratumHost = ""
+	if _, err := buildXMRigConfig(cfg, 18000, ""); err == nil || strings.HasPrefix(err.Error(), "backup pool") {
+		t.Errorf("invalid primary pool: error = %v", err)
+	}
+}